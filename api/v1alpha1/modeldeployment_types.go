@@ -0,0 +1,153 @@
+/*
+Copyright 2023 Willem Meints.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ModelDeploymentSpec defines the desired state of ModelDeployment
+type ModelDeploymentSpec struct {
+	// Workspace is the name of the workspace in the same namespace with the MLFlow server that registers the model
+	// +kubebuilder:validation:MinLength=1
+	Workspace string `json:"workspace"`
+
+	// ModelName is the name of the registered model in MLFlow
+	// +kubebuilder:validation:MinLength=1
+	ModelName string `json:"modelName"`
+
+	// Stage serves the latest version of the model in the stage. New versions in the stage are rolled out automatically.
+	// +optional
+	Stage ModelStage `json:"stage,omitempty"`
+
+	// Version serves a fixed version of the model. It can't be combined with a stage.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Replicas is the number of replicas that serve the model. It can't be combined with autoscaling.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Autoscaling scales the number of replicas with the number of requests
+	// +optional
+	Autoscaling *ModelAutoscalingSpec `json:"autoscaling,omitempty"`
+
+	// PipPackages are the additional python packages the model needs. The requirements of the model in MLFlow are always installed.
+	// +optional
+	PipPackages []string `json:"pipPackages,omitempty"`
+
+	// WorkerPool is the worker pool of the compute cluster in the workspace that runs the replicas of the model.
+	// The model gets a cluster with only this worker pool. Defaults to the first worker pool of the workspace.
+	// +optional
+	WorkerPool string `json:"workerPool,omitempty"`
+}
+
+// ModelStage is a stage of a registered model in MLFlow
+// +kubebuilder:validation:Enum=Staging;Production
+type ModelStage string
+
+const (
+	// ModelStageStaging is the stage for versions of a model that are being validated
+	ModelStageStaging ModelStage = "Staging"
+	// ModelStageProduction is the stage for versions of a model that are used in production
+	ModelStageProduction ModelStage = "Production"
+)
+
+// ModelAutoscalingSpec defines how ray serve scales the replicas of a model
+type ModelAutoscalingSpec struct {
+	// MinReplicas is the lowest number of replicas
+	// +kubebuilder:validation:Minimum=0
+	MinReplicas int32 `json:"minReplicas"`
+
+	// MaxReplicas is the highest number of replicas
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// TargetOngoingRequests is the number of requests a replica handles at the same time before ray serve scales up
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetOngoingRequests *int32 `json:"targetOngoingRequests,omitempty"`
+}
+
+// ModelDeploymentPhase describes the state of a model deployment
+// +kubebuilder:validation:Enum=Pending;Deploying;Available;Failed
+type ModelDeploymentPhase string
+
+const (
+	// ModelDeploymentPhasePending indicates that the model can't be deployed yet
+	ModelDeploymentPhasePending ModelDeploymentPhase = "Pending"
+	// ModelDeploymentPhaseDeploying indicates that ray is deploying a version of the model
+	ModelDeploymentPhaseDeploying ModelDeploymentPhase = "Deploying"
+	// ModelDeploymentPhaseAvailable indicates that the model serves requests
+	ModelDeploymentPhaseAvailable ModelDeploymentPhase = "Available"
+	// ModelDeploymentPhaseFailed indicates that ray failed to deploy the model
+	ModelDeploymentPhaseFailed ModelDeploymentPhase = "Failed"
+)
+
+// ModelDeploymentStatus defines the observed state of ModelDeployment
+type ModelDeploymentStatus struct {
+	// Phase summarizes the state of the deployment
+	// +optional
+	Phase ModelDeploymentPhase `json:"phase,omitempty"`
+
+	// Message explains the phase of the deployment
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// ModelVersion is the version of the model that is being deployed or served
+	// +optional
+	ModelVersion string `json:"modelVersion,omitempty"`
+
+	// URL is the address of the model inside the cluster
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// ObservedGeneration is the generation of the spec the status describes
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Workspace",type=string,JSONPath=`.spec.workspace`
+//+kubebuilder:printcolumn:name="Model",type=string,JSONPath=`.spec.modelName`
+//+kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.status.modelVersion`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ModelDeployment is the Schema for the modeldeployments API
+type ModelDeployment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ModelDeploymentSpec   `json:"spec,omitempty"`
+	Status ModelDeploymentStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ModelDeploymentList contains a list of ModelDeployment
+type ModelDeploymentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ModelDeployment `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ModelDeployment{}, &ModelDeploymentList{})
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 Willem Meints.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var modeldeploymentlog = logf.Log.WithName("modeldeployment-resource")
+
+func (r *ModelDeployment) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-mlops-aigency-com-v1alpha1-modeldeployment,mutating=true,failurePolicy=fail,sideEffects=None,groups=mlops.aigency.com,resources=modeldeployments,verbs=create;update,versions=v1alpha1,name=mmodeldeployment.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &ModelDeployment{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *ModelDeployment) Default() {
+	modeldeploymentlog.Info("Providing defaults for model deployment", "modelDeploymentName", r.Name)
+
+	if r.Spec.Stage == "" && r.Spec.Version == "" {
+		r.Spec.Stage = ModelStageProduction
+	}
+
+	if r.Spec.Replicas == nil && r.Spec.Autoscaling == nil {
+		r.Spec.Replicas = pointer.Int32(1)
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-mlops-aigency-com-v1alpha1-modeldeployment,mutating=false,failurePolicy=fail,sideEffects=None,groups=mlops.aigency.com,resources=modeldeployments,verbs=create;update,versions=v1alpha1,name=vmodeldeployment.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &ModelDeployment{}
+
+func (r *ModelDeployment) ValidateCreate() error {
+	return r.validate()
+}
+
+func (r *ModelDeployment) ValidateUpdate(old runtime.Object) error {
+	return r.validate()
+}
+
+func (r *ModelDeployment) ValidateDelete() error {
+	return nil
+}
+
+func (r *ModelDeployment) validate() error {
+	validationErrors := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	if r.Spec.Stage != "" && r.Spec.Version != "" {
+		validationErrors = append(validationErrors, field.Invalid(
+			specPath,
+			[]string{"stage", "version"},
+			"only one of stage or version can be configured",
+		))
+	}
+
+	if r.Spec.Replicas != nil && r.Spec.Autoscaling != nil {
+		validationErrors = append(validationErrors, field.Invalid(
+			specPath,
+			[]string{"replicas", "autoscaling"},
+			"only one of replicas or autoscaling can be configured",
+		))
+	}
+
+	if autoscaling := r.Spec.Autoscaling; autoscaling != nil && autoscaling.MinReplicas > autoscaling.MaxReplicas {
+		validationErrors = append(validationErrors, field.Invalid(
+			specPath.Child("autoscaling").Child("minReplicas"),
+			autoscaling.MinReplicas,
+			"minReplicas can't be higher than maxReplicas",
+		))
+	}
+
+	if len(validationErrors) > 0 {
+		groupKind := schema.GroupKind{Group: "mlops.aigency.com", Kind: "ModelDeployment"}
+		return apierrors.NewInvalid(groupKind, r.Name, validationErrors)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("Model deployment webhook", func() {
+	It("Should serve one replica of the production version by default", func() {
+		modelDeployment := &ModelDeployment{}
+		modelDeployment.Default()
+
+		Expect(modelDeployment.Spec.Stage).To(Equal(ModelStageProduction))
+		Expect(modelDeployment.Spec.Replicas).To(Equal(pointer.Int32(1)))
+		Expect(modelDeployment.ValidateCreate()).To(Succeed())
+	})
+
+	It("Should keep a fixed version without a stage", func() {
+		modelDeployment := &ModelDeployment{}
+		modelDeployment.Spec.Version = "3"
+		modelDeployment.Default()
+
+		Expect(modelDeployment.Spec.Stage).To(BeEmpty())
+		Expect(modelDeployment.ValidateCreate()).To(Succeed())
+	})
+
+	It("Should reject a stage combined with a version", func() {
+		modelDeployment := &ModelDeployment{}
+		modelDeployment.Spec.Stage = ModelStageStaging
+		modelDeployment.Spec.Version = "3"
+
+		Expect(modelDeployment.ValidateCreate()).NotTo(Succeed())
+	})
+
+	It("Should reject replicas combined with autoscaling", func() {
+		modelDeployment := &ModelDeployment{}
+		modelDeployment.Spec.Autoscaling = &ModelAutoscalingSpec{MinReplicas: 1, MaxReplicas: 4}
+		modelDeployment.Default()
+
+		Expect(modelDeployment.Spec.Replicas).To(BeNil())
+		Expect(modelDeployment.ValidateCreate()).To(Succeed())
+
+		modelDeployment.Spec.Replicas = pointer.Int32(2)
+
+		Expect(modelDeployment.ValidateCreate()).NotTo(Succeed())
+	})
+
+	It("Should reject more minimum than maximum replicas", func() {
+		modelDeployment := &ModelDeployment{}
+		modelDeployment.Spec.Autoscaling = &ModelAutoscalingSpec{MinReplicas: 5, MaxReplicas: 2}
+
+		Expect(modelDeployment.ValidateCreate()).NotTo(Succeed())
+	})
+})
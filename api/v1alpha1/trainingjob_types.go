@@ -0,0 +1,169 @@
+/*
+Copyright 2023 Willem Meints.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TrainingJobSpec defines the desired state of TrainingJob
+type TrainingJobSpec struct {
+	// Workspace is the name of the workspace in the same namespace that runs the job
+	// +kubebuilder:validation:MinLength=1
+	Workspace string `json:"workspace"`
+
+	// Entrypoint is the shell command that starts the job, for example python train.py
+	// +kubebuilder:validation:MinLength=1
+	Entrypoint string `json:"entrypoint"`
+
+	// RuntimeEnvironment defines the packages, code, and environment variables for the job
+	// +optional
+	RuntimeEnvironment RuntimeEnvironmentSpec `json:"runtimeEnvironment,omitempty"`
+
+	// ExperimentName is the name of the MLFlow experiment to record the run of the job in
+	// +optional
+	ExperimentName string `json:"experimentName,omitempty"`
+
+	// Cluster determines whether the job runs on the compute cluster of the workspace
+	// or on a cluster that is created for the job only
+	// +optional
+	Cluster TrainingJobClusterMode `json:"cluster,omitempty"`
+}
+
+// TrainingJobClusterMode determines which ray cluster runs a training job
+// +kubebuilder:validation:Enum=Shared;Ephemeral
+type TrainingJobClusterMode string
+
+const (
+	// TrainingJobClusterModeShared runs the job on the compute cluster of the workspace
+	TrainingJobClusterModeShared TrainingJobClusterMode = "Shared"
+	// TrainingJobClusterModeEphemeral runs the job on a new cluster that is removed when the job finishes
+	TrainingJobClusterModeEphemeral TrainingJobClusterMode = "Ephemeral"
+)
+
+// RuntimeEnvironmentSpec defines the runtime environment ray prepares for a job
+type RuntimeEnvironmentSpec struct {
+	// PipPackages are the python packages to install for the job
+	// +optional
+	PipPackages []string `json:"pipPackages,omitempty"`
+
+	// WorkingDirectory defines where the code of the job comes from
+	// +optional
+	WorkingDirectory *WorkingDirectorySpec `json:"workingDirectory,omitempty"`
+
+	// EnvironmentVariables are the additional environment variables for the job
+	// +optional
+	EnvironmentVariables map[string]string `json:"environmentVariables,omitempty"`
+}
+
+// WorkingDirectorySpec defines where the code of a job comes from. Only one source can be configured.
+type WorkingDirectorySpec struct {
+	// ConfigMapRef references a config map with the files of the job. It's only supported on ephemeral clusters.
+	// +optional
+	ConfigMapRef *corev1.LocalObjectReference `json:"configMapRef,omitempty"`
+
+	// URL is the address of a zip file with the code of the job, for example s3://bucket/job.zip
+	// +optional
+	URL string `json:"url,omitempty"`
+}
+
+// TrainingJobPhase describes the state of a training job
+// +kubebuilder:validation:Enum=Pending;Running;Succeeded;Failed;Stopped
+type TrainingJobPhase string
+
+const (
+	// TrainingJobPhasePending indicates that the job hasn't started yet
+	TrainingJobPhasePending TrainingJobPhase = "Pending"
+	// TrainingJobPhaseRunning indicates that the job runs on the ray cluster
+	TrainingJobPhaseRunning TrainingJobPhase = "Running"
+	// TrainingJobPhaseSucceeded indicates that the job finished successfully
+	TrainingJobPhaseSucceeded TrainingJobPhase = "Succeeded"
+	// TrainingJobPhaseFailed indicates that the job failed
+	TrainingJobPhaseFailed TrainingJobPhase = "Failed"
+	// TrainingJobPhaseStopped indicates that the job was stopped before it finished
+	TrainingJobPhaseStopped TrainingJobPhase = "Stopped"
+)
+
+// IsFinished returns true when the job will no longer change state
+func (p TrainingJobPhase) IsFinished() bool {
+	return p == TrainingJobPhaseSucceeded || p == TrainingJobPhaseFailed || p == TrainingJobPhaseStopped
+}
+
+// TrainingJobStatus defines the observed state of TrainingJob
+type TrainingJobStatus struct {
+	// Phase summarizes the state of the job
+	// +optional
+	Phase TrainingJobPhase `json:"phase,omitempty"`
+
+	// Message explains the phase of the job
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// JobID is the identifier of the job in the ray cluster
+	// +optional
+	JobID string `json:"jobId,omitempty"`
+
+	// RayClusterName is the name of the ray cluster that runs the job
+	// +optional
+	RayClusterName string `json:"rayClusterName,omitempty"`
+
+	// ExperimentID is the identifier of the MLFlow experiment the job is recorded in
+	// +optional
+	ExperimentID string `json:"experimentId,omitempty"`
+
+	// RunID is the identifier of the MLFlow run for the job
+	// +optional
+	RunID string `json:"runId,omitempty"`
+
+	// StartTime is the time the ray cluster started the job
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time the job finished
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Workspace",type=string,JSONPath=`.spec.workspace`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Run",type=string,JSONPath=`.status.runId`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// TrainingJob is the Schema for the trainingjobs API
+type TrainingJob struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TrainingJobSpec   `json:"spec,omitempty"`
+	Status TrainingJobStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// TrainingJobList contains a list of TrainingJob
+type TrainingJobList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TrainingJob `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&TrainingJob{}, &TrainingJobList{})
+}
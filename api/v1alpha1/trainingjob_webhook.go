@@ -0,0 +1,121 @@
+/*
+Copyright 2023 Willem Meints.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var trainingjoblog = logf.Log.WithName("trainingjob-resource")
+
+// DefaultExperimentName is the experiment MLFlow records runs in when no experiment is specified.
+const DefaultExperimentName = "Default"
+
+func (r *TrainingJob) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-mlops-aigency-com-v1alpha1-trainingjob,mutating=true,failurePolicy=fail,sideEffects=None,groups=mlops.aigency.com,resources=trainingjobs,verbs=create;update,versions=v1alpha1,name=mtrainingjob.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &TrainingJob{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *TrainingJob) Default() {
+	trainingjoblog.Info("Providing defaults for training job", "trainingJobName", r.Name)
+
+	if r.Spec.ExperimentName == "" {
+		r.Spec.ExperimentName = DefaultExperimentName
+	}
+
+	if r.Spec.Cluster == "" {
+		r.Spec.Cluster = TrainingJobClusterModeShared
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-mlops-aigency-com-v1alpha1-trainingjob,mutating=false,failurePolicy=fail,sideEffects=None,groups=mlops.aigency.com,resources=trainingjobs,verbs=create;update,versions=v1alpha1,name=vtrainingjob.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &TrainingJob{}
+
+func (r *TrainingJob) ValidateCreate() error {
+	return r.validate(validateWorkingDirectory(r))
+}
+
+// ValidateUpdate rejects changes to the spec. The job is submitted to ray once, so changes would never reach it.
+func (r *TrainingJob) ValidateUpdate(old runtime.Object) error {
+	validationErrors := validateWorkingDirectory(r)
+
+	if oldTrainingJob, ok := old.(*TrainingJob); ok && !apiequality.Semantic.DeepEqual(r.Spec, oldTrainingJob.Spec) {
+		validationErrors = append(validationErrors, field.Forbidden(
+			field.NewPath("spec"),
+			"the spec of a training job can't be changed, create a new training job instead",
+		))
+	}
+
+	return r.validate(validationErrors)
+}
+
+func (r *TrainingJob) ValidateDelete() error {
+	return nil
+}
+
+func (r *TrainingJob) validate(validationErrors field.ErrorList) error {
+	if len(validationErrors) > 0 {
+		groupKind := schema.GroupKind{Group: "mlops.aigency.com", Kind: "TrainingJob"}
+		return apierrors.NewInvalid(groupKind, r.Name, validationErrors)
+	}
+
+	return nil
+}
+
+func validateWorkingDirectory(r *TrainingJob) field.ErrorList {
+	validationErrors := field.ErrorList{}
+	workingDirectory := r.Spec.RuntimeEnvironment.WorkingDirectory
+	workingDirectoryPath := field.NewPath("spec").Child("runtimeEnvironment").Child("workingDirectory")
+
+	if workingDirectory == nil {
+		return validationErrors
+	}
+
+	if workingDirectory.ConfigMapRef != nil && workingDirectory.URL != "" {
+		validationErrors = append(validationErrors, field.Invalid(
+			workingDirectoryPath,
+			[]string{"configMapRef", "url"},
+			"only one working directory source can be configured",
+		))
+	}
+
+	// The config map is mounted into the pods of the cluster, which can't be done for the shared cluster without restarting it.
+	if workingDirectory.ConfigMapRef != nil && r.Spec.Cluster != TrainingJobClusterModeEphemeral {
+		validationErrors = append(validationErrors, field.Invalid(
+			workingDirectoryPath.Child("configMapRef"),
+			workingDirectory.ConfigMapRef.Name,
+			"a working directory from a config map requires an ephemeral cluster",
+		))
+	}
+
+	return validationErrors
+}
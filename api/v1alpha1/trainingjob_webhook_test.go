@@ -0,0 +1,57 @@
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Training job webhook", func() {
+	It("Should run the job on the shared cluster in the default experiment", func() {
+		trainingJob := &TrainingJob{}
+		trainingJob.Default()
+
+		Expect(trainingJob.Spec.Cluster).To(Equal(TrainingJobClusterModeShared))
+		Expect(trainingJob.Spec.ExperimentName).To(Equal("Default"))
+	})
+
+	It("Should only allow a working directory from a config map on an ephemeral cluster", func() {
+		trainingJob := &TrainingJob{}
+		trainingJob.Spec.RuntimeEnvironment.WorkingDirectory = &WorkingDirectorySpec{
+			ConfigMapRef: &corev1.LocalObjectReference{Name: "training-code"},
+		}
+		trainingJob.Default()
+
+		Expect(trainingJob.ValidateCreate()).NotTo(Succeed())
+
+		trainingJob.Spec.Cluster = TrainingJobClusterModeEphemeral
+
+		Expect(trainingJob.ValidateCreate()).To(Succeed())
+	})
+
+	It("Should reject multiple working directory sources", func() {
+		trainingJob := &TrainingJob{}
+		trainingJob.Spec.Cluster = TrainingJobClusterModeEphemeral
+		trainingJob.Spec.RuntimeEnvironment.WorkingDirectory = &WorkingDirectorySpec{
+			ConfigMapRef: &corev1.LocalObjectReference{Name: "training-code"},
+			URL:          "s3://jobs/train.zip",
+		}
+
+		Expect(trainingJob.ValidateCreate()).NotTo(Succeed())
+	})
+
+	It("Should reject changes to the spec", func() {
+		oldTrainingJob := &TrainingJob{}
+		oldTrainingJob.Spec.Entrypoint = "python train.py"
+		oldTrainingJob.Default()
+
+		trainingJob := oldTrainingJob.DeepCopy()
+		trainingJob.Labels = map[string]string{"team": "forecasting"}
+
+		Expect(trainingJob.ValidateUpdate(oldTrainingJob)).To(Succeed())
+
+		trainingJob.Spec.Entrypoint = "python evaluate.py"
+
+		Expect(trainingJob.ValidateUpdate(oldTrainingJob)).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,30 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func defaultAuthenticationSpec(r *Workspace) {
+	if r.Spec.Authentication == nil {
+		return
+	}
+
+	if r.Spec.Authentication.Image == "" {
+		r.Spec.Authentication.Image = "quay.io/oauth2-proxy/oauth2-proxy:v7.4.0"
+	}
+
+	if len(r.Spec.Authentication.Resources.Limits) == 0 {
+		r.Spec.Authentication.Resources.Limits = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("200m"),
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+		}
+	}
+
+	if len(r.Spec.Authentication.Resources.Requests) == 0 {
+		r.Spec.Authentication.Resources.Requests = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("50m"),
+			corev1.ResourceMemory: resource.MustParse("64Mi"),
+		}
+	}
+}
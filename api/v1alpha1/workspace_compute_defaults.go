@@ -9,9 +9,49 @@ import (
 	"k8s.io/utils/pointer"
 )
 
+const (
+	// NvidiaGPUResourceName is the extended resource of the NVIDIA device plugin.
+	NvidiaGPUResourceName corev1.ResourceName = "nvidia.com/gpu"
+
+	// AcceleratorTypeNodeLabel is the node label the NVIDIA GPU feature discovery sets to the type of GPU.
+	AcceleratorTypeNodeLabel = "nvidia.com/gpu.product"
+)
+
 func defaultComputeClusterSpec(r *Workspace) {
 	defaultComputerClusterControllerSpec(r)
 	defaultComputeWorkerPoolSpecs(r)
+	defaultAutoscalingSpec(r)
+	defaultFaultToleranceSpec(r)
+}
+
+func defaultAutoscalingSpec(r *Workspace) {
+	autoscaling := &r.Spec.Compute.Autoscaling
+
+	if !autoscaling.Enabled {
+		return
+	}
+
+	if autoscaling.IdleTimeoutSeconds == nil {
+		autoscaling.IdleTimeoutSeconds = pointer.Int32(60)
+	}
+
+	if autoscaling.UpscalingMode == "" {
+		autoscaling.UpscalingMode = "Default"
+	}
+
+	if len(autoscaling.Resources.Requests) == 0 {
+		autoscaling.Resources.Requests = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("512Mi"),
+		}
+	}
+
+	if len(autoscaling.Resources.Limits) == 0 {
+		autoscaling.Resources.Limits = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("512Mi"),
+		}
+	}
 }
 
 func defaultComputerClusterControllerSpec(r *Workspace) {
@@ -46,8 +86,15 @@ func defaultComputeWorkerPoolSpecs(r *Workspace) {
 	workerGroups := []ComputeWorkerPoolSpec{}
 
 	for _, workerPoolSpec := range r.Spec.Compute.WorkerPools {
+		defaultAcceleratorSpec(workerPoolSpec.Accelerators)
+
 		if workerPoolSpec.Image == "" {
 			workerPoolSpec.Image = fmt.Sprintf("rayproject/ray:%s", r.Spec.Compute.RayVersion)
+
+			// The GPU flavour of the Ray image includes CUDA.
+			if workerPoolSpec.Accelerators != nil && workerPoolSpec.Accelerators.ResourceName == NvidiaGPUResourceName {
+				workerPoolSpec.Image = fmt.Sprintf("rayproject/ray:%s-gpu", r.Spec.Compute.RayVersion)
+			}
 		}
 
 		if workerPoolSpec.MinReplicas == nil {
@@ -82,3 +129,18 @@ func defaultComputeWorkerPoolSpecs(r *Workspace) {
 
 	r.Spec.Compute.WorkerPools = workerGroups
 }
+
+func defaultAcceleratorSpec(acceleratorSpec *AcceleratorSpec) {
+	if acceleratorSpec == nil {
+		return
+	}
+
+	if acceleratorSpec.ResourceName == "" {
+		acceleratorSpec.ResourceName = NvidiaGPUResourceName
+	}
+
+	// Only NVIDIA has a well-known label for the type of accelerator, the webhook requires it for other accelerators.
+	if acceleratorSpec.Type != "" && acceleratorSpec.NodeLabel == "" && acceleratorSpec.ResourceName == NvidiaGPUResourceName {
+		acceleratorSpec.NodeLabel = AcceleratorTypeNodeLabel
+	}
+}
@@ -0,0 +1,15 @@
+package v1alpha1
+
+func defaultExposureSpec(r *Workspace) {
+	if r.Spec.Exposure.Mode == "" {
+		r.Spec.Exposure.Mode = ExposureModeNone
+	}
+
+	if r.Spec.Exposure.Routing == "" {
+		r.Spec.Exposure.Routing = ExposureRoutingHost
+	}
+
+	if r.Spec.Exposure.TLS != nil && r.Spec.Exposure.TLS.IssuerRef.Kind == "" {
+		r.Spec.Exposure.TLS.IssuerRef.Kind = "Issuer"
+	}
+}
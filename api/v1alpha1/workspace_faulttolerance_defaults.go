@@ -0,0 +1,28 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func defaultFaultToleranceSpec(r *Workspace) {
+	faultTolerance := &r.Spec.Compute.FaultTolerance
+
+	if faultTolerance.Image == "" {
+		faultTolerance.Image = "redis:7.0.10"
+	}
+
+	if len(faultTolerance.Resources.Limits) == 0 {
+		faultTolerance.Resources.Limits = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("512Mi"),
+		}
+	}
+
+	if len(faultTolerance.Resources.Requests) == 0 {
+		faultTolerance.Resources.Requests = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("100m"),
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package v1alpha1
+
+func defaultMembers(r *Workspace) {
+	for index := range r.Spec.Members {
+		if r.Spec.Members[index].Kind == "" {
+			r.Spec.Members[index].Kind = WorkspaceMemberKindUser
+		}
+	}
+}
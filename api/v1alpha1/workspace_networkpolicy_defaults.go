@@ -0,0 +1,29 @@
+package v1alpha1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+func defaultNetworkPolicySpec(r *Workspace) {
+	if r.Spec.NetworkPolicy.IngressNamespaceSelector == nil {
+		r.Spec.NetworkPolicy.IngressNamespaceSelector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"kubernetes.io/metadata.name": "ingress-nginx",
+			},
+		}
+	}
+
+	if r.Spec.NetworkPolicy.OperatorNamespaceSelector == nil {
+		r.Spec.NetworkPolicy.OperatorNamespaceSelector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"kubernetes.io/metadata.name": "cartographer-system",
+			},
+		}
+	}
+
+	if r.Spec.NetworkPolicy.KubeRayNamespaceSelector == nil {
+		r.Spec.NetworkPolicy.KubeRayNamespaceSelector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"kubernetes.io/metadata.name": "ray-system",
+			},
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func defaultObjectStorageSpec(r *Workspace) {
+	if r.Spec.ObjectStorage.Image == "" {
+		r.Spec.ObjectStorage.Image = "minio/minio:RELEASE.2023-03-13T19-46-17Z"
+	}
+
+	if len(r.Spec.ObjectStorage.Resources.Limits) == 0 {
+		r.Spec.ObjectStorage.Resources.Limits = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		}
+	}
+
+	if len(r.Spec.ObjectStorage.Resources.Requests) == 0 {
+		r.Spec.ObjectStorage.Resources.Requests = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("100m"),
+			corev1.ResourceMemory: resource.MustParse("256Mi"),
+		}
+	}
+}
@@ -10,4 +10,18 @@ func defaultStorageSpec(r *Workspace) {
 	if r.Spec.Storage.DatabaseBackupStorage.IsZero() {
 		r.Spec.Storage.DatabaseBackupStorage = resource.MustParse("10Gi")
 	}
+
+	if r.Spec.Storage.ObjectStorage.IsZero() {
+		r.Spec.Storage.ObjectStorage = resource.MustParse("10Gi")
+	}
+
+	if r.Spec.Storage.FaultTolerance.IsZero() {
+		r.Spec.Storage.FaultTolerance = resource.MustParse("1Gi")
+	}
+}
+
+func defaultDeletionPolicy(r *Workspace) {
+	if r.Spec.DeletionPolicy == "" {
+		r.Spec.DeletionPolicy = DeletionPolicyDelete
+	}
 }
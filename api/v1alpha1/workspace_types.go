@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -33,10 +34,339 @@ type WorkspaceSpec struct {
 	Storage WorkspaceStorageSpec `json:"storage,omitempty"`
 
 	Compute ComputeSpec `json:"compute,omitempty"`
+
+	// Database defines the configuration for the postgres cluster in the workspace
+	// +optional
+	Database DatabaseSpec `json:"database,omitempty"`
+
+	// ObjectStorage defines the configuration for the object storage in the workspace
+	// +optional
+	ObjectStorage ObjectStorageSpec `json:"objectStorage,omitempty"`
+
+	// Exposure defines how the user interfaces in the workspace are exposed outside the cluster
+	// +optional
+	Exposure ExposureSpec `json:"exposure,omitempty"`
+
+	// Authentication puts an OIDC authentication proxy in front of the user interfaces
+	// +optional
+	Authentication *AuthenticationSpec `json:"authentication,omitempty"`
+
+	// Members are the users and groups that can use the workspace
+	// +optional
+	Members []WorkspaceMember `json:"members,omitempty"`
+
+	// NetworkPolicy isolates the components of the workspace from other pods in the cluster
+	// +optional
+	NetworkPolicy NetworkPolicySpec `json:"networkPolicy,omitempty"`
+
+	// DeletionPolicy controls what happens to the database and backups when the workspace is deleted
+	// +kubebuilder:default=Delete
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// DeletionPolicy describes what happens to the data of a workspace when the workspace is deleted
+// +kubebuilder:validation:Enum=Delete;Retain;Snapshot
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete removes the database and its backups together with the workspace
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyRetain keeps the database and its backup storage, so a new workspace can adopt them
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	// DeletionPolicySnapshot creates a final backup of the database and keeps the backup storage
+	DeletionPolicySnapshot DeletionPolicy = "Snapshot"
+)
+
+// ExposureMode describes the kind of resources used to expose the user interfaces
+// +kubebuilder:validation:Enum=None;Ingress;Gateway
+type ExposureMode string
+
+const (
+	// ExposureModeNone keeps the user interfaces inside the cluster
+	ExposureModeNone ExposureMode = "None"
+	// ExposureModeIngress exposes the user interfaces with an Ingress
+	ExposureModeIngress ExposureMode = "Ingress"
+	// ExposureModeGateway exposes the user interfaces with Gateway API HTTPRoutes
+	ExposureModeGateway ExposureMode = "Gateway"
+)
+
+// ExposureRouting describes how requests are routed to the user interfaces
+// +kubebuilder:validation:Enum=Host;Path
+type ExposureRouting string
+
+const (
+	// ExposureRoutingHost routes requests based on a subdomain of the hostname, like mlflow.example.com
+	ExposureRoutingHost ExposureRouting = "Host"
+	// ExposureRoutingPath routes requests based on a path under the hostname, like example.com/mlflow
+	ExposureRoutingPath ExposureRouting = "Path"
+)
+
+// ExposureSpec defines how the user interfaces in the workspace are exposed
+type ExposureSpec struct {
+	// Mode determines the kind of resources used to expose the user interfaces
+	// +kubebuilder:default=None
+	// +optional
+	Mode ExposureMode `json:"mode,omitempty"`
+
+	// Routing determines how requests are routed to the user interfaces
+	// +kubebuilder:default=Host
+	// +optional
+	Routing ExposureRouting `json:"routing,omitempty"`
+
+	// Hostname is the hostname under which the user interfaces are exposed
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+
+	// IngressClassName is the ingress class to use in the Ingress mode
+	// +optional
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+
+	// Annotations are added to the Ingress or HTTPRoutes, for example to configure the ingress controller
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Gateway is the gateway the HTTPRoutes attach to in the Gateway mode
+	// +optional
+	Gateway *GatewayReference `json:"gateway,omitempty"`
+
+	// TLS configures a certificate from cert-manager for the hostname
+	// +optional
+	TLS *ExposureTLSSpec `json:"tls,omitempty"`
+}
+
+// GatewayReference refers to a Gateway API gateway
+type GatewayReference struct {
+	// Name is the name of the gateway
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the gateway, defaults to the namespace of the workspace
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// SectionName is the name of the listener on the gateway
+	// +optional
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+// ExposureTLSSpec defines the certificate for the exposed user interfaces
+type ExposureTLSSpec struct {
+	// IssuerRef is the cert-manager issuer that issues the certificate
+	IssuerRef IssuerReference `json:"issuerRef"`
+}
+
+// IssuerReference refers to a cert-manager Issuer or ClusterIssuer
+type IssuerReference struct {
+	// Name is the name of the issuer
+	Name string `json:"name"`
+
+	// Kind is the kind of issuer
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	// +kubebuilder:default=Issuer
+	// +optional
+	Kind string `json:"kind,omitempty"`
+}
+
+// AuthenticationSpec defines the OIDC provider that users sign in with before they can use the user interfaces
+type AuthenticationSpec struct {
+	// IssuerURL is the address of the OIDC provider
+	IssuerURL string `json:"issuerURL"`
+
+	// ClientID is the identifier of the workspace in the OIDC provider
+	ClientID string `json:"clientID"`
+
+	// ClientSecretRef refers to the key of the secret that contains the client secret
+	ClientSecretRef corev1.SecretKeySelector `json:"clientSecretRef"`
+
+	// AllowedGroups limits access to members of these groups. Every authenticated user has access when empty
+	// +optional
+	AllowedGroups []string `json:"allowedGroups,omitempty"`
+
+	// Image defines the docker image to use for the authentication proxy
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Resources define the resource requirements for the authentication proxy
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Scheduling configures the nodes the authentication proxies run on
+	// +optional
+	Scheduling SchedulingSpec `json:"scheduling,omitempty"`
 }
 
+// WorkspaceMemberKind describes whether a member is a user or a group
+// +kubebuilder:validation:Enum=User;Group
+type WorkspaceMemberKind string
+
+const (
+	// WorkspaceMemberKindUser is a single user
+	WorkspaceMemberKindUser WorkspaceMemberKind = "User"
+	// WorkspaceMemberKindGroup is a group of users
+	WorkspaceMemberKindGroup WorkspaceMemberKind = "Group"
+)
+
+// WorkspaceRole describes what a member can do in the workspace
+// +kubebuilder:validation:Enum=Owner;Contributor;Viewer
+type WorkspaceRole string
+
+const (
+	// WorkspaceRoleOwner can change the workspace and manage its secrets
+	WorkspaceRoleOwner WorkspaceRole = "Owner"
+	// WorkspaceRoleContributor can run jobs and connect to the workloads in the workspace
+	WorkspaceRoleContributor WorkspaceRole = "Contributor"
+	// WorkspaceRoleViewer can look at the workloads and their logs
+	WorkspaceRoleViewer WorkspaceRole = "Viewer"
+)
+
+// WorkspaceMember defines a user or group and its role in the workspace
+type WorkspaceMember struct {
+	// Kind determines whether the member is a user or a group
+	// +kubebuilder:default=User
+	// +optional
+	Kind WorkspaceMemberKind `json:"kind,omitempty"`
+
+	// Name is the name of the user or group as known to the Kubernetes API server and the OIDC provider
+	Name string `json:"name"`
+
+	// Role determines what the member can do in the workspace
+	Role WorkspaceRole `json:"role"`
+}
+
+// ServiceAccountSpec defines the service account the pods of a component run as
+type ServiceAccountSpec struct {
+	// Annotations are added to the service account, for example to configure cloud workload identity
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// ImagePullSecrets are used to pull the images of the pods that run as the service account
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+}
+
+// DatabaseSpec defines the configuration for the postgres cluster
+type DatabaseSpec struct {
+	// Scheduling configures the nodes the database runs on
+	// +optional
+	Scheduling SchedulingSpec `json:"scheduling,omitempty"`
+}
+
+// SchedulingSpec defines where the pods of a component run in the cluster
+type SchedulingSpec struct {
+	// NodeSelector limits the pods to the nodes with these labels
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations allow the pods to run on nodes with matching taints
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity defines the node affinity and pod (anti-)affinity rules of the pods
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// TopologySpreadConstraints spread the pods across the cluster. Constraints without a label selector
+	// select the pods of the component.
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// PriorityClassName is the priority class of the pods
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+}
+
+// NetworkPolicySpec defines the network policies for the workspace
+type NetworkPolicySpec struct {
+	// Enabled generates network policies that only allow traffic between the components of the workspace
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// IngressNamespaceSelector selects the namespaces of the ingress controller or gateway that can reach the user interfaces
+	// +optional
+	IngressNamespaceSelector *metav1.LabelSelector `json:"ingressNamespaceSelector,omitempty"`
+
+	// OperatorNamespaceSelector selects the namespace of this operator, which records training jobs in MLflow
+	// +optional
+	OperatorNamespaceSelector *metav1.LabelSelector `json:"operatorNamespaceSelector,omitempty"`
+
+	// KubeRayNamespaceSelector selects the namespace of the KubeRay operator, which submits jobs to the ray dashboard
+	// +optional
+	KubeRayNamespaceSelector *metav1.LabelSelector `json:"kubeRayNamespaceSelector,omitempty"`
+
+	// AllowedPeers can reach the experiment tracking server, workflow server, compute cluster, and object storage,
+	// for example notebooks or CI pipelines outside the workspace
+	// +optional
+	AllowedPeers []networkingv1.NetworkPolicyPeer `json:"allowedPeers,omitempty"`
+}
+
+// WorkspacePhase describes the overall state of the workspace
+// +kubebuilder:validation:Enum=Provisioning;Ready;Failed
+type WorkspacePhase string
+
+const (
+	// WorkspacePhaseProvisioning indicates that one or more components are not ready yet
+	WorkspacePhaseProvisioning WorkspacePhase = "Provisioning"
+	// WorkspacePhaseReady indicates that all components in the workspace are ready
+	WorkspacePhaseReady WorkspacePhase = "Ready"
+	// WorkspacePhaseFailed indicates that the operator failed to reconcile the workspace
+	WorkspacePhaseFailed WorkspacePhase = "Failed"
+)
+
+const (
+	// ConditionTypeReady is true when all components in the workspace are ready
+	ConditionTypeReady = "Ready"
+	// ConditionTypeDatabaseReady is true when the postgres cluster for the workspace is ready
+	ConditionTypeDatabaseReady = "DatabaseReady"
+	// ConditionTypeExperimentTrackingReady is true when the MLFlow server is ready
+	ConditionTypeExperimentTrackingReady = "ExperimentTrackingReady"
+	// ConditionTypeWorkflowsReady is true when the Prefect server and all agent pools are ready
+	ConditionTypeWorkflowsReady = "WorkflowsReady"
+	// ConditionTypeComputeReady is true when the Ray cluster is ready
+	ConditionTypeComputeReady = "ComputeReady"
+	// ConditionTypeObjectStorageReady is true when the MinIO server is ready or the object storage is disabled
+	ConditionTypeObjectStorageReady = "ObjectStorageReady"
+	// ConditionTypeFaultToleranceReady is true when the Redis instance for the Ray head is ready or fault tolerance is disabled
+	ConditionTypeFaultToleranceReady = "FaultToleranceReady"
+	// ConditionTypeExposureReady is true when the user interfaces are exposed or the workspace isn't exposed
+	ConditionTypeExposureReady = "ExposureReady"
+	// ConditionTypeAuthenticationReady is true when the authentication proxies are ready or authentication is disabled
+	ConditionTypeAuthenticationReady = "AuthenticationReady"
+)
+
 // WorkspaceStatus defines the observed state of Workspace
 type WorkspaceStatus struct {
+	// Phase summarizes the state of the workspace
+	// +optional
+	Phase WorkspacePhase `json:"phase,omitempty"`
+
+	// ObservedGeneration is the most recent generation of the workspace processed by the operator
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions describe the state of the individual components in the workspace
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// URLs are the addresses of the exposed user interfaces
+	// +optional
+	URLs WorkspaceURLs `json:"urls,omitempty"`
+}
+
+// WorkspaceURLs contains the addresses of the exposed user interfaces
+type WorkspaceURLs struct {
+	// ExperimentTracking is the address of the MLFlow user interface
+	// +optional
+	ExperimentTracking string `json:"experimentTracking,omitempty"`
+
+	// Workflows is the address of the Prefect user interface
+	// +optional
+	Workflows string `json:"workflows,omitempty"`
+
+	// ComputeDashboard is the address of the Ray dashboard
+	// +optional
+	ComputeDashboard string `json:"computeDashboard,omitempty"`
 }
 
 // WorkflowComponentSpec defines the configuration for the workflow component
@@ -47,6 +377,11 @@ type WorkflowComponentSpec struct {
 	// Agents defines the agent pools to deploy
 	// +kubebuilder:validation:MinItems=1
 	Agents []WorkflowAgentPoolSpec `json:"agentPools,omitempty"`
+
+	// MigrateLegacyDatabase copies the workflow state from the experiment tracking database to the workflow
+	// database. Earlier versions of the operator stored the workflow state in the experiment tracking database.
+	// +optional
+	MigrateLegacyDatabase bool `json:"migrateLegacyDatabase,omitempty"`
 }
 
 // WorkflowControllerSpec defines the configuration for the workflow controller
@@ -60,6 +395,14 @@ type WorkflowControllerSpec struct {
 
 	// Image defines the docker image to use for the controller
 	Image string `json:"image,omitempty"`
+
+	// ServiceAccount configures the service account the pods run as
+	// +optional
+	ServiceAccount ServiceAccountSpec `json:"serviceAccount,omitempty"`
+
+	// Scheduling configures the nodes the pods run on
+	// +optional
+	Scheduling SchedulingSpec `json:"scheduling,omitempty"`
 }
 
 // WorkflowAgentPoolSpec defines the shape of an agent pool
@@ -74,6 +417,14 @@ type WorkflowAgentPoolSpec struct {
 	Replicas *int32 `json:"replicas"`
 	// Resources define the resource requirements for each agent in the pool
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// ServiceAccount configures the service account the pods run as
+	// +optional
+	ServiceAccount ServiceAccountSpec `json:"serviceAccount,omitempty"`
+
+	// Scheduling configures the nodes the pods run on
+	// +optional
+	Scheduling SchedulingSpec `json:"scheduling,omitempty"`
 }
 
 // ExperimentTrackingComponentSpec defines the configuration for the experiment tracking component
@@ -87,6 +438,84 @@ type ExperimentTrackingComponentSpec struct {
 
 	// Resources define the resource requirements for the experiment tracking component
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Artifacts defines where the experiment tracking server stores artifacts like models and plots
+	// +optional
+	Artifacts ArtifactStorageSpec `json:"artifacts,omitempty"`
+
+	// ServiceAccount configures the service account the pods run as
+	// +optional
+	ServiceAccount ServiceAccountSpec `json:"serviceAccount,omitempty"`
+
+	// Scheduling configures the nodes the pods run on
+	// +optional
+	Scheduling SchedulingSpec `json:"scheduling,omitempty"`
+}
+
+// ArtifactStorageSpec defines the storage backend for experiment tracking artifacts.
+// Configure at most one backend. Without a backend, the artifacts are stored in the container of the server.
+type ArtifactStorageSpec struct {
+	// S3 stores the artifacts in an S3-compatible bucket
+	// +optional
+	S3 *S3ArtifactStorageSpec `json:"s3,omitempty"`
+
+	// PersistentVolume stores the artifacts on a ReadWriteMany volume
+	// +optional
+	PersistentVolume *PersistentVolumeArtifactStorageSpec `json:"persistentVolume,omitempty"`
+
+	// AzureBlob stores the artifacts in an Azure Blob Storage container
+	// +optional
+	AzureBlob *AzureBlobArtifactStorageSpec `json:"azureBlob,omitempty"`
+}
+
+// S3ArtifactStorageSpec defines an S3-compatible bucket for artifacts
+type S3ArtifactStorageSpec struct {
+	// Bucket is the name of the bucket to store the artifacts in
+	// +kubebuilder:validation:MinLength=1
+	Bucket string `json:"bucket"`
+
+	// Path is the prefix for the artifacts in the bucket
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Endpoint is the URL of the S3-compatible service, leave it empty to use AWS S3
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region is the region of the bucket
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// CredentialsSecret refers to a secret with the keys AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+	CredentialsSecret corev1.LocalObjectReference `json:"credentialsSecret"`
+}
+
+// PersistentVolumeArtifactStorageSpec defines a ReadWriteMany volume for artifacts
+type PersistentVolumeArtifactStorageSpec struct {
+	// Size is the storage capacity of the volume
+	Size resource.Quantity `json:"size"`
+
+	// StorageClassName is the name of a storage class that supports ReadWriteMany volumes
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+}
+
+// AzureBlobArtifactStorageSpec defines an Azure Blob Storage container for artifacts
+type AzureBlobArtifactStorageSpec struct {
+	// StorageAccount is the name of the storage account
+	// +kubebuilder:validation:MinLength=1
+	StorageAccount string `json:"storageAccount"`
+
+	// Container is the name of the blob container to store the artifacts in
+	// +kubebuilder:validation:MinLength=1
+	Container string `json:"container"`
+
+	// Path is the prefix for the artifacts in the container
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// CredentialsSecret refers to a secret with the key AZURE_STORAGE_CONNECTION_STRING or AZURE_STORAGE_ACCESS_KEY
+	CredentialsSecret corev1.LocalObjectReference `json:"credentialsSecret"`
 }
 
 // WorkspaceStorageSpec defines the storage configuration for the workspace
@@ -96,6 +525,33 @@ type WorkspaceStorageSpec struct {
 
 	// DatabaseBackupStorage defines the storage requirements for the database backup
 	DatabaseBackupStorage resource.Quantity `json:"databaseBackup,omitempty"`
+
+	// ObjectStorage defines the storage requirements for the object storage
+	ObjectStorage resource.Quantity `json:"objectStorage,omitempty"`
+
+	// FaultTolerance defines the storage requirements for the Redis instance that stores the state of the Ray head
+	FaultTolerance resource.Quantity `json:"faultTolerance,omitempty"`
+}
+
+// ObjectStorageSpec defines the configuration for the object storage component
+type ObjectStorageSpec struct {
+	// Enabled deploys a MinIO server in the workspace
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Image defines the docker image to use for the MinIO server
+	Image string `json:"image,omitempty"`
+
+	// Resources define the resource requirements for the MinIO server
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Buckets defines the buckets to create in the object storage
+	// +optional
+	Buckets []string `json:"buckets,omitempty"`
+
+	// Scheduling configures the nodes the MinIO server runs on
+	// +optional
+	Scheduling SchedulingSpec `json:"scheduling,omitempty"`
 }
 
 // ComputeSpec defines the configuration for the compute cluster
@@ -108,6 +564,66 @@ type ComputeSpec struct {
 	// RayVersion defines the version of Ray in use in the compute cluster
 	// +optional
 	RayVersion string `json:"rayVersion,omitempty"`
+	// Autoscaling scales the worker pools between their minimum and maximum number of replicas
+	// +optional
+	Autoscaling AutoscalingSpec `json:"autoscaling,omitempty"`
+	// FaultTolerance stores the state of the Ray head in Redis, so it can recover from a restart
+	// +optional
+	FaultTolerance FaultToleranceSpec `json:"faultTolerance,omitempty"`
+}
+
+// FaultToleranceSpec defines the Redis instance that stores the state of the Ray head
+type FaultToleranceSpec struct {
+	// Enabled stores the state of the Ray head in Redis. The workers and running jobs survive a restart of the head
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// ExternalRedis refers to an existing Redis instance. The operator deploys a Redis instance in the workspace when empty
+	// +optional
+	ExternalRedis *ExternalRedisSpec `json:"externalRedis,omitempty"`
+
+	// Image defines the docker image to use for the Redis instance
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Resources define the resource requirements for the Redis instance
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Scheduling configures the nodes the Redis instance runs on
+	// +optional
+	Scheduling SchedulingSpec `json:"scheduling,omitempty"`
+}
+
+// ExternalRedisSpec refers to a Redis instance outside the workspace
+type ExternalRedisSpec struct {
+	// Address is the host and port of the Redis instance
+	Address string `json:"address"`
+
+	// PasswordSecretRef refers to the key of the secret that contains the password of the Redis instance
+	// +optional
+	PasswordSecretRef *corev1.SecretKeySelector `json:"passwordSecretRef,omitempty"`
+}
+
+// AutoscalingSpec defines the configuration of the Ray autoscaler
+type AutoscalingSpec struct {
+	// Enabled runs the Ray autoscaler next to the ray head
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// IdleTimeoutSeconds is the time a worker without work keeps running before the autoscaler removes it
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	IdleTimeoutSeconds *int32 `json:"idleTimeoutSeconds,omitempty"`
+
+	// UpscalingMode controls how fast the autoscaler adds workers
+	// +kubebuilder:validation:Enum=Default;Aggressive;Conservative
+	// +optional
+	UpscalingMode string `json:"upscalingMode,omitempty"`
+
+	// Resources define the resource requirements for the autoscaler
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
 // ComputeControllerSpec defines the configuration for the compute cluster controller
@@ -119,6 +635,14 @@ type ComputeControllerSpec struct {
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
 	// Image defines the docker image to use for the compute cluster controller
 	Image string `json:"image,omitempty"`
+
+	// ServiceAccount configures the service account the pods run as
+	// +optional
+	ServiceAccount ServiceAccountSpec `json:"serviceAccount,omitempty"`
+
+	// Scheduling configures the nodes the pods run on
+	// +optional
+	Scheduling SchedulingSpec `json:"scheduling,omitempty"`
 }
 
 type ComputeWorkerPoolSpec struct {
@@ -134,10 +658,48 @@ type ComputeWorkerPoolSpec struct {
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
 	// Image defines the docker image to use for the compute cluster controller
 	Image string `json:"image,omitempty"`
+
+	// Accelerators defines the GPUs or other accelerators attached to each worker in the pool
+	// +optional
+	Accelerators *AcceleratorSpec `json:"accelerators,omitempty"`
+
+	// ServiceAccount configures the service account the pods run as
+	// +optional
+	ServiceAccount ServiceAccountSpec `json:"serviceAccount,omitempty"`
+
+	// Scheduling configures the nodes the pods run on
+	// +optional
+	Scheduling SchedulingSpec `json:"scheduling,omitempty"`
+}
+
+// AcceleratorSpec defines the accelerators attached to each worker in a pool
+type AcceleratorSpec struct {
+	// ResourceName is the extended resource of the accelerator, for example nvidia.com/gpu
+	// +optional
+	ResourceName corev1.ResourceName `json:"resourceName,omitempty"`
+
+	// Count is the number of accelerators attached to each worker
+	// +kubebuilder:validation:Minimum=1
+	Count int64 `json:"count"`
+
+	// Type is the type of accelerator, for example Tesla-T4. The workers run on nodes with this type
+	// and Ray offers it as the accelerator_type resource
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// NodeLabel is the node label that holds the type of accelerator
+	// +optional
+	NodeLabel string `json:"nodeLabel,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Database",type=string,JSONPath=`.status.conditions[?(@.type=="DatabaseReady")].status`
+//+kubebuilder:printcolumn:name="Tracking",type=string,JSONPath=`.status.conditions[?(@.type=="ExperimentTrackingReady")].status`
+//+kubebuilder:printcolumn:name="Workflows",type=string,JSONPath=`.status.conditions[?(@.type=="WorkflowsReady")].status`
+//+kubebuilder:printcolumn:name="Compute",type=string,JSONPath=`.status.conditions[?(@.type=="ComputeReady")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Workspace is the Schema for the workspaces API
 type Workspace struct {
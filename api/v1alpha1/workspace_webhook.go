@@ -17,6 +17,12 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -25,17 +31,143 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 // log is for logging in this package.
 var workspacelog = logf.Log.WithName("workspace-resource")
 
+// restrictedImageRepositories are the images known to run as user 1000 with a read-only root filesystem.
+var restrictedImageRepositories = []string{
+	"willemmeints/experiment-tracking",
+	"willemmeints/workflow-controller",
+	"willemmeints/workflow-agent",
+	"rayproject/ray",
+	"rayproject/ray-ml",
+	"minio/minio",
+	"redis",
+	"quay.io/oauth2-proxy/oauth2-proxy",
+}
+
 func (r *Workspace) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	// The builder skips the validating webhook when its path is already registered,
+	// so we register our own handler first to add warnings to the validation results.
+	mgr.GetWebhookServer().Register(
+		"/validate-mlops-aigency-com-v1alpha1-workspace",
+		&admission.Webhook{Handler: &workspaceValidatingHandler{validator: admission.ValidatingWebhookFor(r).Handler}},
+	)
+
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
 		Complete()
 }
 
+// workspaceValidatingHandler validates workspaces and warns about settings that may not work in a hardened cluster.
+// The validator interface of controller-runtime can't return warnings, so the handler adds them to the response.
+type workspaceValidatingHandler struct {
+	validator admission.Handler
+	decoder   *admission.Decoder
+}
+
+var _ admission.DecoderInjector = &workspaceValidatingHandler{}
+
+// InjectDecoder injects the decoder into the handler and the validator it wraps.
+func (h *workspaceValidatingHandler) InjectDecoder(decoder *admission.Decoder) error {
+	h.decoder = decoder
+
+	_, err := admission.InjectDecoderInto(decoder, h.validator)
+	return err
+}
+
+// Handle validates the workspace and adds warnings for custom images and ephemeral artifact storage to the response.
+func (h *workspaceValidatingHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	response := h.validator.Handle(ctx, req)
+
+	if !response.Allowed || req.Operation == admissionv1.Delete {
+		return response
+	}
+
+	workspace := &Workspace{}
+
+	if err := h.decoder.Decode(req, workspace); err != nil {
+		return response
+	}
+
+	return response.WithWarnings(append(workspace.ImageWarnings(), workspace.ArtifactStorageWarnings()...)...)
+}
+
+// ArtifactStorageWarnings warns when the experiment tracking server stores artifacts in the pod,
+// where they're lost when the server restarts.
+func (r *Workspace) ArtifactStorageWarnings() []string {
+	if hasSharedArtifactStorage(r) {
+		return []string{}
+	}
+
+	return []string{
+		"spec.experimentTracking.artifacts: without an artifact storage backend or object storage, " +
+			"artifacts are stored in the experiment tracking server and lost when it restarts",
+	}
+}
+
+// hasSharedArtifactStorage returns whether all replicas of the experiment tracking server store their artifacts
+// in the same place that outlives the pods.
+func hasSharedArtifactStorage(r *Workspace) bool {
+	artifacts := r.Spec.ExperimentTracking.Artifacts
+
+	return artifacts.S3 != nil || artifacts.PersistentVolume != nil || artifacts.AzureBlob != nil || r.Spec.ObjectStorage.Enabled
+}
+
+// ImageWarnings warns about custom images that may not run under the restricted Pod Security Standard.
+// The operator runs every container as user 1000 with a read-only root filesystem, where only /tmp is writable.
+func (r *Workspace) ImageWarnings() []string {
+	images := map[string]string{
+		"spec.experimentTracking.image":     r.Spec.ExperimentTracking.Image,
+		"spec.workflows.controller.image":   r.Spec.Workflows.Controller.Image,
+		"spec.compute.controller.image":     r.Spec.Compute.Controller.Image,
+		"spec.compute.faultTolerance.image": r.Spec.Compute.FaultTolerance.Image,
+		"spec.objectStorage.image":          r.Spec.ObjectStorage.Image,
+	}
+
+	for index, agentPoolSpec := range r.Spec.Workflows.Agents {
+		images[fmt.Sprintf("spec.workflows.agentPools[%d].image", index)] = agentPoolSpec.Image
+	}
+
+	for index, workerPoolSpec := range r.Spec.Compute.WorkerPools {
+		images[fmt.Sprintf("spec.compute.workers[%d].image", index)] = workerPoolSpec.Image
+	}
+
+	if r.Spec.Authentication != nil {
+		images["spec.authentication.image"] = r.Spec.Authentication.Image
+	}
+
+	warnings := []string{}
+
+	for fieldPath, image := range images {
+		if image == "" || slices.Contains(restrictedImageRepositories, getImageRepository(image)) {
+			continue
+		}
+
+		warnings = append(warnings, fmt.Sprintf(
+			"%s: custom image %s must run as user 1000 with a read-only root filesystem and only write to /tmp",
+			fieldPath, image))
+	}
+
+	sort.Strings(warnings)
+
+	return warnings
+}
+
+// getImageRepository removes the tag and digest from an image reference.
+func getImageRepository(image string) string {
+	repository, _, _ := strings.Cut(image, "@")
+
+	if index := strings.LastIndex(repository, ":"); index > strings.LastIndex(repository, "/") {
+		repository = repository[:index]
+	}
+
+	return repository
+}
+
 //+kubebuilder:webhook:path=/mutate-mlops-aigency-com-v1alpha1-workspace,mutating=true,failurePolicy=fail,sideEffects=None,groups=mlops.aigency.com,resources=workspaces,verbs=create;update,versions=v1alpha1,name=mworkspace.kb.io,admissionReviewVersions=v1
 
 var _ webhook.Defaulter = &Workspace{}
@@ -47,6 +179,12 @@ func (r *Workspace) Default() {
 	defaultWorkflowsSpec(r)
 	defaultExperimentTrackingSpec(r)
 	defaultStorageSpec(r)
+	defaultObjectStorageSpec(r)
+	defaultDeletionPolicy(r)
+	defaultExposureSpec(r)
+	defaultAuthenticationSpec(r)
+	defaultMembers(r)
+	defaultNetworkPolicySpec(r)
 	defaultComputeClusterSpec(r)
 }
 
@@ -57,6 +195,11 @@ var _ webhook.Validator = &Workspace{}
 func (r *Workspace) ValidateCreate() error {
 	validationErrors := field.ErrorList{}
 
+	validationErrors = append(validationErrors, validateArtifactStorage(r)...)
+	validationErrors = append(validationErrors, validateExposure(r)...)
+	validationErrors = append(validationErrors, validateMembers(r)...)
+	validationErrors = append(validationErrors, validateAccelerators(r)...)
+
 	if len(validationErrors) > 0 {
 		groupKind := schema.GroupKind{Group: "mlops.aigency.com", Kind: "Workspace"}
 		return apierrors.NewInvalid(groupKind, r.Name, validationErrors)
@@ -69,6 +212,10 @@ func (r *Workspace) ValidateUpdate(old runtime.Object) error {
 	validationErrors := field.ErrorList{}
 
 	validationErrors = append(validationErrors, validateWorkflowAgentPoolNames(r)...)
+	validationErrors = append(validationErrors, validateArtifactStorage(r)...)
+	validationErrors = append(validationErrors, validateExposure(r)...)
+	validationErrors = append(validationErrors, validateMembers(r)...)
+	validationErrors = append(validationErrors, validateAccelerators(r)...)
 
 	if len(validationErrors) > 0 {
 		groupKind := schema.GroupKind{Group: "mlops.aigency.com", Kind: "Workspace"}
@@ -102,3 +249,118 @@ func validateWorkflowAgentPoolNames(r *Workspace) field.ErrorList {
 
 	return validationErrors
 }
+
+func validateArtifactStorage(r *Workspace) field.ErrorList {
+	validationErrors := field.ErrorList{}
+	artifacts := r.Spec.ExperimentTracking.Artifacts
+	backends := []string{}
+
+	if artifacts.S3 != nil {
+		backends = append(backends, "s3")
+	}
+
+	if artifacts.PersistentVolume != nil {
+		backends = append(backends, "persistentVolume")
+	}
+
+	if artifacts.AzureBlob != nil {
+		backends = append(backends, "azureBlob")
+	}
+
+	if len(backends) > 1 {
+		err := field.Invalid(
+			field.NewPath("spec").Child("experimentTracking").Child("artifacts"),
+			backends,
+			"only one artifact storage backend can be configured",
+		)
+
+		validationErrors = append(validationErrors, err)
+	}
+
+	// Each replica would store the artifacts it receives in its own pod, invisible to the other replicas.
+	if replicas := r.Spec.ExperimentTracking.Replicas; replicas != nil && *replicas > 1 && !hasSharedArtifactStorage(r) {
+		err := field.Invalid(
+			field.NewPath("spec").Child("experimentTracking").Child("replicas"),
+			*replicas,
+			"more than one replica requires an artifact storage backend or object storage",
+		)
+
+		validationErrors = append(validationErrors, err)
+	}
+
+	return validationErrors
+}
+
+// validateAccelerators requires the node label for the type of accelerators other than NVIDIA GPUs,
+// because we don't know which label their device plugin sets.
+func validateAccelerators(r *Workspace) field.ErrorList {
+	validationErrors := field.ErrorList{}
+
+	for index, workerPoolSpec := range r.Spec.Compute.WorkerPools {
+		accelerators := workerPoolSpec.Accelerators
+
+		if accelerators == nil || accelerators.Type == "" || accelerators.NodeLabel != "" {
+			continue
+		}
+
+		if accelerators.ResourceName == "" || accelerators.ResourceName == NvidiaGPUResourceName {
+			continue
+		}
+
+		validationErrors = append(validationErrors, field.Required(
+			field.NewPath("spec").Child("compute").Child("workers").Index(index).Child("accelerators").Child("nodeLabel"),
+			fmt.Sprintf("a node label is required for the type of %s accelerators", accelerators.ResourceName),
+		))
+	}
+
+	return validationErrors
+}
+
+func validateExposure(r *Workspace) field.ErrorList {
+	validationErrors := field.ErrorList{}
+	exposure := r.Spec.Exposure
+	exposurePath := field.NewPath("spec").Child("exposure")
+
+	if exposure.Mode == "" || exposure.Mode == ExposureModeNone {
+		return validationErrors
+	}
+
+	if exposure.Hostname == "" {
+		validationErrors = append(validationErrors, field.Required(
+			exposurePath.Child("hostname"),
+			"hostname is required to expose the workspace",
+		))
+	}
+
+	if exposure.Mode == ExposureModeGateway && exposure.Gateway == nil {
+		validationErrors = append(validationErrors, field.Required(
+			exposurePath.Child("gateway"),
+			"gateway is required when the mode is Gateway",
+		))
+	}
+
+	return validationErrors
+}
+
+func validateMembers(r *Workspace) field.ErrorList {
+	validationErrors := field.ErrorList{}
+	var memberNames []string
+
+	for index, member := range r.Spec.Members {
+		memberName := string(member.Kind) + "/" + member.Name
+
+		if slices.Contains(memberNames, memberName) {
+			err := field.Invalid(
+				field.NewPath("spec").Child("members").Index(index).Child("name"),
+				member.Name,
+				"a member can only have one role",
+			)
+
+			validationErrors = append(validationErrors, err)
+		}
+
+		memberNames = append(memberNames, memberName)
+	}
+
+	return validationErrors
+}
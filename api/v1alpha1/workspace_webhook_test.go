@@ -88,4 +88,198 @@ var _ = Describe("Defaulting webhook", func() {
 			},
 		}))
 	})
+
+	It("Should set the default values for the object storage", func() {
+		workspace := &Workspace{}
+
+		workspace.Default()
+
+		Expect(workspace.Spec.ObjectStorage).To(Equal(ObjectStorageSpec{
+			Image: "minio/minio:RELEASE.2023-03-13T19-46-17Z",
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				},
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("100m"),
+					corev1.ResourceMemory: resource.MustParse("256Mi"),
+				},
+			},
+		}))
+
+		Expect(workspace.Spec.Storage.ObjectStorage).To(Equal(resource.MustParse("10Gi")))
+	})
+
+	It("Should delete the data together with the workspace by default", func() {
+		workspace := &Workspace{}
+
+		workspace.Default()
+
+		Expect(workspace.Spec.DeletionPolicy).To(Equal(DeletionPolicyDelete))
+	})
+
+	It("Should keep the configured deletion policy", func() {
+		workspace := &Workspace{Spec: WorkspaceSpec{DeletionPolicy: DeletionPolicyRetain}}
+
+		workspace.Default()
+
+		Expect(workspace.Spec.DeletionPolicy).To(Equal(DeletionPolicyRetain))
+	})
+
+	It("Should keep the workspace inside the cluster by default", func() {
+		workspace := &Workspace{}
+
+		workspace.Default()
+
+		Expect(workspace.Spec.Exposure.Mode).To(Equal(ExposureModeNone))
+		Expect(workspace.Spec.Exposure.Routing).To(Equal(ExposureRoutingHost))
+	})
+
+	It("Should set the default values for the authentication proxy", func() {
+		workspace := &Workspace{Spec: WorkspaceSpec{Authentication: &AuthenticationSpec{}}}
+
+		workspace.Default()
+
+		Expect(workspace.Spec.Authentication.Image).To(Equal("quay.io/oauth2-proxy/oauth2-proxy:v7.4.0"))
+		Expect(workspace.Spec.Authentication.Resources.Limits).To(HaveKey(corev1.ResourceCPU))
+	})
+
+	It("Should allow the ingress-nginx namespace to reach the user interfaces by default", func() {
+		workspace := &Workspace{}
+
+		workspace.Default()
+
+		Expect(workspace.Spec.NetworkPolicy.Enabled).To(BeFalse())
+		Expect(workspace.Spec.NetworkPolicy.IngressNamespaceSelector.MatchLabels).To(
+			HaveKeyWithValue("kubernetes.io/metadata.name", "ingress-nginx"))
+		Expect(workspace.Spec.NetworkPolicy.OperatorNamespaceSelector.MatchLabels).To(
+			HaveKeyWithValue("kubernetes.io/metadata.name", "cartographer-system"))
+		Expect(workspace.Spec.NetworkPolicy.KubeRayNamespaceSelector.MatchLabels).To(
+			HaveKeyWithValue("kubernetes.io/metadata.name", "ray-system"))
+	})
+
+	It("Should treat members as users by default", func() {
+		workspace := &Workspace{Spec: WorkspaceSpec{Members: []WorkspaceMember{{Name: "jane", Role: WorkspaceRoleOwner}}}}
+
+		workspace.Default()
+
+		Expect(workspace.Spec.Members[0].Kind).To(Equal(WorkspaceMemberKindUser))
+	})
+})
+
+var _ = Describe("Validating webhook", func() {
+	It("Should allow a single artifact storage backend", func() {
+		workspace := &Workspace{}
+		workspace.Spec.ExperimentTracking.Artifacts.S3 = &S3ArtifactStorageSpec{
+			Bucket: "artifacts",
+		}
+
+		Expect(workspace.ValidateCreate()).To(Succeed())
+	})
+
+	It("Should reject multiple artifact storage backends", func() {
+		workspace := &Workspace{}
+		workspace.Spec.ExperimentTracking.Artifacts.S3 = &S3ArtifactStorageSpec{
+			Bucket: "artifacts",
+		}
+		workspace.Spec.ExperimentTracking.Artifacts.PersistentVolume = &PersistentVolumeArtifactStorageSpec{
+			Size: resource.MustParse("10Gi"),
+		}
+
+		Expect(workspace.ValidateCreate()).NotTo(Succeed())
+	})
+
+	It("Should reject more than one experiment tracking replica without shared artifact storage", func() {
+		workspace := &Workspace{}
+		workspace.Spec.ExperimentTracking.Replicas = pointer.Int32(2)
+
+		Expect(workspace.ValidateCreate()).NotTo(Succeed())
+		Expect(workspace.ArtifactStorageWarnings()).To(HaveLen(1))
+
+		workspace.Spec.ObjectStorage.Enabled = true
+
+		Expect(workspace.ValidateCreate()).To(Succeed())
+		Expect(workspace.ArtifactStorageWarnings()).To(BeEmpty())
+	})
+
+	It("Should require a hostname to expose the workspace", func() {
+		workspace := &Workspace{}
+		workspace.Spec.Exposure.Mode = ExposureModeIngress
+
+		Expect(workspace.ValidateCreate()).NotTo(Succeed())
+
+		workspace.Spec.Exposure.Hostname = "ml.example.com"
+
+		Expect(workspace.ValidateCreate()).To(Succeed())
+	})
+
+	It("Should require a gateway in the Gateway mode", func() {
+		workspace := &Workspace{}
+		workspace.Spec.Exposure.Mode = ExposureModeGateway
+		workspace.Spec.Exposure.Hostname = "ml.example.com"
+
+		Expect(workspace.ValidateCreate()).NotTo(Succeed())
+	})
+
+	It("Should allow path routing", func() {
+		workspace := &Workspace{}
+		workspace.Spec.Exposure.Mode = ExposureModeIngress
+		workspace.Spec.Exposure.Routing = ExposureRoutingPath
+		workspace.Spec.Exposure.Hostname = "ml.example.com"
+
+		Expect(workspace.ValidateCreate()).To(Succeed())
+	})
+
+	It("Should reject a member with more than one role", func() {
+		workspace := &Workspace{}
+		workspace.Spec.Members = []WorkspaceMember{
+			{Kind: WorkspaceMemberKindUser, Name: "jane", Role: WorkspaceRoleOwner},
+			{Kind: WorkspaceMemberKindUser, Name: "jane", Role: WorkspaceRoleViewer},
+		}
+
+		Expect(workspace.ValidateCreate()).NotTo(Succeed())
+	})
+
+	It("Should warn about custom images", func() {
+		workspace := &Workspace{}
+		workspace.Default()
+
+		Expect(workspace.ImageWarnings()).To(BeEmpty())
+
+		workspace.Spec.ExperimentTracking.Image = "registry.example.com/mlflow:2.1.1"
+		workspace.Spec.Compute.Controller.Image = "rayproject/ray@sha256:0123456789abcdef"
+
+		Expect(workspace.ImageWarnings()).To(ConsistOf(ContainSubstring("spec.experimentTracking.image")))
+	})
+
+	It("Should use the GPU flavour of the Ray image for NVIDIA GPUs", func() {
+		workspace := &Workspace{}
+		workspace.Spec.Compute.WorkerPools = []ComputeWorkerPoolSpec{
+			{Name: "gpu", Accelerators: &AcceleratorSpec{Count: 1, Type: "Tesla-T4"}},
+		}
+
+		workspace.Default()
+
+		workerPoolSpec := workspace.Spec.Compute.WorkerPools[0]
+		Expect(workerPoolSpec.Image).To(Equal("rayproject/ray:2.2.0-gpu"))
+		Expect(workerPoolSpec.Accelerators.ResourceName).To(Equal(NvidiaGPUResourceName))
+		Expect(workerPoolSpec.Accelerators.NodeLabel).To(Equal(AcceleratorTypeNodeLabel))
+	})
+
+	It("Should require a node label for the type of other accelerators", func() {
+		workspace := &Workspace{}
+		workspace.Spec.Compute.WorkerPools = []ComputeWorkerPoolSpec{
+			{Name: "gpu", Accelerators: &AcceleratorSpec{ResourceName: "amd.com/gpu", Count: 1, Type: "MI100"}},
+		}
+
+		workspace.Default()
+
+		Expect(workspace.Spec.Compute.WorkerPools[0].Accelerators.NodeLabel).To(BeEmpty())
+		Expect(workspace.ValidateCreate()).NotTo(Succeed())
+
+		workspace.Spec.Compute.WorkerPools[0].Accelerators.NodeLabel = "amd.com/gpu.device-id"
+
+		Expect(workspace.ValidateCreate()).To(Succeed())
+	})
 })
@@ -22,9 +22,117 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AcceleratorSpec) DeepCopyInto(out *AcceleratorSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AcceleratorSpec.
+func (in *AcceleratorSpec) DeepCopy() *AcceleratorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AcceleratorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArtifactStorageSpec) DeepCopyInto(out *ArtifactStorageSpec) {
+	*out = *in
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(S3ArtifactStorageSpec)
+		**out = **in
+	}
+	if in.PersistentVolume != nil {
+		in, out := &in.PersistentVolume, &out.PersistentVolume
+		*out = new(PersistentVolumeArtifactStorageSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AzureBlob != nil {
+		in, out := &in.AzureBlob, &out.AzureBlob
+		*out = new(AzureBlobArtifactStorageSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArtifactStorageSpec.
+func (in *ArtifactStorageSpec) DeepCopy() *ArtifactStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ArtifactStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthenticationSpec) DeepCopyInto(out *AuthenticationSpec) {
+	*out = *in
+	in.ClientSecretRef.DeepCopyInto(&out.ClientSecretRef)
+	if in.AllowedGroups != nil {
+		in, out := &in.AllowedGroups, &out.AllowedGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	in.Scheduling.DeepCopyInto(&out.Scheduling)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthenticationSpec.
+func (in *AuthenticationSpec) DeepCopy() *AuthenticationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthenticationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoscalingSpec) DeepCopyInto(out *AutoscalingSpec) {
+	*out = *in
+	if in.IdleTimeoutSeconds != nil {
+		in, out := &in.IdleTimeoutSeconds, &out.IdleTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoscalingSpec.
+func (in *AutoscalingSpec) DeepCopy() *AutoscalingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoscalingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureBlobArtifactStorageSpec) DeepCopyInto(out *AzureBlobArtifactStorageSpec) {
+	*out = *in
+	out.CredentialsSecret = in.CredentialsSecret
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureBlobArtifactStorageSpec.
+func (in *AzureBlobArtifactStorageSpec) DeepCopy() *AzureBlobArtifactStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureBlobArtifactStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComputeControllerSpec) DeepCopyInto(out *ComputeControllerSpec) {
 	*out = *in
@@ -34,6 +142,8 @@ func (in *ComputeControllerSpec) DeepCopyInto(out *ComputeControllerSpec) {
 		**out = **in
 	}
 	in.Resources.DeepCopyInto(&out.Resources)
+	in.ServiceAccount.DeepCopyInto(&out.ServiceAccount)
+	in.Scheduling.DeepCopyInto(&out.Scheduling)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComputeControllerSpec.
@@ -57,6 +167,8 @@ func (in *ComputeSpec) DeepCopyInto(out *ComputeSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	in.Autoscaling.DeepCopyInto(&out.Autoscaling)
+	in.FaultTolerance.DeepCopyInto(&out.FaultTolerance)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComputeSpec.
@@ -83,6 +195,13 @@ func (in *ComputeWorkerPoolSpec) DeepCopyInto(out *ComputeWorkerPoolSpec) {
 		**out = **in
 	}
 	in.Resources.DeepCopyInto(&out.Resources)
+	if in.Accelerators != nil {
+		in, out := &in.Accelerators, &out.Accelerators
+		*out = new(AcceleratorSpec)
+		**out = **in
+	}
+	in.ServiceAccount.DeepCopyInto(&out.ServiceAccount)
+	in.Scheduling.DeepCopyInto(&out.Scheduling)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComputeWorkerPoolSpec.
@@ -95,6 +214,22 @@ func (in *ComputeWorkerPoolSpec) DeepCopy() *ComputeWorkerPoolSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseSpec) DeepCopyInto(out *DatabaseSpec) {
+	*out = *in
+	in.Scheduling.DeepCopyInto(&out.Scheduling)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseSpec.
+func (in *DatabaseSpec) DeepCopy() *DatabaseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExperimentTrackingComponentSpec) DeepCopyInto(out *ExperimentTrackingComponentSpec) {
 	*out = *in
@@ -104,6 +239,9 @@ func (in *ExperimentTrackingComponentSpec) DeepCopyInto(out *ExperimentTrackingC
 		**out = **in
 	}
 	in.Resources.DeepCopyInto(&out.Resources)
+	in.Artifacts.DeepCopyInto(&out.Artifacts)
+	in.ServiceAccount.DeepCopyInto(&out.ServiceAccount)
+	in.Scheduling.DeepCopyInto(&out.Scheduling)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentTrackingComponentSpec.
@@ -116,6 +254,549 @@ func (in *ExperimentTrackingComponentSpec) DeepCopy() *ExperimentTrackingCompone
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExposureSpec) DeepCopyInto(out *ExposureSpec) {
+	*out = *in
+	if in.IngressClassName != nil {
+		in, out := &in.IngressClassName, &out.IngressClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Gateway != nil {
+		in, out := &in.Gateway, &out.Gateway
+		*out = new(GatewayReference)
+		**out = **in
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(ExposureTLSSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExposureSpec.
+func (in *ExposureSpec) DeepCopy() *ExposureSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExposureSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExposureTLSSpec) DeepCopyInto(out *ExposureTLSSpec) {
+	*out = *in
+	out.IssuerRef = in.IssuerRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExposureTLSSpec.
+func (in *ExposureTLSSpec) DeepCopy() *ExposureTLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExposureTLSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalRedisSpec) DeepCopyInto(out *ExternalRedisSpec) {
+	*out = *in
+	if in.PasswordSecretRef != nil {
+		in, out := &in.PasswordSecretRef, &out.PasswordSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalRedisSpec.
+func (in *ExternalRedisSpec) DeepCopy() *ExternalRedisSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalRedisSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FaultToleranceSpec) DeepCopyInto(out *FaultToleranceSpec) {
+	*out = *in
+	if in.ExternalRedis != nil {
+		in, out := &in.ExternalRedis, &out.ExternalRedis
+		*out = new(ExternalRedisSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	in.Scheduling.DeepCopyInto(&out.Scheduling)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FaultToleranceSpec.
+func (in *FaultToleranceSpec) DeepCopy() *FaultToleranceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FaultToleranceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayReference) DeepCopyInto(out *GatewayReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayReference.
+func (in *GatewayReference) DeepCopy() *GatewayReference {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IssuerReference) DeepCopyInto(out *IssuerReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IssuerReference.
+func (in *IssuerReference) DeepCopy() *IssuerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(IssuerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModelAutoscalingSpec) DeepCopyInto(out *ModelAutoscalingSpec) {
+	*out = *in
+	if in.TargetOngoingRequests != nil {
+		in, out := &in.TargetOngoingRequests, &out.TargetOngoingRequests
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelAutoscalingSpec.
+func (in *ModelAutoscalingSpec) DeepCopy() *ModelAutoscalingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ModelAutoscalingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModelDeployment) DeepCopyInto(out *ModelDeployment) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelDeployment.
+func (in *ModelDeployment) DeepCopy() *ModelDeployment {
+	if in == nil {
+		return nil
+	}
+	out := new(ModelDeployment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ModelDeployment) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModelDeploymentList) DeepCopyInto(out *ModelDeploymentList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ModelDeployment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelDeploymentList.
+func (in *ModelDeploymentList) DeepCopy() *ModelDeploymentList {
+	if in == nil {
+		return nil
+	}
+	out := new(ModelDeploymentList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ModelDeploymentList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModelDeploymentSpec) DeepCopyInto(out *ModelDeploymentSpec) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Autoscaling != nil {
+		in, out := &in.Autoscaling, &out.Autoscaling
+		*out = new(ModelAutoscalingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PipPackages != nil {
+		in, out := &in.PipPackages, &out.PipPackages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelDeploymentSpec.
+func (in *ModelDeploymentSpec) DeepCopy() *ModelDeploymentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ModelDeploymentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModelDeploymentStatus) DeepCopyInto(out *ModelDeploymentStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelDeploymentStatus.
+func (in *ModelDeploymentStatus) DeepCopy() *ModelDeploymentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ModelDeploymentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicySpec) DeepCopyInto(out *NetworkPolicySpec) {
+	*out = *in
+	if in.IngressNamespaceSelector != nil {
+		in, out := &in.IngressNamespaceSelector, &out.IngressNamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OperatorNamespaceSelector != nil {
+		in, out := &in.OperatorNamespaceSelector, &out.OperatorNamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.KubeRayNamespaceSelector != nil {
+		in, out := &in.KubeRayNamespaceSelector, &out.KubeRayNamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AllowedPeers != nil {
+		in, out := &in.AllowedPeers, &out.AllowedPeers
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicySpec.
+func (in *NetworkPolicySpec) DeepCopy() *NetworkPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectStorageSpec) DeepCopyInto(out *ObjectStorageSpec) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.Buckets != nil {
+		in, out := &in.Buckets, &out.Buckets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Scheduling.DeepCopyInto(&out.Scheduling)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectStorageSpec.
+func (in *ObjectStorageSpec) DeepCopy() *ObjectStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PersistentVolumeArtifactStorageSpec) DeepCopyInto(out *PersistentVolumeArtifactStorageSpec) {
+	*out = *in
+	out.Size = in.Size.DeepCopy()
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PersistentVolumeArtifactStorageSpec.
+func (in *PersistentVolumeArtifactStorageSpec) DeepCopy() *PersistentVolumeArtifactStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PersistentVolumeArtifactStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuntimeEnvironmentSpec) DeepCopyInto(out *RuntimeEnvironmentSpec) {
+	*out = *in
+	if in.PipPackages != nil {
+		in, out := &in.PipPackages, &out.PipPackages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.WorkingDirectory != nil {
+		in, out := &in.WorkingDirectory, &out.WorkingDirectory
+		*out = new(WorkingDirectorySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EnvironmentVariables != nil {
+		in, out := &in.EnvironmentVariables, &out.EnvironmentVariables
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuntimeEnvironmentSpec.
+func (in *RuntimeEnvironmentSpec) DeepCopy() *RuntimeEnvironmentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RuntimeEnvironmentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3ArtifactStorageSpec) DeepCopyInto(out *S3ArtifactStorageSpec) {
+	*out = *in
+	out.CredentialsSecret = in.CredentialsSecret
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3ArtifactStorageSpec.
+func (in *S3ArtifactStorageSpec) DeepCopy() *S3ArtifactStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(S3ArtifactStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchedulingSpec) DeepCopyInto(out *SchedulingSpec) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchedulingSpec.
+func (in *SchedulingSpec) DeepCopy() *SchedulingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SchedulingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceAccountSpec) DeepCopyInto(out *ServiceAccountSpec) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceAccountSpec.
+func (in *ServiceAccountSpec) DeepCopy() *ServiceAccountSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceAccountSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrainingJob) DeepCopyInto(out *TrainingJob) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrainingJob.
+func (in *TrainingJob) DeepCopy() *TrainingJob {
+	if in == nil {
+		return nil
+	}
+	out := new(TrainingJob)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TrainingJob) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrainingJobList) DeepCopyInto(out *TrainingJobList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TrainingJob, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrainingJobList.
+func (in *TrainingJobList) DeepCopy() *TrainingJobList {
+	if in == nil {
+		return nil
+	}
+	out := new(TrainingJobList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TrainingJobList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrainingJobSpec) DeepCopyInto(out *TrainingJobSpec) {
+	*out = *in
+	in.RuntimeEnvironment.DeepCopyInto(&out.RuntimeEnvironment)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrainingJobSpec.
+func (in *TrainingJobSpec) DeepCopy() *TrainingJobSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TrainingJobSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrainingJobStatus) DeepCopyInto(out *TrainingJobStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrainingJobStatus.
+func (in *TrainingJobStatus) DeepCopy() *TrainingJobStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TrainingJobStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkflowAgentPoolSpec) DeepCopyInto(out *WorkflowAgentPoolSpec) {
 	*out = *in
@@ -125,6 +806,8 @@ func (in *WorkflowAgentPoolSpec) DeepCopyInto(out *WorkflowAgentPoolSpec) {
 		**out = **in
 	}
 	in.Resources.DeepCopyInto(&out.Resources)
+	in.ServiceAccount.DeepCopyInto(&out.ServiceAccount)
+	in.Scheduling.DeepCopyInto(&out.Scheduling)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowAgentPoolSpec.
@@ -169,6 +852,8 @@ func (in *WorkflowControllerSpec) DeepCopyInto(out *WorkflowControllerSpec) {
 		**out = **in
 	}
 	in.Resources.DeepCopyInto(&out.Resources)
+	in.ServiceAccount.DeepCopyInto(&out.ServiceAccount)
+	in.Scheduling.DeepCopyInto(&out.Scheduling)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowControllerSpec.
@@ -181,13 +866,33 @@ func (in *WorkflowControllerSpec) DeepCopy() *WorkflowControllerSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkingDirectorySpec) DeepCopyInto(out *WorkingDirectorySpec) {
+	*out = *in
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkingDirectorySpec.
+func (in *WorkingDirectorySpec) DeepCopy() *WorkingDirectorySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkingDirectorySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Workspace) DeepCopyInto(out *Workspace) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Workspace.
@@ -240,6 +945,21 @@ func (in *WorkspaceList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceMember) DeepCopyInto(out *WorkspaceMember) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceMember.
+func (in *WorkspaceMember) DeepCopy() *WorkspaceMember {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkspaceSpec) DeepCopyInto(out *WorkspaceSpec) {
 	*out = *in
@@ -247,6 +967,20 @@ func (in *WorkspaceSpec) DeepCopyInto(out *WorkspaceSpec) {
 	in.ExperimentTracking.DeepCopyInto(&out.ExperimentTracking)
 	in.Storage.DeepCopyInto(&out.Storage)
 	in.Compute.DeepCopyInto(&out.Compute)
+	in.Database.DeepCopyInto(&out.Database)
+	in.ObjectStorage.DeepCopyInto(&out.ObjectStorage)
+	in.Exposure.DeepCopyInto(&out.Exposure)
+	if in.Authentication != nil {
+		in, out := &in.Authentication, &out.Authentication
+		*out = new(AuthenticationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]WorkspaceMember, len(*in))
+		copy(*out, *in)
+	}
+	in.NetworkPolicy.DeepCopyInto(&out.NetworkPolicy)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceSpec.
@@ -262,6 +996,14 @@ func (in *WorkspaceSpec) DeepCopy() *WorkspaceSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkspaceStatus) DeepCopyInto(out *WorkspaceStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.URLs = in.URLs
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceStatus.
@@ -279,6 +1021,8 @@ func (in *WorkspaceStorageSpec) DeepCopyInto(out *WorkspaceStorageSpec) {
 	*out = *in
 	out.DatabaseStorage = in.DatabaseStorage.DeepCopy()
 	out.DatabaseBackupStorage = in.DatabaseBackupStorage.DeepCopy()
+	out.ObjectStorage = in.ObjectStorage.DeepCopy()
+	out.FaultTolerance = in.FaultTolerance.DeepCopy()
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceStorageSpec.
@@ -290,3 +1034,18 @@ func (in *WorkspaceStorageSpec) DeepCopy() *WorkspaceStorageSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceURLs) DeepCopyInto(out *WorkspaceURLs) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceURLs.
+func (in *WorkspaceURLs) DeepCopy() *WorkspaceURLs {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceURLs)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"fmt"
+	"path"
+
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+// artifactsMountPath is the location where the artifact volume is mounted in the experiment tracking server.
+const artifactsMountPath = "/mlflow/artifacts"
+
+// newArtifactStorageEnvVars creates the environment variables that configure the artifact storage backend.
+// The experiment tracking server proxies all artifact requests, so only the server gets the credentials.
+// Without an explicit backend, the artifacts go to the object storage in the workspace when it is enabled,
+// or to the artifact volume of the server otherwise.
+func newArtifactStorageEnvVars(workspace *mlopsv1alpha1.Workspace) []corev1.EnvVar {
+	artifacts := workspace.Spec.ExperimentTracking.Artifacts
+
+	switch {
+	case artifacts.S3 != nil:
+		envVars := []corev1.EnvVar{
+			{
+				Name:  "MLFLOW_ARTIFACTS_DESTINATION",
+				Value: fmt.Sprintf("s3://%s", path.Join(artifacts.S3.Bucket, artifacts.S3.Path)),
+			},
+			newSecretEnvVar("AWS_ACCESS_KEY_ID", artifacts.S3.CredentialsSecret.Name, "AWS_ACCESS_KEY_ID"),
+			newSecretEnvVar("AWS_SECRET_ACCESS_KEY", artifacts.S3.CredentialsSecret.Name, "AWS_SECRET_ACCESS_KEY"),
+		}
+
+		if artifacts.S3.Endpoint != "" {
+			envVars = append(envVars, corev1.EnvVar{Name: "MLFLOW_S3_ENDPOINT_URL", Value: artifacts.S3.Endpoint})
+		}
+
+		if artifacts.S3.Region != "" {
+			envVars = append(envVars, corev1.EnvVar{Name: "AWS_DEFAULT_REGION", Value: artifacts.S3.Region})
+		}
+
+		return envVars
+	case artifacts.AzureBlob != nil:
+		connectionString := newSecretEnvVar("AZURE_STORAGE_CONNECTION_STRING", artifacts.AzureBlob.CredentialsSecret.Name, "AZURE_STORAGE_CONNECTION_STRING")
+		connectionString.ValueFrom.SecretKeyRef.Optional = pointer.Bool(true)
+
+		accessKey := newSecretEnvVar("AZURE_STORAGE_ACCESS_KEY", artifacts.AzureBlob.CredentialsSecret.Name, "AZURE_STORAGE_ACCESS_KEY")
+		accessKey.ValueFrom.SecretKeyRef.Optional = pointer.Bool(true)
+
+		return []corev1.EnvVar{
+			{
+				Name: "MLFLOW_ARTIFACTS_DESTINATION",
+				Value: fmt.Sprintf("wasbs://%s@%s.blob.core.windows.net/%s",
+					artifacts.AzureBlob.Container, artifacts.AzureBlob.StorageAccount, artifacts.AzureBlob.Path),
+			},
+			connectionString,
+			accessKey,
+		}
+	case artifacts.PersistentVolume != nil:
+		return []corev1.EnvVar{
+			{
+				Name:  "MLFLOW_ARTIFACTS_DESTINATION",
+				Value: artifactsMountPath,
+			},
+		}
+	case workspace.Spec.ObjectStorage.Enabled:
+		envVars := []corev1.EnvVar{
+			{
+				Name:  "MLFLOW_ARTIFACTS_DESTINATION",
+				Value: fmt.Sprintf("s3://%s", experimentTrackingBucket),
+			},
+			{
+				Name:  "MLFLOW_S3_ENDPOINT_URL",
+				Value: newWorkspaceEndpoints(workspace).objectStorageURL,
+			},
+		}
+
+		return append(envVars, newObjectStorageCredentialEnvVars(workspace)...)
+	}
+
+	return []corev1.EnvVar{
+		{
+			Name:  "MLFLOW_ARTIFACTS_DESTINATION",
+			Value: artifactsMountPath,
+		},
+	}
+}
+
+// newArtifactVolume returns the volume for the artifacts the experiment tracking server stores itself, or nil when
+// the artifacts go to a bucket. The root filesystem of the server is read-only, so without a backend the artifacts
+// go to an empty directory. They don't survive a restart of the server, so the webhook warns about it and only
+// allows a single replica.
+func newArtifactVolume(workspace *mlopsv1alpha1.Workspace) *corev1.Volume {
+	artifacts := workspace.Spec.ExperimentTracking.Artifacts
+	volume := &corev1.Volume{Name: "artifacts"}
+
+	switch {
+	case artifacts.S3 != nil, artifacts.AzureBlob != nil:
+		return nil
+	case artifacts.PersistentVolume != nil:
+		volume.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{
+			ClaimName: newArtifactVolumeClaimName(workspace),
+		}
+	case workspace.Spec.ObjectStorage.Enabled:
+		return nil
+	default:
+		volume.EmptyDir = &corev1.EmptyDirVolumeSource{}
+	}
+
+	return volume
+}
+
+func newArtifactVolumeClaimName(workspace *mlopsv1alpha1.Workspace) string {
+	return fmt.Sprintf("%s-mlflow-artifacts", workspace.GetName())
+}
+
+// newArtifactVolumeClaim creates the volume for the artifacts. All replicas of the experiment tracking server
+// share the volume, so it needs to support ReadWriteMany.
+func newArtifactVolumeClaim(workspace *mlopsv1alpha1.Workspace) *corev1.PersistentVolumeClaim {
+	volumeSpec := workspace.Spec.ExperimentTracking.Artifacts.PersistentVolume
+
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      newArtifactVolumeClaimName(workspace),
+			Namespace: workspace.GetNamespace(),
+			Labels:    newComponentLabels(workspace, "experiment-tracking"),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{
+				corev1.ReadWriteMany,
+			},
+			StorageClassName: volumeSpec.StorageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: volumeSpec.Size,
+				},
+			},
+		},
+	}
+}
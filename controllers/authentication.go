@@ -0,0 +1,309 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+	"k8s.io/utils/strings/slices"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	authenticationComponentName = "authentication"
+
+	// authenticationProxyPort is the port the authentication proxies listen on.
+	authenticationProxyPort = 4180
+
+	// authenticationEmailsDirectory is where the proxies find the email addresses of the user members.
+	authenticationEmailsDirectory = "/etc/oauth2-proxy"
+)
+
+// authenticationComponent puts an oauth2-proxy in front of each user interface in the workspace.
+// The exposure component routes to the proxies instead of the user interfaces when authentication is enabled.
+type authenticationComponent struct {
+	componentBase
+}
+
+func (c *authenticationComponent) Name() string {
+	return authenticationComponentName
+}
+
+func (c *authenticationComponent) ConditionType() string {
+	return mlopsv1alpha1.ConditionTypeAuthenticationReady
+}
+
+func (c *authenticationComponent) OwnedTypes() []client.Object {
+	return []client.Object{&appsv1.Deployment{}, &corev1.Service{}, &corev1.Secret{}, &corev1.ServiceAccount{}, &corev1.ConfigMap{}}
+}
+
+func (c *authenticationComponent) Render(workspace *mlopsv1alpha1.Workspace) ([]client.Object, error) {
+	resources := []client.Object{}
+
+	if workspace.Spec.Authentication == nil {
+		return resources, nil
+	}
+
+	resources = append(resources, newAuthenticationEmailsConfigMap(workspace))
+
+	for _, exposed := range newExposedInterfaces(workspace) {
+		resources = append(resources,
+			newAuthenticationProxyServiceAccount(workspace, exposed),
+			newAuthenticationProxyDeployment(workspace, exposed),
+			newAuthenticationProxyService(workspace, exposed))
+	}
+
+	return resources, nil
+}
+
+// Apply generates the secret the proxies use to encrypt their cookies before applying the proxies.
+func (c *authenticationComponent) Apply(ctx context.Context, workspace *mlopsv1alpha1.Workspace, resources []client.Object) error {
+	if workspace.Spec.Authentication == nil {
+		return nil
+	}
+
+	// The cookie secret must be 16, 24, or 32 bytes. The hexadecimal string of 16 random bytes is 32 bytes long.
+	keyLengths := map[string]int{"cookie-secret": 16}
+
+	if err := c.reconciler.createGeneratedSecret(ctx, workspace, newAuthenticationCookieSecretName(workspace), authenticationComponentName, keyLengths); err != nil {
+		return err
+	}
+
+	return c.reconciler.applyResources(ctx, workspace, resources)
+}
+
+func (c *authenticationComponent) Ready(ctx context.Context, workspace *mlopsv1alpha1.Workspace) (componentCondition, error) {
+	if workspace.Spec.Authentication == nil {
+		return componentCondition{ready: true, reason: reasonDisabled, message: "Authentication is disabled"}, nil
+	}
+
+	for _, exposed := range newExposedInterfaces(workspace) {
+		deployment := &appsv1.Deployment{}
+		deploymentName := newAuthenticationProxyName(workspace, exposed)
+
+		if err := c.reconciler.Get(ctx, types.NamespacedName{Name: deploymentName, Namespace: workspace.GetNamespace()}, deployment); err != nil {
+			if errors.IsNotFound(err) {
+				return componentCondition{reason: reasonNotFound, message: fmt.Sprintf("Authentication proxy %s does not exist yet", deploymentName)}, nil
+			}
+
+			return componentCondition{}, err
+		}
+
+		if condition := newDeploymentCondition(deployment); !condition.ready {
+			return condition, nil
+		}
+	}
+
+	return componentCondition{ready: true, reason: reasonAvailable, message: "All authentication proxies are available"}, nil
+}
+
+// Cleanup removes the proxies and their email addresses when authentication is disabled.
+// The cookie secret is removed together with the workspace.
+func (c *authenticationComponent) Cleanup(ctx context.Context, workspace *mlopsv1alpha1.Workspace) error {
+	if workspace.Spec.Authentication != nil {
+		return nil
+	}
+
+	logger := log.FromContext(ctx).WithValues(
+		"workspace", workspace.GetName(),
+		"namespace", workspace.GetNamespace())
+
+	for _, exposed := range newExposedInterfaces(workspace) {
+		objectMeta := metav1.ObjectMeta{Name: newAuthenticationProxyName(workspace, exposed), Namespace: workspace.GetNamespace()}
+
+		obsoleteResources := []client.Object{
+			&appsv1.Deployment{ObjectMeta: objectMeta},
+			&corev1.Service{ObjectMeta: objectMeta},
+			&corev1.ServiceAccount{ObjectMeta: objectMeta},
+		}
+
+		for _, resource := range obsoleteResources {
+			if err := c.reconciler.deleteOwnedResource(ctx, workspace, resource); err != nil {
+				logger.Error(err, "Failed to remove authentication proxy resource", "resource", resource.GetName())
+				return err
+			}
+		}
+	}
+
+	emailsConfigMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: newAuthenticationEmailsName(workspace), Namespace: workspace.GetNamespace()}}
+
+	if err := c.reconciler.deleteOwnedResource(ctx, workspace, emailsConfigMap); err != nil {
+		logger.Error(err, "Failed to remove the email addresses of the authentication proxies")
+		return err
+	}
+
+	return nil
+}
+
+func newAuthenticationProxyName(workspace *mlopsv1alpha1.Workspace, exposed exposedInterface) string {
+	return fmt.Sprintf("%s-%s-auth", workspace.GetName(), exposed.name)
+}
+
+func newAuthenticationEmailsName(workspace *mlopsv1alpha1.Workspace) string {
+	return fmt.Sprintf("%s-auth-emails", workspace.GetName())
+}
+
+func newAuthenticationCookieSecretName(workspace *mlopsv1alpha1.Workspace) string {
+	return fmt.Sprintf("%s-auth-cookie", workspace.GetName())
+}
+
+// newExposedBackend returns the service and port that receive the requests for a user interface from outside the cluster.
+func newExposedBackend(workspace *mlopsv1alpha1.Workspace, exposed exposedInterface) (string, int32) {
+	if workspace.Spec.Authentication != nil {
+		return newAuthenticationProxyName(workspace, exposed), authenticationProxyPort
+	}
+
+	return exposed.serviceName, exposed.port
+}
+
+// newAuthenticationProxyArgs configures oauth2-proxy for a user interface. The proxy only knows where to send users
+// after they signed in when the workspace is exposed. With path routing the proxies share the cookie, so users sign in once.
+func newAuthenticationProxyArgs(workspace *mlopsv1alpha1.Workspace, exposed exposedInterface) []string {
+	authentication := workspace.Spec.Authentication
+
+	args := []string{
+		"--provider=oidc",
+		fmt.Sprintf("--oidc-issuer-url=%s", authentication.IssuerURL),
+		fmt.Sprintf("--client-id=%s", authentication.ClientID),
+		fmt.Sprintf("--upstream=http://%s:%d/", exposed.serviceName, exposed.port),
+		fmt.Sprintf("--http-address=0.0.0.0:%d", authenticationProxyPort),
+		"--reverse-proxy=true",
+		"--skip-provider-button=true",
+	}
+
+	allowedGroups := append([]string{}, authentication.AllowedGroups...)
+
+	// Once the workspace has members, only the members and the allowed groups can use the user interfaces.
+	for _, group := range newMemberGroups(workspace) {
+		if !slices.Contains(allowedGroups, group) {
+			allowedGroups = append(allowedGroups, group)
+		}
+	}
+
+	// oauth2-proxy requires users to be in an allowed group on top of a valid email address. With groups,
+	// user members only get in through one of the groups, so we only check the email addresses without groups.
+	switch {
+	case len(allowedGroups) > 0:
+		args = append(args, "--email-domain=*")
+
+		for _, group := range allowedGroups {
+			args = append(args, fmt.Sprintf("--allowed-group=%s", group))
+		}
+	case len(newMemberUsers(workspace)) > 0:
+		args = append(args, fmt.Sprintf("--authenticated-emails-file=%s/emails", authenticationEmailsDirectory))
+	default:
+		args = append(args, "--email-domain=*")
+	}
+
+	if isWorkspaceExposed(workspace) {
+		args = append(args, fmt.Sprintf("--redirect-url=%soauth2/callback", newExposedURL(workspace, exposed)))
+	}
+
+	// Interfaces that serve their prefix receive the requests for the proxy with the prefix as well.
+	if basePath := newExposedBasePath(workspace, exposed); basePath != "" {
+		args = append(args, fmt.Sprintf("--proxy-prefix=%s/oauth2", basePath))
+	}
+
+	if workspace.Spec.Exposure.TLS == nil {
+		args = append(args, "--cookie-secure=false")
+	}
+
+	return args
+}
+
+func newAuthenticationProxyDeployment(workspace *mlopsv1alpha1.Workspace, exposed exposedInterface) *appsv1.Deployment {
+	deploymentName := newAuthenticationProxyName(workspace, exposed)
+	deploymentLabels := newComponentLabels(workspace, fmt.Sprintf("%s-auth", exposed.name))
+	authentication := workspace.Spec.Authentication
+
+	container := newContainer("oauth2-proxy", authentication.Image, authentication.Resources)
+	container.Args = newAuthenticationProxyArgs(workspace, exposed)
+
+	container.Env = []corev1.EnvVar{
+		newSecretEnvVar("OAUTH2_PROXY_CLIENT_SECRET", authentication.ClientSecretRef.Name, authentication.ClientSecretRef.Key),
+		newSecretEnvVar("OAUTH2_PROXY_COOKIE_SECRET", newAuthenticationCookieSecretName(workspace), "cookie-secret"),
+	}
+
+	container.Ports = []corev1.ContainerPort{
+		{
+			Name:          "http-auth",
+			ContainerPort: authenticationProxyPort,
+		},
+	}
+
+	container.VolumeMounts = []corev1.VolumeMount{
+		{
+			Name:      "emails",
+			MountPath: authenticationEmailsDirectory,
+			ReadOnly:  true,
+		},
+	}
+
+	deployment := newDeployment(workspace.GetNamespace(), deploymentName, deploymentLabels, pointer.Int32(1), container)
+	deployment.Spec.Template.Spec.ServiceAccountName = deploymentName
+	deployment.Spec.Template.Spec.Volumes = append(deployment.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: "emails",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: newAuthenticationEmailsName(workspace)},
+			},
+		},
+	})
+	applyScheduling(&deployment.Spec.Template.Spec, deploymentLabels, authentication.Scheduling)
+
+	return deployment
+}
+
+// newAuthenticationEmailsConfigMap lists the email addresses of the user members, one per line.
+// The proxies only use it when the workspace has no member groups or allowed groups.
+func newAuthenticationEmailsConfigMap(workspace *mlopsv1alpha1.Workspace) *corev1.ConfigMap {
+	emails := ""
+
+	for _, user := range newMemberUsers(workspace) {
+		emails += user + "\n"
+	}
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      newAuthenticationEmailsName(workspace),
+			Namespace: workspace.GetNamespace(),
+			Labels:    newComponentLabels(workspace, authenticationComponentName),
+		},
+		Data: map[string]string{
+			"emails": emails,
+		},
+	}
+}
+
+func newAuthenticationProxyServiceAccount(workspace *mlopsv1alpha1.Workspace, exposed exposedInterface) *corev1.ServiceAccount {
+	return newServiceAccount(
+		workspace.GetNamespace(),
+		newAuthenticationProxyName(workspace, exposed),
+		newComponentLabels(workspace, fmt.Sprintf("%s-auth", exposed.name)),
+		mlopsv1alpha1.ServiceAccountSpec{},
+	)
+}
+
+func newAuthenticationProxyService(workspace *mlopsv1alpha1.Workspace, exposed exposedInterface) *corev1.Service {
+	serviceLabels := newComponentLabels(workspace, fmt.Sprintf("%s-auth", exposed.name))
+	service := newService(newAuthenticationProxyName(workspace, exposed), workspace.GetNamespace(), serviceLabels)
+
+	service.Spec.Ports = []corev1.ServicePort{
+		{
+			Name:       "http-auth",
+			Protocol:   corev1.ProtocolTCP,
+			Port:       authenticationProxyPort,
+			TargetPort: intstr.FromInt(authenticationProxyPort),
+		},
+	}
+
+	return service
+}
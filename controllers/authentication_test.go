@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("authenticationComponent", func() {
+	It("Should put an authentication proxy in front of the user interfaces", func() {
+		ctx := context.Background()
+		workspace := newTestWorkspace("test-authentication")
+		workspace.Spec.Exposure = mlopsv1alpha1.ExposureSpec{
+			Mode:     mlopsv1alpha1.ExposureModeIngress,
+			Routing:  mlopsv1alpha1.ExposureRoutingHost,
+			Hostname: "ml.example.com",
+		}
+		workspace.Spec.Authentication = &mlopsv1alpha1.AuthenticationSpec{
+			IssuerURL: "http://mock-oidc.test-namespace:8080/default",
+			ClientID:  "cartographer",
+			ClientSecretRef: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "oidc-client"},
+				Key:                  "client-secret",
+			},
+			AllowedGroups: []string{"data-scientists"},
+			Image:         "quay.io/oauth2-proxy/oauth2-proxy:v7.4.0",
+		}
+
+		Expect(k8sClient.Create(ctx, workspace)).To(Succeed())
+
+		deployment := &appsv1.Deployment{}
+		deploymentName := types.NamespacedName{Name: "test-authentication-mlflow-auth", Namespace: workspace.GetNamespace()}
+
+		Eventually(func() error {
+			return k8sClient.Get(ctx, deploymentName, deployment)
+		}, time.Minute, time.Second).Should(Succeed())
+
+		Expect(deployment.Spec.Template.Spec.Containers[0].Args).To(ContainElements(
+			"--oidc-issuer-url=http://mock-oidc.test-namespace:8080/default",
+			"--client-id=cartographer",
+			"--upstream=http://test-authentication-mlflow-server:5000/",
+			"--allowed-group=data-scientists",
+			"--redirect-url=http://mlflow.ml.example.com/oauth2/callback",
+		))
+
+		Expect(deployment.Spec.Template.Spec.ServiceAccountName).To(Equal(deploymentName.Name))
+		Expect(k8sClient.Get(ctx, deploymentName, &corev1.ServiceAccount{})).To(Succeed())
+
+		cookieSecret := &corev1.Secret{}
+		cookieSecretName := types.NamespacedName{Name: "test-authentication-auth-cookie", Namespace: workspace.GetNamespace()}
+
+		Expect(k8sClient.Get(ctx, cookieSecretName, cookieSecret)).To(Succeed())
+		Expect(cookieSecret.Data["cookie-secret"]).To(HaveLen(32))
+
+		emailsConfigMap := &corev1.ConfigMap{}
+		emailsConfigMapName := types.NamespacedName{Name: "test-authentication-auth-emails", Namespace: workspace.GetNamespace()}
+
+		Expect(k8sClient.Get(ctx, emailsConfigMapName, emailsConfigMap)).To(Succeed())
+
+		ingress := getExposureIngress(ctx, workspace)
+
+		Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name).To(Equal("test-authentication-mlflow-auth"))
+		Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Port.Number).To(Equal(int32(authenticationProxyPort)))
+	})
+
+	It("Should only allow the members to sign in", func() {
+		workspace := newTestWorkspace("test-authentication-members")
+		workspace.Spec.Authentication = &mlopsv1alpha1.AuthenticationSpec{IssuerURL: "http://mock-oidc.test-namespace:8080/default"}
+		workspace.Spec.Members = []mlopsv1alpha1.WorkspaceMember{
+			{Kind: mlopsv1alpha1.WorkspaceMemberKindUser, Name: "jane@example.com", Role: mlopsv1alpha1.WorkspaceRoleOwner},
+		}
+
+		exposed := newExposedInterfaces(workspace)[0]
+
+		Expect(newAuthenticationProxyArgs(workspace, exposed)).To(ContainElement("--authenticated-emails-file=/etc/oauth2-proxy/emails"))
+		Expect(newAuthenticationProxyArgs(workspace, exposed)).NotTo(ContainElement("--email-domain=*"))
+		Expect(newAuthenticationEmailsConfigMap(workspace).Data).To(HaveKeyWithValue("emails", "jane@example.com\n"))
+
+		workspace.Spec.Members = append(workspace.Spec.Members, mlopsv1alpha1.WorkspaceMember{
+			Kind: mlopsv1alpha1.WorkspaceMemberKindGroup, Name: "data-scientists", Role: mlopsv1alpha1.WorkspaceRoleContributor,
+		})
+
+		Expect(newAuthenticationProxyArgs(workspace, exposed)).To(ContainElement("--allowed-group=data-scientists"))
+		Expect(newAuthenticationProxyArgs(workspace, exposed)).NotTo(ContainElement(ContainSubstring("--authenticated-emails-file")))
+	})
+})
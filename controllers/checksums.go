@@ -0,0 +1,280 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/strings/slices"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// configChecksumAnnotation holds the checksum of the secrets and config maps the pods of a workload consume.
+	configChecksumAnnotation = "mlops.aigency.com/config-checksum"
+
+	// consumedSecretsIndexKey indexes the workspaces by the names of the secrets their workloads consume.
+	consumedSecretsIndexKey = ".spec.consumedSecrets"
+
+	// consumedConfigMapsIndexKey indexes the workspaces by the names of the config maps their workloads consume.
+	consumedConfigMapsIndexKey = ".spec.consumedConfigMaps"
+)
+
+// configReference identifies a secret or config map consumed by a pod.
+type configReference struct {
+	kind string
+	name string
+}
+
+// getRolloutPodTemplate returns the pod template of a workload that rolls out its pods when the template changes.
+// The pod templates of jobs can't be changed, and KubeRay doesn't restart the pods of a ray cluster, so they are left out.
+func getRolloutPodTemplate(resource client.Object) *corev1.PodTemplateSpec {
+	switch workload := resource.(type) {
+	case *appsv1.Deployment:
+		return &workload.Spec.Template
+	case *appsv1.StatefulSet:
+		return &workload.Spec.Template
+	}
+
+	return nil
+}
+
+// getConfigReferences returns the secrets and config maps used by the environment variables and volumes of a pod.
+func getConfigReferences(podSpec *corev1.PodSpec) []configReference {
+	references := []configReference{}
+
+	addReference := func(kind string, name string) {
+		reference := configReference{kind: kind, name: name}
+
+		for _, existing := range references {
+			if existing == reference {
+				return
+			}
+		}
+
+		references = append(references, reference)
+	}
+
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for _, container := range containers {
+			for _, envVar := range container.Env {
+				if envVar.ValueFrom == nil {
+					continue
+				}
+
+				if envVar.ValueFrom.SecretKeyRef != nil {
+					addReference("Secret", envVar.ValueFrom.SecretKeyRef.Name)
+				}
+
+				if envVar.ValueFrom.ConfigMapKeyRef != nil {
+					addReference("ConfigMap", envVar.ValueFrom.ConfigMapKeyRef.Name)
+				}
+			}
+
+			for _, envFrom := range container.EnvFrom {
+				if envFrom.SecretRef != nil {
+					addReference("Secret", envFrom.SecretRef.Name)
+				}
+
+				if envFrom.ConfigMapRef != nil {
+					addReference("ConfigMap", envFrom.ConfigMapRef.Name)
+				}
+			}
+		}
+	}
+
+	for _, volume := range podSpec.Volumes {
+		if volume.Secret != nil {
+			addReference("Secret", volume.Secret.SecretName)
+		}
+
+		if volume.ConfigMap != nil {
+			addReference("ConfigMap", volume.ConfigMap.Name)
+		}
+	}
+
+	// The order of the references must be stable, otherwise the checksum changes on every reconcile.
+	sort.Slice(references, func(i, j int) bool {
+		if references[i].kind != references[j].kind {
+			return references[i].kind < references[j].kind
+		}
+
+		return references[i].name < references[j].name
+	})
+
+	return references
+}
+
+// newConfigChecksum hashes the contents of the secrets and config maps consumed by a pod.
+// Missing resources are part of the checksum too, so the pods restart when they are created.
+func (r *WorkspaceReconciler) newConfigChecksum(ctx context.Context, namespace string, podSpec *corev1.PodSpec) (string, error) {
+	hash := sha256.New()
+
+	for _, reference := range getConfigReferences(podSpec) {
+		data := map[string][]byte{}
+		resourceName := types.NamespacedName{Name: reference.name, Namespace: namespace}
+
+		var err error
+
+		if reference.kind == "Secret" {
+			secret := &corev1.Secret{}
+
+			if err = r.Get(ctx, resourceName, secret); err == nil {
+				data = secret.Data
+			}
+		} else {
+			configMap := &corev1.ConfigMap{}
+
+			if err = r.Get(ctx, resourceName, configMap); err == nil {
+				for key, value := range configMap.Data {
+					data[key] = []byte(value)
+				}
+
+				for key, value := range configMap.BinaryData {
+					data[key] = value
+				}
+			}
+		}
+
+		if err != nil && !errors.IsNotFound(err) {
+			return "", err
+		}
+
+		fmt.Fprintf(hash, "%s/%s:%t\n", reference.kind, reference.name, err == nil)
+
+		keys := []string{}
+
+		for key := range data {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fmt.Fprintf(hash, "%s=%x\n", key, data[key])
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// stampConfigChecksum adds the checksum of the consumed secrets and config maps to the pod template of a workload.
+// A new password or changed artifact storage credentials change the pod template, which rolls out the pods again.
+func (r *WorkspaceReconciler) stampConfigChecksum(ctx context.Context, workspace *mlopsv1alpha1.Workspace, resource client.Object) error {
+	podTemplate := getRolloutPodTemplate(resource)
+
+	if podTemplate == nil {
+		return nil
+	}
+
+	checksum, err := r.newConfigChecksum(ctx, workspace.GetNamespace(), &podTemplate.Spec)
+
+	if err != nil {
+		return err
+	}
+
+	if podTemplate.Annotations == nil {
+		podTemplate.Annotations = map[string]string{}
+	}
+
+	podTemplate.Annotations[configChecksumAnnotation] = checksum
+
+	return nil
+}
+
+// indexConsumedSecrets returns the names of the secrets consumed by the workloads of a workspace.
+func (r *WorkspaceReconciler) indexConsumedSecrets(object client.Object) []string {
+	return r.getConsumedConfigNames(object, "Secret")
+}
+
+// indexConsumedConfigMaps returns the names of the config maps consumed by the workloads of a workspace.
+func (r *WorkspaceReconciler) indexConsumedConfigMaps(object client.Object) []string {
+	return r.getConsumedConfigNames(object, "ConfigMap")
+}
+
+// getConsumedConfigNames renders the workloads of a workspace and returns the names of the secrets or config maps they consume.
+// The cache holds the workspace as stored, so we apply the defaults first, like the reconciler does before rendering.
+func (r *WorkspaceReconciler) getConsumedConfigNames(object client.Object, kind string) []string {
+	cachedWorkspace, ok := object.(*mlopsv1alpha1.Workspace)
+
+	if !ok {
+		return nil
+	}
+
+	workspace := cachedWorkspace.DeepCopy()
+	workspace.Default()
+
+	components, err := r.registry.Components()
+
+	if err != nil {
+		return nil
+	}
+
+	names := []string{}
+
+	for _, component := range components {
+		resources, err := component.Render(workspace)
+
+		if err != nil {
+			continue
+		}
+
+		for _, resource := range resources {
+			podTemplate := getRolloutPodTemplate(resource)
+
+			if podTemplate == nil {
+				continue
+			}
+
+			for _, reference := range getConfigReferences(&podTemplate.Spec) {
+				if reference.kind == kind && !slices.Contains(names, reference.name) {
+					names = append(names, reference.name)
+				}
+			}
+		}
+	}
+
+	return names
+}
+
+// findWorkspacesForSecret maps a secret to the workspaces that consume it, so changes to the secret
+// reach the workloads even when the secret belongs to another operator or the user.
+func (r *WorkspaceReconciler) findWorkspacesForSecret(secret client.Object) []reconcile.Request {
+	return r.findWorkspacesForConfig(secret, consumedSecretsIndexKey)
+}
+
+// findWorkspacesForConfigMap maps a config map to the workspaces that consume it, like findWorkspacesForSecret.
+func (r *WorkspaceReconciler) findWorkspacesForConfigMap(configMap client.Object) []reconcile.Request {
+	return r.findWorkspacesForConfig(configMap, consumedConfigMapsIndexKey)
+}
+
+func (r *WorkspaceReconciler) findWorkspacesForConfig(object client.Object, indexKey string) []reconcile.Request {
+	workspaces := &mlopsv1alpha1.WorkspaceList{}
+
+	listOptions := []client.ListOption{
+		client.InNamespace(object.GetNamespace()),
+		client.MatchingFields{indexKey: object.GetName()},
+	}
+
+	if err := r.List(context.Background(), workspaces, listOptions...); err != nil {
+		return []reconcile.Request{}
+	}
+
+	requests := []reconcile.Request{}
+
+	for _, workspace := range workspaces.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: workspace.GetName(), Namespace: workspace.GetNamespace()},
+		})
+	}
+
+	return requests
+}
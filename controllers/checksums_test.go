@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+var _ = Describe("stampConfigChecksum", func() {
+	It("Should roll out the experiment tracking server when its database password changes", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForExperimentTrackingDeployment(ctx, "test-config-checksum")
+
+		var originalChecksum string
+
+		Eventually(func() error {
+			deployment, err := getExperimentTrackingDeployment(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			originalChecksum = deployment.Spec.Template.Annotations[configChecksumAnnotation]
+
+			if originalChecksum == "" {
+				return fmt.Errorf("expected the pod template to have a config checksum")
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+
+		secretName := types.NamespacedName{Name: "test-config-checksum-pguser-mlflow", Namespace: workspace.GetNamespace()}
+
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			secret := &corev1.Secret{}
+
+			if err := k8sClient.Get(ctx, secretName, secret); err != nil {
+				return err
+			}
+
+			secret.Data["password"] = []byte("rotated")
+
+			return k8sClient.Update(ctx, secret)
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() error {
+			deployment, err := getExperimentTrackingDeployment(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			if deployment.Spec.Template.Annotations[configChecksumAnnotation] == originalChecksum {
+				return fmt.Errorf("expected the config checksum to change after rotating the password")
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+	})
+})
+
+var _ = Describe("indexConsumedSecrets", func() {
+	It("Should index the secrets of the workspace with its defaults applied", func() {
+		reconciler := &WorkspaceReconciler{}
+		reconciler.registry = newWorkspaceComponents(reconciler)
+
+		cachedWorkspace := &mlopsv1alpha1.Workspace{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-index-secrets", Namespace: "test-namespace"},
+		}
+
+		defaultedWorkspace := cachedWorkspace.DeepCopy()
+		defaultedWorkspace.Default()
+
+		Expect(reconciler.indexConsumedSecrets(cachedWorkspace)).To(ContainElement("test-index-secrets-pguser-mlflow"))
+		Expect(reconciler.indexConsumedSecrets(cachedWorkspace)).To(Equal(reconciler.indexConsumedSecrets(defaultedWorkspace)))
+		Expect(reconciler.indexConsumedConfigMaps(cachedWorkspace)).To(Equal(reconciler.indexConsumedConfigMaps(defaultedWorkspace)))
+
+		// The index must not change the workspace in the cache.
+		Expect(cachedWorkspace.Spec).To(Equal(mlopsv1alpha1.WorkspaceSpec{}))
+	})
+})
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/utils/strings/slices"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Component is a subsystem of a workspace, like the database or the experiment tracking server.
+// The reconciler uses the component to render, apply, and monitor the resources that make up the subsystem.
+type Component interface {
+	// Name returns the unique name of the component in the registry.
+	Name() string
+
+	// ConditionType returns the type of the status condition the component contributes to the workspace.
+	// Components that don't have a condition of their own return an empty string.
+	ConditionType() string
+
+	// OwnedTypes returns the types of resources the component creates, so the reconciler can watch them.
+	OwnedTypes() []client.Object
+
+	// Render produces the desired state of the resources that make up the component.
+	Render(workspace *mlopsv1alpha1.Workspace) ([]client.Object, error)
+
+	// Apply brings the resources in the cluster in line with the rendered resources.
+	Apply(ctx context.Context, workspace *mlopsv1alpha1.Workspace, resources []client.Object) error
+
+	// Ready determines the observed state of the component.
+	Ready(ctx context.Context, workspace *mlopsv1alpha1.Workspace) (componentCondition, error)
+
+	// Cleanup removes resources of the component that are no longer part of the workspace.
+	Cleanup(ctx context.Context, workspace *mlopsv1alpha1.Workspace) error
+}
+
+// componentBase provides the default implementation for applying and cleaning up the resources of a component.
+type componentBase struct {
+	reconciler *WorkspaceReconciler
+}
+
+// Apply applies all rendered resources using server-side apply.
+func (c *componentBase) Apply(ctx context.Context, workspace *mlopsv1alpha1.Workspace, resources []client.Object) error {
+	return c.reconciler.applyResources(ctx, workspace, resources)
+}
+
+// Cleanup doesn't remove anything, components with resources per item in the spec should override this.
+func (c *componentBase) Cleanup(ctx context.Context, workspace *mlopsv1alpha1.Workspace) error {
+	return nil
+}
+
+// applyResources applies a set of resources for a workspace.
+// Errors for individual resources are combined, so a failing resource doesn't block the other resources.
+func (r *WorkspaceReconciler) applyResources(ctx context.Context, workspace *mlopsv1alpha1.Workspace, resources []client.Object) error {
+	logger := log.FromContext(ctx).WithValues(
+		"workspace", workspace.GetName(),
+		"namespace", workspace.GetNamespace())
+
+	applyErrors := []error{}
+
+	for _, resource := range resources {
+		err := r.stampConfigChecksum(ctx, workspace, resource)
+
+		if err == nil {
+			err = r.applyResource(ctx, workspace, resource)
+		}
+
+		if err == nil {
+			err = r.adoptRetainedResource(ctx, workspace, resource)
+		}
+
+		if err != nil {
+			kind := resource.GetObjectKind().GroupVersionKind().Kind
+
+			logger.Error(err, "Failed to apply resource", "kind", kind, "name", resource.GetName())
+			applyErrors = append(applyErrors, fmt.Errorf("%s %s: %w", kind, resource.GetName(), err))
+		}
+	}
+
+	return utilerrors.NewAggregate(applyErrors)
+}
+
+// componentRegistry keeps track of the components in a workspace and the dependencies between them.
+type componentRegistry struct {
+	components   []Component
+	dependencies map[string][]string
+}
+
+func newComponentRegistry() *componentRegistry {
+	return &componentRegistry{
+		dependencies: map[string][]string{},
+	}
+}
+
+// Register adds a component to the registry, together with the names of the components it depends on.
+func (registry *componentRegistry) Register(component Component, dependsOn ...string) {
+	registry.components = append(registry.components, component)
+	registry.dependencies[component.Name()] = dependsOn
+}
+
+// Dependencies returns the names of the components the component depends on.
+func (registry *componentRegistry) Dependencies(componentName string) []string {
+	return registry.dependencies[componentName]
+}
+
+// Components returns the registered components in an order where each component comes after its dependencies.
+// Components without a dependency between them keep the order in which they were registered.
+func (registry *componentRegistry) Components() ([]Component, error) {
+	componentNames := []string{}
+
+	for _, component := range registry.components {
+		componentNames = append(componentNames, component.Name())
+	}
+
+	for componentName, dependencies := range registry.dependencies {
+		for _, dependency := range dependencies {
+			if !slices.Contains(componentNames, dependency) {
+				return nil, fmt.Errorf("component %s depends on unknown component %s", componentName, dependency)
+			}
+		}
+	}
+
+	orderedComponents := []Component{}
+	orderedNames := []string{}
+
+	for len(orderedComponents) < len(registry.components) {
+		progress := false
+
+		for _, component := range registry.components {
+			if slices.Contains(orderedNames, component.Name()) {
+				continue
+			}
+
+			if !registry.dependenciesIn(component.Name(), orderedNames) {
+				continue
+			}
+
+			orderedComponents = append(orderedComponents, component)
+			orderedNames = append(orderedNames, component.Name())
+			progress = true
+		}
+
+		if !progress {
+			return nil, fmt.Errorf("the dependencies between the components contain a cycle")
+		}
+	}
+
+	return orderedComponents, nil
+}
+
+func (registry *componentRegistry) dependenciesIn(componentName string, componentNames []string) bool {
+	for _, dependency := range registry.dependencies[componentName] {
+		if !slices.Contains(componentNames, dependency) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newWorkspaceComponents creates the registry with all components that make up a workspace.
+func newWorkspaceComponents(r *WorkspaceReconciler) *componentRegistry {
+	registry := newComponentRegistry()
+
+	registry.Register(&endpointsComponent{componentBase{r}})
+	registry.Register(&membersComponent{componentBase{r}})
+	registry.Register(&databaseComponent{componentBase{r}})
+	registry.Register(&objectStorageComponent{componentBase{r}})
+	registry.Register(&experimentTrackingComponent{componentBase{r}}, databaseComponentName, objectStorageComponentName)
+	registry.Register(&workflowsComponent{componentBase{r}}, databaseComponentName)
+	registry.Register(&faultToleranceComponent{componentBase{r}})
+	registry.Register(&computeComponent{componentBase{r}}, experimentTrackingComponentName, faultToleranceComponentName)
+	registry.Register(&authenticationComponent{componentBase{r}})
+	registry.Register(&exposureComponent{componentBase{r}})
+	registry.Register(&networkPolicyComponent{componentBase{r}})
+
+	return registry
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("componentRegistry", func() {
+	It("Should order components after their dependencies", func() {
+		registry := newComponentRegistry()
+
+		registry.Register(&testComponent{name: "compute"}, "experiment-tracking")
+		registry.Register(&testComponent{name: "experiment-tracking"}, "database")
+		registry.Register(&testComponent{name: "workflows"}, "database")
+		registry.Register(&testComponent{name: "database"})
+
+		components, err := registry.Components()
+		Expect(err).NotTo(HaveOccurred())
+
+		componentNames := []string{}
+
+		for _, component := range components {
+			componentNames = append(componentNames, component.Name())
+		}
+
+		Expect(componentNames).To(Equal([]string{"database", "experiment-tracking", "workflows", "compute"}))
+	})
+
+	It("Should order the workspace components after their dependencies", func() {
+		registry := newWorkspaceComponents(&WorkspaceReconciler{})
+
+		components, err := registry.Components()
+		Expect(err).NotTo(HaveOccurred())
+
+		orderedNames := []string{}
+
+		for _, component := range components {
+			for _, dependency := range registry.Dependencies(component.Name()) {
+				Expect(orderedNames).To(ContainElement(dependency))
+			}
+
+			orderedNames = append(orderedNames, component.Name())
+		}
+	})
+
+	It("Should fail for unknown dependencies", func() {
+		registry := newComponentRegistry()
+		registry.Register(&testComponent{name: "compute"}, "experiment-tracking")
+
+		_, err := registry.Components()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should fail for circular dependencies", func() {
+		registry := newComponentRegistry()
+		registry.Register(&testComponent{name: "experiment-tracking"}, "workflows")
+		registry.Register(&testComponent{name: "workflows"}, "experiment-tracking")
+
+		_, err := registry.Components()
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("reconcileComponents", func() {
+	It("Should hold back components until their dependencies are ready", func() {
+		ctx := context.Background()
+		workspace := newTestWorkspace("test-component-dependencies")
+
+		err := k8sClient.Create(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() error {
+			updatedWorkspace, err := getWorkspace(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			condition := meta.FindStatusCondition(updatedWorkspace.Status.Conditions, mlopsv1alpha1.ConditionTypeExperimentTrackingReady)
+
+			if condition == nil || condition.Reason != reasonWaiting {
+				return fmt.Errorf("expected the experiment tracking server to wait for the database")
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+
+		_, err = getExperimentTrackingDeployment(workspace)
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+
+		simulateDatabaseReady(ctx, workspace)
+
+		Eventually(func() error {
+			_, err := getExperimentTrackingDeployment(workspace)
+			return err
+		}, time.Minute, time.Second).Should(Succeed())
+
+		_, err = getRayCluster(workspace)
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+
+		simulateDeploymentAvailable(ctx, workspace.GetNamespace(), fmt.Sprintf("%s-mlflow-server", workspace.GetName()))
+
+		Eventually(func() error {
+			_, err := getRayCluster(workspace)
+			return err
+		}, time.Minute, time.Second).Should(Succeed())
+	})
+
+	It("Should determine the status of each component once", func() {
+		database := &testComponent{name: "database"}
+		experimentTracking := &testComponent{name: "experiment-tracking"}
+
+		reconciler := &WorkspaceReconciler{registry: newComponentRegistry()}
+		reconciler.registry.Register(database)
+		reconciler.registry.Register(experimentTracking, "database")
+
+		components, err := reconciler.registry.Components()
+		Expect(err).NotTo(HaveOccurred())
+
+		conditions, reconcileErrors, waiting := reconciler.reconcileComponents(context.Background(), newTestWorkspace("test-component-ready"), components)
+
+		Expect(reconcileErrors).To(BeEmpty())
+		Expect(waiting).To(BeEmpty())
+		Expect(conditions).To(HaveKeyWithValue("experiment-tracking", componentCondition{ready: true}))
+		Expect(database.readyCalls).To(Equal(1))
+		Expect(experimentTracking.readyCalls).To(Equal(1))
+	})
+})
+
+type testComponent struct {
+	componentBase
+	name       string
+	readyCalls int
+}
+
+func (c *testComponent) Name() string {
+	return c.name
+}
+
+func (c *testComponent) ConditionType() string {
+	return c.name
+}
+
+func (c *testComponent) OwnedTypes() []client.Object {
+	return nil
+}
+
+func (c *testComponent) Render(workspace *mlopsv1alpha1.Workspace) ([]client.Object, error) {
+	return nil, nil
+}
+
+func (c *testComponent) Ready(ctx context.Context, workspace *mlopsv1alpha1.Workspace) (componentCondition, error) {
+	c.readyCalls++
+	return componentCondition{ready: true}, nil
+}
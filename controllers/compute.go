@@ -2,73 +2,163 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"reflect"
+	"strconv"
 
-	"github.com/go-logr/logr"
 	ray "github.com/ray-project/kuberay/ray-operator/apis/ray/v1alpha1"
 	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/pointer"
-	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-func (r *WorkspaceReconciler) reconcileRayCluster(ctx context.Context, workspace *mlopsv1alpha1.Workspace) error {
+const (
+	computeComponentName = "compute"
+
+	// rayWorkerInitImage is used by the init container that waits for the ray head.
+	rayWorkerInitImage = "busybox:1.35"
+
+	// rayFaultToleranceAnnotation tells KubeRay to restore the state of the ray head from Redis after a restart.
+	rayFaultToleranceAnnotation = "ray.io/ft-enabled"
+
+	// rayHeadReconnectTimeoutSeconds is how long the workers wait for a restarted ray head.
+	rayHeadReconnectTimeoutSeconds = 600
+)
+
+// computeComponent manages the ray cluster that runs the distributed workloads in the workspace.
+type computeComponent struct {
+	componentBase
+}
+
+func (c *computeComponent) Name() string {
+	return computeComponentName
+}
+
+func (c *computeComponent) ConditionType() string {
+	return mlopsv1alpha1.ConditionTypeComputeReady
+}
+
+func (c *computeComponent) OwnedTypes() []client.Object {
+	return []client.Object{&ray.RayCluster{}, &corev1.ServiceAccount{}}
+}
+
+func (c *computeComponent) Render(workspace *mlopsv1alpha1.Workspace) ([]client.Object, error) {
+	resources := []client.Object{newRayHeadServiceAccount(workspace)}
+
+	for index := range workspace.Spec.Compute.WorkerPools {
+		resources = append(resources, newRayWorkerServiceAccount(workspace, &workspace.Spec.Compute.WorkerPools[index]))
+	}
+
+	return append(resources, newRayCluster(workspace)), nil
+}
+
+// Cleanup removes the service accounts of worker pools that are no longer part of the workspace spec.
+// KubeRay removes the pods of the worker pools themselves.
+func (c *computeComponent) Cleanup(ctx context.Context, workspace *mlopsv1alpha1.Workspace) error {
 	logger := log.FromContext(ctx).WithValues(
 		"workspace", workspace.GetName(),
 		"namespace", workspace.GetNamespace())
 
-	clusterName := fmt.Sprintf("%s-ray", workspace.Name)
-	rayCluster := &ray.RayCluster{}
-
-	if err := r.Get(ctx, types.NamespacedName{Name: clusterName, Namespace: workspace.Namespace}, rayCluster); err != nil {
-		if errors.IsNotFound(err) {
-			return r.createComputeCluster(ctx, workspace, logger)
-		}
+	poolNames := []string{}
 
-		logger.Error(err, "Failed to get the compute cluster for the workspace")
-		return err
+	for _, workerPoolSpec := range workspace.Spec.Compute.WorkerPools {
+		poolNames = append(poolNames, workerPoolSpec.Name)
 	}
 
-	return r.updateComputeCluster(ctx, workspace, rayCluster, logger)
+	return c.reconciler.pruneOwnedPoolResources(ctx, logger, workspace, &corev1.ServiceAccountList{}, "ray-worker", poolNames)
 }
 
-func (r *WorkspaceReconciler) updateComputeCluster(ctx context.Context, workspace *mlopsv1alpha1.Workspace, rayCluster *ray.RayCluster, logger logr.Logger) error {
-	if rayCluster.Spec.HeadGroupSpec.Replicas != workspace.Spec.Compute.Controller.Replicas {
-		rayCluster.Spec.HeadGroupSpec.Replicas = workspace.Spec.Compute.Controller.Replicas
-	}
+// Apply records the worker pools removed from the ray cluster before applying the new cluster spec.
+// With autoscaling enabled, it keeps the number of workers the autoscaler chose. The cluster is read from the API
+// server rather than the cache, and applied with the version that was read. When the autoscaler scales the workers
+// in the meantime, the apply fails with a conflict and the next reconcile starts over from the new state.
+func (c *computeComponent) Apply(ctx context.Context, workspace *mlopsv1alpha1.Workspace, resources []client.Object) error {
+	logger := log.FromContext(ctx).WithValues(
+		"workspace", workspace.GetName(),
+		"namespace", workspace.GetNamespace())
 
-	if rayCluster.Spec.HeadGroupSpec.Template.Spec.Containers[0].Image != workspace.Spec.Compute.Controller.Image {
-		rayCluster.Spec.HeadGroupSpec.Template.Spec.Containers[0].Image = workspace.Spec.Compute.Controller.Image
-	}
+	clusterName := newRayClusterName(workspace)
+	currentRayCluster := &ray.RayCluster{}
 
-	if !reflect.DeepEqual(rayCluster.Spec.HeadGroupSpec.Template.Spec.Containers[0].Resources, workspace.Spec.Compute.Controller.Resources) {
-		rayCluster.Spec.HeadGroupSpec.Template.Spec.Containers[0].Resources = workspace.Spec.Compute.Controller.Resources
+	if err := c.reconciler.APIReader.Get(ctx, types.NamespacedName{Name: clusterName, Namespace: workspace.Namespace}, currentRayCluster); err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "Failed to get the compute cluster for the workspace")
+			return err
+		}
 	}
 
-	if !reflect.DeepEqual(rayCluster.Spec.WorkerGroupSpecs, newWorkerGroups(workspace)) {
-		workerGroups := newWorkerGroups(workspace)
-		rayCluster.Spec.WorkerGroupSpecs = workerGroups
+	for _, resource := range resources {
+		if rayCluster, ok := resource.(*ray.RayCluster); ok {
+			c.reconciler.recordPrunedWorkerGroups(logger, workspace,
+				getWorkerGroupNames(currentRayCluster.Spec.WorkerGroupSpecs),
+				getWorkerGroupNames(rayCluster.Spec.WorkerGroupSpecs))
+
+			if workspace.Spec.Compute.Autoscaling.Enabled {
+				preserveAutoscalerState(currentRayCluster, rayCluster)
+				rayCluster.SetResourceVersion(currentRayCluster.GetResourceVersion())
+			}
+		}
 	}
 
-	if err := r.Update(ctx, rayCluster); err != nil {
-		logger.Error(err, "Failed to update compute cluster for the workspace")
-		return err
+	return c.reconciler.applyResources(ctx, workspace, resources)
+}
+
+func (c *computeComponent) Ready(ctx context.Context, workspace *mlopsv1alpha1.Workspace) (componentCondition, error) {
+	clusterName := newRayClusterName(workspace)
+	rayCluster := &ray.RayCluster{}
+
+	if err := c.reconciler.Get(ctx, types.NamespacedName{Name: clusterName, Namespace: workspace.GetNamespace()}, rayCluster); err != nil {
+		if errors.IsNotFound(err) {
+			return componentCondition{reason: reasonNotFound, message: "The ray cluster does not exist yet"}, nil
+		}
+
+		return componentCondition{}, err
 	}
 
-	return nil
+	return newRayClusterCondition(rayCluster), nil
+}
+
+func newRayClusterName(workspace *mlopsv1alpha1.Workspace) string {
+	return fmt.Sprintf("%s-ray", workspace.GetName())
+}
+
+func newRayHeadServiceAccountName(workspace *mlopsv1alpha1.Workspace) string {
+	return fmt.Sprintf("%s-head", newRayClusterName(workspace))
+}
+
+func newRayWorkerServiceAccountName(workspace *mlopsv1alpha1.Workspace, workerPoolName string) string {
+	return fmt.Sprintf("%s-worker-%s", newRayClusterName(workspace), workerPoolName)
 }
 
-func (r *WorkspaceReconciler) createComputeCluster(ctx context.Context, workspace *mlopsv1alpha1.Workspace, logger logr.Logger) error {
-	logger.Info("Compute cluster not found. Creating new ray cluster for workspace")
+func newRayHeadServiceAccount(workspace *mlopsv1alpha1.Workspace) *corev1.ServiceAccount {
+	return newServiceAccount(
+		workspace.GetNamespace(),
+		newRayHeadServiceAccountName(workspace),
+		newComponentLabels(workspace, "ray-controller"),
+		workspace.Spec.Compute.Controller.ServiceAccount,
+	)
+}
+
+func newRayWorkerServiceAccount(workspace *mlopsv1alpha1.Workspace, workerPoolSpec *mlopsv1alpha1.ComputeWorkerPoolSpec) *corev1.ServiceAccount {
+	serviceAccountLabels := newComponentLabels(workspace, "ray-worker")
+	serviceAccountLabels["mlops.aigency.com/pool"] = workerPoolSpec.Name
 
-	clusterName := fmt.Sprintf("%s-ray", workspace.Name)
-	controllerSpec := newRayClusterController(workspace)
-	workerGroups := newWorkerGroups(workspace)
+	return newServiceAccount(
+		workspace.GetNamespace(),
+		newRayWorkerServiceAccountName(workspace, workerPoolSpec.Name),
+		serviceAccountLabels,
+		workerPoolSpec.ServiceAccount,
+	)
+}
+
+func newRayCluster(workspace *mlopsv1alpha1.Workspace) *ray.RayCluster {
+	clusterName := newRayClusterName(workspace)
 
 	rayCluster := &ray.RayCluster{
 		ObjectMeta: metav1.ObjectMeta{
@@ -76,23 +166,148 @@ func (r *WorkspaceReconciler) createComputeCluster(ctx context.Context, workspac
 			Namespace: workspace.Namespace,
 		},
 		Spec: ray.RayClusterSpec{
-			RayVersion:       workspace.Spec.Compute.RayVersion,
-			HeadGroupSpec:    controllerSpec,
-			WorkerGroupSpecs: workerGroups,
+			RayVersion:              workspace.Spec.Compute.RayVersion,
+			HeadGroupSpec:           newRayClusterController(workspace),
+			WorkerGroupSpecs:        newWorkerGroups(workspace),
+			EnableInTreeAutoscaling: pointer.Bool(workspace.Spec.Compute.Autoscaling.Enabled),
+			AutoscalerOptions:       newAutoscalerOptions(workspace),
 		},
 	}
 
-	if err := ctrl.SetControllerReference(workspace, rayCluster, r.Scheme); err != nil {
-		logger.Error(err, "Failed to set controller reference for ray cluster")
-		return err
+	applyFaultTolerance(rayCluster, workspace)
+
+	return rayCluster
+}
+
+// newStandaloneRayClusterSpec copies the compute cluster of the workspace for workloads that run on their own cluster,
+// like ephemeral training jobs and model deployments. The copy doesn't share the Redis instance of the workspace.
+func newStandaloneRayClusterSpec(workspace *mlopsv1alpha1.Workspace) *ray.RayClusterSpec {
+	standaloneWorkspace := workspace.DeepCopy()
+	standaloneWorkspace.Spec.Compute.FaultTolerance.Enabled = false
+
+	return &newRayCluster(standaloneWorkspace).Spec
+}
+
+// mountConfigMapDirectory mounts the files in a config map into every pod of a ray cluster and adds them to the python path.
+func mountConfigMapDirectory(rayClusterSpec *ray.RayClusterSpec, volumeName string, configMapName string, mountPath string) {
+	for _, podSpec := range newRayClusterPodSpecs(rayClusterSpec) {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: volumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: configMapName},
+				},
+			},
+		})
+
+		container := &podSpec.Containers[0]
+
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      volumeName,
+			MountPath: mountPath,
+			ReadOnly:  true,
+		})
+
+		container.Env = append(container.Env, corev1.EnvVar{Name: "PYTHONPATH", Value: mountPath})
+	}
+}
+
+// newRayClusterPodSpecs returns the pod specs of the head and all worker groups of a ray cluster.
+func newRayClusterPodSpecs(rayClusterSpec *ray.RayClusterSpec) []*corev1.PodSpec {
+	podSpecs := []*corev1.PodSpec{&rayClusterSpec.HeadGroupSpec.Template.Spec}
+
+	for index := range rayClusterSpec.WorkerGroupSpecs {
+		podSpecs = append(podSpecs, &rayClusterSpec.WorkerGroupSpecs[index].Template.Spec)
+	}
+
+	return podSpecs
+}
+
+// applyFaultTolerance stores the state of the ray head in Redis, so KubeRay can restart the head
+// without restarting the workers and the jobs running on them.
+func applyFaultTolerance(rayCluster *ray.RayCluster, workspace *mlopsv1alpha1.Workspace) {
+	if !workspace.Spec.Compute.FaultTolerance.Enabled {
+		return
 	}
 
-	if err := r.Create(ctx, rayCluster); err != nil {
-		logger.Error(err, "Failed to create ray cluster for workspace")
-		return err
+	rayCluster.Annotations = map[string]string{
+		rayFaultToleranceAnnotation: "true",
 	}
 
-	return nil
+	headContainer := &rayCluster.Spec.HeadGroupSpec.Template.Spec.Containers[0]
+	headContainer.Env = append(headContainer.Env, corev1.EnvVar{Name: "RAY_REDIS_ADDRESS", Value: newRedisAddress(workspace)})
+
+	// KubeRay runs ray start in a shell, so the password is read from the environment instead of the cluster spec.
+	if redisPasswordEnvVars := newRedisPasswordEnvVars(workspace); len(redisPasswordEnvVars) > 0 {
+		headContainer.Env = append(headContainer.Env, redisPasswordEnvVars...)
+		rayCluster.Spec.HeadGroupSpec.RayStartParams["redis-password"] = "$REDIS_PASSWORD"
+	}
+
+	// The workers keep trying to reach the head while it restarts, instead of exiting after a minute.
+	for index := range rayCluster.Spec.WorkerGroupSpecs {
+		workerContainer := &rayCluster.Spec.WorkerGroupSpecs[index].Template.Spec.Containers[0]
+		workerContainer.Env = append(workerContainer.Env, corev1.EnvVar{
+			Name:  "RAY_gcs_rpc_server_reconnect_timeout_s",
+			Value: strconv.Itoa(rayHeadReconnectTimeoutSeconds),
+		})
+	}
+}
+
+// newAutoscalerOptions configures the autoscaler that KubeRay runs next to the ray head.
+func newAutoscalerOptions(workspace *mlopsv1alpha1.Workspace) *ray.AutoscalerOptions {
+	autoscaling := workspace.Spec.Compute.Autoscaling
+
+	if !autoscaling.Enabled {
+		return nil
+	}
+
+	autoscalerOptions := &ray.AutoscalerOptions{
+		IdleTimeoutSeconds: autoscaling.IdleTimeoutSeconds,
+		SecurityContext:    newSecurityContext(),
+	}
+
+	if len(autoscaling.Resources.Limits) > 0 || len(autoscaling.Resources.Requests) > 0 {
+		autoscalerOptions.Resources = autoscaling.Resources.DeepCopy()
+	}
+
+	if autoscaling.UpscalingMode != "" {
+		upscalingMode := ray.UpscalingMode(autoscaling.UpscalingMode)
+		autoscalerOptions.UpscalingMode = &upscalingMode
+	}
+
+	return autoscalerOptions
+}
+
+// preserveAutoscalerState keeps the number of replicas and the workers to remove that the autoscaler chose.
+// The worker groups are applied as a whole, so without this every reconcile would undo the decisions of the autoscaler.
+func preserveAutoscalerState(currentRayCluster *ray.RayCluster, rayCluster *ray.RayCluster) {
+	currentWorkerGroups := map[string]ray.WorkerGroupSpec{}
+
+	for _, workerGroup := range currentRayCluster.Spec.WorkerGroupSpecs {
+		currentWorkerGroups[workerGroup.GroupName] = workerGroup
+	}
+
+	for index := range rayCluster.Spec.WorkerGroupSpecs {
+		workerGroup := &rayCluster.Spec.WorkerGroupSpecs[index]
+		currentWorkerGroup, ok := currentWorkerGroups[workerGroup.GroupName]
+
+		if !ok || currentWorkerGroup.Replicas == nil {
+			continue
+		}
+
+		replicas := *currentWorkerGroup.Replicas
+
+		if workerGroup.MinReplicas != nil && replicas < *workerGroup.MinReplicas {
+			replicas = *workerGroup.MinReplicas
+		}
+
+		if workerGroup.MaxReplicas != nil && replicas > *workerGroup.MaxReplicas {
+			replicas = *workerGroup.MaxReplicas
+		}
+
+		workerGroup.Replicas = pointer.Int32(replicas)
+		workerGroup.ScaleStrategy = currentWorkerGroup.ScaleStrategy
+	}
 }
 
 func newRayClusterController(workspace *mlopsv1alpha1.Workspace) ray.HeadGroupSpec {
@@ -114,11 +329,13 @@ func newRayClusterController(workspace *mlopsv1alpha1.Workspace) ray.HeadGroupSp
 				Labels: rayClusterLabels,
 			},
 			Spec: corev1.PodSpec{
+				ServiceAccountName: newRayHeadServiceAccountName(workspace),
 				Containers: []corev1.Container{
 					{
 						Name:      "ray-head",
 						Image:     workspace.Spec.Compute.Controller.Image,
 						Resources: workspace.Spec.Compute.Controller.Resources,
+						Env:       append(newWorkspaceEndpoints(workspace).newComputeEnvVars(), newObjectStorageCredentialEnvVars(workspace)...),
 						Ports: []corev1.ContainerPort{
 							{
 								Name:          "tcp-gcs",
@@ -148,24 +365,27 @@ func newRayClusterController(workspace *mlopsv1alpha1.Workspace) ray.HeadGroupSp
 		},
 	}
 
+	hardenRayPodSpec(&controllerSpec.Template.Spec)
+	applyScheduling(&controllerSpec.Template.Spec, rayClusterLabels, workspace.Spec.Compute.Controller.Scheduling)
+
 	return controllerSpec
 }
 
 func newWorkerGroups(workspace *mlopsv1alpha1.Workspace) []ray.WorkerGroupSpec {
 	workerGroups := []ray.WorkerGroupSpec{}
 
-	for _, workerGroup := range workspace.Spec.Compute.WorkerPools {
+	for _, workerPoolSpec := range workspace.Spec.Compute.WorkerPools {
 		workerGroupLabels := map[string]string{
 			"mlops.aigency.com/workspace": workspace.GetName(),
 			"mlops.aigency.com/component": "ray-worker",
-			"mlops.aigency.com/pool":      workerGroup.Name,
+			"mlops.aigency.com/pool":      workerPoolSpec.Name,
 		}
 
 		workerGroup := ray.WorkerGroupSpec{
-			GroupName:   workerGroup.Name,
-			Replicas:    workerGroup.MinReplicas,
-			MinReplicas: workerGroup.MinReplicas,
-			MaxReplicas: workerGroup.MaxReplicas,
+			GroupName:   workerPoolSpec.Name,
+			Replicas:    workerPoolSpec.MinReplicas,
+			MinReplicas: workerPoolSpec.MinReplicas,
+			MaxReplicas: workerPoolSpec.MaxReplicas,
 			RayStartParams: map[string]string{
 				"block": "true",
 			},
@@ -174,11 +394,12 @@ func newWorkerGroups(workspace *mlopsv1alpha1.Workspace) []ray.WorkerGroupSpec {
 					Labels: workerGroupLabels,
 				},
 				Spec: corev1.PodSpec{
+					ServiceAccountName: newRayWorkerServiceAccountName(workspace, workerPoolSpec.Name),
 					Containers: []corev1.Container{
 						{
 							Name:      "ray-worker",
-							Image:     workerGroup.Image,
-							Resources: workerGroup.Resources,
+							Image:     workerPoolSpec.Image,
+							Resources: workerPoolSpec.Resources,
 							Lifecycle: &corev1.Lifecycle{
 								PreStop: &corev1.LifecycleHandler{
 									Exec: &corev1.ExecAction{
@@ -186,27 +407,153 @@ func newWorkerGroups(workspace *mlopsv1alpha1.Workspace) []ray.WorkerGroupSpec {
 									},
 								},
 							},
-							Env: []corev1.EnvVar{
-								{
-									Name:  "MLFLOW_TRACKING_URI",
-									Value: fmt.Sprintf("http://%s-orion-server:4200/api", workspace.GetName()),
-								},
-							},
+							Env: append(newWorkspaceEndpoints(workspace).newComputeEnvVars(), newObjectStorageCredentialEnvVars(workspace)...),
 						},
 					},
 					InitContainers: []corev1.Container{
-						{
-							Name:    "ray-worker-init",
-							Image:   "busybox:1.35",
-							Command: []string{"sh", "-c", "until nslookup $RAY_IP.$(cat /var/run/secrets/kubernetes.io/serviceaccount/namespace).svc.cluster.local; do echo waiting for K8s Service $RAY_IP; sleep 2; done"},
-						},
+						newRayWorkerInitContainer(),
 					},
 				},
 			},
 		}
 
+		hardenRayPodSpec(&workerGroup.Template.Spec)
+		applyScheduling(&workerGroup.Template.Spec, workerGroupLabels, workerPoolSpec.Scheduling)
+		applyAccelerators(&workerGroup, workerPoolSpec.Accelerators)
+
 		workerGroups = append(workerGroups, workerGroup)
 	}
 
 	return workerGroups
 }
+
+// applyAccelerators attaches the accelerators of a worker pool to its workers and offers them to Ray.
+// NVIDIA GPUs are offered as GPUs, other accelerators as a custom resource with the name of the extended resource.
+func applyAccelerators(workerGroup *ray.WorkerGroupSpec, acceleratorSpec *mlopsv1alpha1.AcceleratorSpec) {
+	if acceleratorSpec == nil {
+		return
+	}
+
+	resourceName := acceleratorSpec.ResourceName
+
+	if resourceName == "" {
+		resourceName = mlopsv1alpha1.NvidiaGPUResourceName
+	}
+
+	podSpec := &workerGroup.Template.Spec
+	container := &podSpec.Containers[0]
+	quantity := *resource.NewQuantity(acceleratorSpec.Count, resource.DecimalSI)
+
+	// Extended resources can't be overcommitted, so the requests must be the same as the limits.
+	container.Resources.Limits = container.Resources.Limits.DeepCopy()
+	container.Resources.Requests = container.Resources.Requests.DeepCopy()
+
+	if container.Resources.Limits == nil {
+		container.Resources.Limits = corev1.ResourceList{}
+	}
+
+	if container.Resources.Requests == nil {
+		container.Resources.Requests = corev1.ResourceList{}
+	}
+
+	container.Resources.Limits[resourceName] = quantity
+	container.Resources.Requests[resourceName] = quantity
+
+	customResources := map[string]int64{}
+
+	if resourceName == mlopsv1alpha1.NvidiaGPUResourceName {
+		workerGroup.RayStartParams["num-gpus"] = strconv.FormatInt(acceleratorSpec.Count, 10)
+	} else {
+		customResources[string(resourceName)] = acceleratorSpec.Count
+	}
+
+	if acceleratorSpec.Type != "" {
+		customResources[fmt.Sprintf("accelerator_type:%s", acceleratorSpec.Type)] = 1
+
+		nodeLabel := acceleratorSpec.NodeLabel
+
+		if nodeLabel == "" && resourceName == mlopsv1alpha1.NvidiaGPUResourceName {
+			nodeLabel = mlopsv1alpha1.AcceleratorTypeNodeLabel
+		}
+
+		// Without a label we can't select the nodes, the webhook requires one for accelerators other than NVIDIA GPUs.
+		if nodeLabel != "" {
+			nodeSelector := map[string]string{}
+
+			for labelName, labelValue := range podSpec.NodeSelector {
+				nodeSelector[labelName] = labelValue
+			}
+
+			nodeSelector[nodeLabel] = acceleratorSpec.Type
+			podSpec.NodeSelector = nodeSelector
+		}
+	}
+
+	if len(customResources) > 0 {
+		// Marshalling a map with string keys can't fail, and the keys are sorted so the value is stable.
+		resourcesJSON, _ := json.Marshal(customResources)
+		workerGroup.RayStartParams["resources"] = fmt.Sprintf("'%s'", resourcesJSON)
+	}
+
+	// Nodes with accelerators are usually tainted with the name of the extended resource.
+	toleration := corev1.Toleration{
+		Key:      string(resourceName),
+		Operator: corev1.TolerationOpExists,
+		Effect:   corev1.TaintEffectNoSchedule,
+	}
+
+	for _, existing := range podSpec.Tolerations {
+		if existing.Key == toleration.Key {
+			return
+		}
+	}
+
+	podSpec.Tolerations = append(append([]corev1.Toleration{}, podSpec.Tolerations...), toleration)
+}
+
+// newRayWorkerInitContainer waits for the ray head before the worker starts.
+// The container runs as the unprivileged user of the pod, which can still resolve the address of the head.
+func newRayWorkerInitContainer() corev1.Container {
+	container := newContainer("ray-worker-init", rayWorkerInitImage, corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("100m"),
+			corev1.ResourceMemory: resource.MustParse("32Mi"),
+		},
+	})
+
+	container.Command = []string{"sh", "-c", "until nslookup $RAY_IP.$(cat /var/run/secrets/kubernetes.io/serviceaccount/namespace).svc.cluster.local; do echo waiting for K8s Service $RAY_IP; sleep 2; done"}
+
+	return container
+}
+
+// hardenRayPodSpec prepares a ray pod for the restricted Pod Security Standard. Ray keeps its object store in shared memory,
+// which is not writable with a read-only root filesystem, so the pod gets a memory-backed volume for it.
+func hardenRayPodSpec(podSpec *corev1.PodSpec) {
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "shared-memory",
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{
+				Medium: corev1.StorageMediumMemory,
+			},
+		},
+	})
+
+	for index := range podSpec.Containers {
+		podSpec.Containers[index].VolumeMounts = append(podSpec.Containers[index].VolumeMounts, corev1.VolumeMount{
+			Name:      "shared-memory",
+			MountPath: "/dev/shm",
+		})
+	}
+
+	hardenPodSpec(podSpec)
+}
+
+func getWorkerGroupNames(workerGroups []ray.WorkerGroupSpec) []string {
+	groupNames := []string{}
+
+	for _, workerGroup := range workerGroups {
+		groupNames = append(groupNames, workerGroup.GroupName)
+	}
+
+	return groupNames
+}
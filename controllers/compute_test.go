@@ -9,7 +9,13 @@ import (
 	. "github.com/onsi/gomega"
 	ray "github.com/ray-project/kuberay/ray-operator/apis/ray/v1alpha1"
 	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/utils/pointer"
 )
 
@@ -23,10 +29,9 @@ var _ = Describe("reconcileComputeCluster", func() {
 		ctx := context.Background()
 		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-compute-image")
 
-		workspace.Spec.Compute.Controller.Image = "test-image"
-
-		err := k8sClient.Update(ctx, workspace)
-		Expect(err).NotTo(HaveOccurred())
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.Compute.Controller.Image = "test-image"
+		})
 
 		Eventually(func() error {
 			computeCluster, err := getRayCluster(workspace)
@@ -47,10 +52,9 @@ var _ = Describe("reconcileComputeCluster", func() {
 		ctx := context.Background()
 		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-compute-replicas")
 
-		workspace.Spec.Compute.Controller.Replicas = pointer.Int32(2)
-
-		err := k8sClient.Update(ctx, workspace)
-		Expect(err).NotTo(HaveOccurred())
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.Compute.Controller.Replicas = pointer.Int32(2)
+		})
 
 		Eventually(func() error {
 			computeCluster, err := getRayCluster(workspace)
@@ -71,11 +75,10 @@ var _ = Describe("reconcileComputeCluster", func() {
 		ctx := context.Background()
 		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-compute-workers-replicas")
 
-		workspace.Spec.Compute.WorkerPools[0].MinReplicas = pointer.Int32(2)
-		workspace.Spec.Compute.WorkerPools[0].MaxReplicas = pointer.Int32(2)
-
-		err := k8sClient.Update(ctx, workspace)
-		Expect(err).NotTo(HaveOccurred())
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.Compute.WorkerPools[0].MinReplicas = pointer.Int32(2)
+			workspace.Spec.Compute.WorkerPools[0].MaxReplicas = pointer.Int32(2)
+		})
 
 		Eventually(func() error {
 			computeCluster, err := getRayCluster(workspace)
@@ -104,10 +107,9 @@ var _ = Describe("reconcileComputeCluster", func() {
 		ctx := context.Background()
 		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-compute-workers-image")
 
-		workspace.Spec.Compute.WorkerPools[0].Image = "test-image"
-
-		err := k8sClient.Update(ctx, workspace)
-		Expect(err).NotTo(HaveOccurred())
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.Compute.WorkerPools[0].Image = "test-image"
+		})
 
 		Eventually(func() error {
 			computeCluster, err := getRayCluster(workspace)
@@ -123,6 +125,228 @@ var _ = Describe("reconcileComputeCluster", func() {
 			return nil
 		}, time.Minute, time.Second).Should(Succeed())
 	})
+
+	It("Should run the ray pods as their own service accounts", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-compute-serviceaccounts")
+
+		rayCluster, err := getRayCluster(workspace)
+		Expect(err).NotTo(HaveOccurred())
+
+		workerPoolName := workspace.Spec.Compute.WorkerPools[0].Name
+
+		Expect(rayCluster.Spec.HeadGroupSpec.Template.Spec.ServiceAccountName).To(Equal("test-compute-serviceaccounts-ray-head"))
+		Expect(rayCluster.Spec.WorkerGroupSpecs[0].Template.Spec.ServiceAccountName).To(Equal(
+			fmt.Sprintf("test-compute-serviceaccounts-ray-worker-%s", workerPoolName)))
+
+		serviceAccountName := types.NamespacedName{
+			Name:      fmt.Sprintf("test-compute-serviceaccounts-ray-worker-%s", workerPoolName),
+			Namespace: workspace.GetNamespace(),
+		}
+
+		Expect(k8sClient.Get(ctx, serviceAccountName, &corev1.ServiceAccount{})).To(Succeed())
+
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.Compute.WorkerPools[0].ServiceAccount.Annotations = map[string]string{
+				"iam.gke.io/gcp-service-account": "ray-workers@example.iam.gserviceaccount.com",
+			}
+		})
+
+		Eventually(func() (map[string]string, error) {
+			serviceAccount := &corev1.ServiceAccount{}
+			err := k8sClient.Get(ctx, serviceAccountName, serviceAccount)
+			return serviceAccount.GetAnnotations(), err
+		}, time.Minute, time.Second).Should(HaveKeyWithValue("iam.gke.io/gcp-service-account", "ray-workers@example.iam.gserviceaccount.com"))
+	})
+
+	It("Should schedule the ray workers on the configured nodes", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-compute-scheduling")
+
+		toleration := corev1.Toleration{Key: "memory", Operator: corev1.TolerationOpEqual, Value: "high", Effect: corev1.TaintEffectNoSchedule}
+
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.Compute.WorkerPools[0].Scheduling = mlopsv1alpha1.SchedulingSpec{
+				NodeSelector: map[string]string{"node-pool": "big-memory"},
+				Tolerations:  []corev1.Toleration{toleration},
+			}
+		})
+
+		Eventually(func() error {
+			computeCluster, err := getRayCluster(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			podSpec := computeCluster.Spec.WorkerGroupSpecs[0].Template.Spec
+
+			if podSpec.NodeSelector["node-pool"] != "big-memory" || len(podSpec.Tolerations) != 1 {
+				return fmt.Errorf("scheduling not updated")
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+	})
+
+	It("Should attach the accelerators of a worker pool to the workers", func() {
+		ctx := context.Background()
+
+		// There's no device plugin in the test environment, so we add a node with a fake extended resource.
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "test-compute-accelerators-node",
+				Labels: map[string]string{"example.com/accelerator": "fpga-v1"},
+			},
+		}
+
+		Expect(k8sClient.Create(ctx, node)).To(Succeed())
+
+		node.Status.Capacity = corev1.ResourceList{"example.com/fpga": resource.MustParse("2")}
+		node.Status.Allocatable = node.Status.Capacity
+		Expect(k8sClient.Status().Update(ctx, node)).To(Succeed())
+
+		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-compute-accelerators")
+
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.Compute.WorkerPools[0].Accelerators = &mlopsv1alpha1.AcceleratorSpec{
+				ResourceName: "example.com/fpga",
+				Count:        2,
+				Type:         "fpga-v1",
+				NodeLabel:    "example.com/accelerator",
+			}
+		})
+
+		var workerGroup ray.WorkerGroupSpec
+
+		Eventually(func() error {
+			computeCluster, err := getRayCluster(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			workerGroup = computeCluster.Spec.WorkerGroupSpecs[0]
+
+			if workerGroup.RayStartParams["resources"] == "" {
+				return fmt.Errorf("accelerators not attached")
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+
+		Expect(workerGroup.RayStartParams["resources"]).To(Equal(`'{"accelerator_type:fpga-v1":1,"example.com/fpga":2}'`))
+
+		podSpec := workerGroup.Template.Spec
+		limit := podSpec.Containers[0].Resources.Limits["example.com/fpga"]
+		allocatable := node.Status.Allocatable["example.com/fpga"]
+
+		Expect(limit.Cmp(allocatable)).To(BeNumerically("<=", 0))
+		Expect(podSpec.NodeSelector).To(Equal(node.GetLabels()))
+		Expect(podSpec.Tolerations).To(ContainElement(corev1.Toleration{
+			Key:      "example.com/fpga",
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		}))
+	})
+
+	It("Should keep the number of workers chosen by the autoscaler", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-compute-autoscaling")
+
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.Compute.Autoscaling.Enabled = true
+			workspace.Spec.Compute.WorkerPools[0].MaxReplicas = pointer.Int32(5)
+		})
+
+		Eventually(func() error {
+			computeCluster, err := getRayCluster(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			if computeCluster.Spec.EnableInTreeAutoscaling == nil || !*computeCluster.Spec.EnableInTreeAutoscaling {
+				return fmt.Errorf("autoscaling not enabled")
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+
+		// There's no autoscaler in the test environment, so we scale the workers ourselves.
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			computeCluster, err := getRayCluster(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			computeCluster.Spec.WorkerGroupSpecs[0].Replicas = pointer.Int32(3)
+
+			return k8sClient.Update(ctx, computeCluster)
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.Compute.Controller.Image = "test-image"
+		})
+
+		Eventually(func() error {
+			computeCluster, err := getRayCluster(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			if computeCluster.Spec.HeadGroupSpec.Template.Spec.Containers[0].Image != "test-image" {
+				return fmt.Errorf("image not updated")
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+
+		computeCluster, err := getRayCluster(workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(computeCluster.Spec.WorkerGroupSpecs[0].Replicas).To(Equal(pointer.Int32(3)))
+	})
+
+	It("Should not apply the number of workers from an outdated ray cluster", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-compute-outdated")
+
+		outdatedCluster, err := getRayCluster(workspace)
+		Expect(err).NotTo(HaveOccurred())
+
+		// The autoscaler scales the workers after the operator read the cluster.
+		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			computeCluster, err := getRayCluster(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			computeCluster.Spec.WorkerGroupSpecs[0].Replicas = pointer.Int32(3)
+
+			return k8sClient.Update(ctx, computeCluster)
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+
+		defaultedWorkspace := workspace.DeepCopy()
+		defaultedWorkspace.Default()
+
+		rayCluster := newRayCluster(defaultedWorkspace)
+		preserveAutoscalerState(outdatedCluster, rayCluster)
+		rayCluster.SetResourceVersion(outdatedCluster.GetResourceVersion())
+
+		err = applyOwnedResource(ctx, k8sClient, scheme.Scheme, workspace, rayCluster)
+		Expect(errors.IsConflict(err)).To(BeTrue())
+
+		computeCluster, err := getRayCluster(workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(computeCluster.Spec.WorkerGroupSpecs[0].Replicas).To(Equal(pointer.Int32(3)))
+	})
 })
 
 func createWorkspaceAndWaitForRayCluster(ctx context.Context, name string) *mlopsv1alpha1.Workspace {
@@ -131,6 +355,9 @@ func createWorkspaceAndWaitForRayCluster(ctx context.Context, name string) *mlop
 	err := k8sClient.Create(ctx, workspace)
 	Expect(err).NotTo(HaveOccurred())
 
+	simulateDatabaseReady(ctx, workspace)
+	simulateDeploymentAvailable(ctx, workspace.GetNamespace(), fmt.Sprintf("%s-mlflow-server", workspace.GetName()))
+
 	Eventually(func() error {
 		_, err := getRayCluster(workspace)
 		return err
@@ -18,12 +18,20 @@ package controllers
 
 import (
 	"context"
+	"reflect"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
 )
@@ -31,14 +39,32 @@ import (
 // WorkspaceReconciler reconciles a Workspace object
 type WorkspaceReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// APIReader reads from the API server instead of the cache, for state that other controllers change.
+	APIReader client.Reader
+
+	registry *componentRegistry
 }
 
 //+kubebuilder:rbac:groups=mlops.aigency.com,resources=workspaces,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=mlops.aigency.com,resources=workspaces/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=mlops.aigency.com,resources=workspaces/finalizers,verbs=update
-//+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=services;configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;patch
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=core,resources=pods/log,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=pods/portforward,verbs=create
+//+kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;patch
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=apps,resources=deployments;statefulsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses;networkpolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=postgres-operator.crunchydata.com,resources=postgresclusters,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=ray.io,resources=rayclusters,verbs=get;list;create;watch;update;patch;delete
 
@@ -61,28 +87,167 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.
 		return ctrl.Result{}, err
 	}
 
-	if err := r.reconcilePostgresCluster(ctx, workspace); err != nil {
-		return ctrl.Result{}, err
+	if !workspace.GetDeletionTimestamp().IsZero() {
+		return r.finalizeWorkspace(ctx, workspace)
+	}
+
+	patch := client.MergeFrom(workspace.DeepCopy())
+
+	if controllerutil.AddFinalizer(workspace, workspaceFinalizer) {
+		if err := r.Patch(ctx, workspace, patch); err != nil {
+			logger.Error(err, "Failed to add the finalizer to the workspace")
+			return ctrl.Result{}, err
+		}
 	}
 
-	if err := r.reconcileExperimentTracking(ctx, workspace); err != nil {
+	components, err := r.registry.Components()
+
+	if err != nil {
+		logger.Error(err, "Failed to determine the order of the components in the workspace")
 		return ctrl.Result{}, err
 	}
 
-	if err := r.reconcileWorkflowServer(ctx, workspace); err != nil {
+	conditions, reconcileErrors, waiting := r.reconcileComponents(ctx, workspace, components)
+
+	if err := r.updateWorkspaceStatus(ctx, workspace, components, conditions, reconcileErrors, waiting); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	if err := r.reconcileRayCluster(ctx, workspace); err != nil {
+	if err := reconcileErrors.aggregate(); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	// Requeue with backoff while components are held back, the dependencies may become ready
+	// without an event for the workspace, for example when crunchy creates the database user secrets.
+	if len(waiting) > 0 {
+		logger.Info("Components are waiting for their dependencies", "waiting", waiting)
+		return ctrl.Result{Requeue: true}, nil
+	}
+
 	return ctrl.Result{}, nil
 }
 
+// reconcileComponents renders, applies, and cleans up the components in the workspace in dependency order.
+// Components are held back until the components they depend on are ready, so they don't crash-loop on startup.
+// A failing component doesn't stop the other components from being reconciled.
+// The conditions and errors are returned per component so we can report them in the status of the workspace.
+func (r *WorkspaceReconciler) reconcileComponents(ctx context.Context, workspace *mlopsv1alpha1.Workspace, components []Component) (componentConditions, componentErrors, waitingComponents) {
+	logger := log.FromContext(ctx).WithValues(
+		"workspace", workspace.GetName(),
+		"namespace", workspace.GetNamespace())
+
+	conditions := componentConditions{}
+	reconcileErrors := componentErrors{}
+	waiting := waitingComponents{}
+
+	for _, component := range components {
+		pendingDependencies := []string{}
+
+		for _, dependency := range r.registry.Dependencies(component.Name()) {
+			if !conditions[dependency].ready {
+				pendingDependencies = append(pendingDependencies, dependency)
+			}
+		}
+
+		if len(pendingDependencies) > 0 {
+			waiting[component.Name()] = pendingDependencies
+			continue
+		}
+
+		if err := r.reconcileComponent(ctx, workspace, component); err != nil {
+			reconcileErrors[component.Name()] = err
+			continue
+		}
+
+		condition, err := component.Ready(ctx, workspace)
+
+		if err != nil {
+			logger.Error(err, "Failed to determine the status of the component", "component", component.Name())
+			reconcileErrors[component.Name()] = err
+			continue
+		}
+
+		conditions[component.Name()] = condition
+	}
+
+	return conditions, reconcileErrors, waiting
+}
+
+func (r *WorkspaceReconciler) reconcileComponent(ctx context.Context, workspace *mlopsv1alpha1.Workspace, component Component) error {
+	logger := log.FromContext(ctx).WithValues(
+		"workspace", workspace.GetName(),
+		"namespace", workspace.GetNamespace(),
+		"component", component.Name())
+
+	resources, err := component.Render(workspace)
+
+	if err != nil {
+		logger.Error(err, "Failed to render the resources for the component")
+		return err
+	}
+
+	if err := component.Apply(ctx, workspace, resources); err != nil {
+		logger.Error(err, "Failed to apply the resources for the component")
+		return err
+	}
+
+	if err := component.Cleanup(ctx, workspace); err != nil {
+		logger.Error(err, "Failed to clean up the resources for the component")
+		return err
+	}
+
+	return nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
+// The controller watches all resources owned by a workspace, so changes made outside the operator are corrected.
 func (r *WorkspaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&mlopsv1alpha1.Workspace{}).
-		Complete(r)
+	r.registry = newWorkspaceComponents(r)
+
+	components, err := r.registry.Components()
+
+	if err != nil {
+		return err
+	}
+
+	ownedResourcePredicate := builder.WithPredicates(newOwnedResourcePredicate())
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &mlopsv1alpha1.Workspace{}, consumedSecretsIndexKey, r.indexConsumedSecrets); err != nil {
+		return err
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &mlopsv1alpha1.Workspace{}, consumedConfigMapsIndexKey, r.indexConsumedConfigMaps); err != nil {
+		return err
+	}
+
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
+		For(&mlopsv1alpha1.Workspace{}, builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+
+	ownedTypes := map[reflect.Type]bool{}
+
+	for _, component := range components {
+		for _, ownedType := range component.OwnedTypes() {
+			if ownedTypes[reflect.TypeOf(ownedType)] {
+				continue
+			}
+
+			ownedTypes[reflect.TypeOf(ownedType)] = true
+			controllerBuilder = controllerBuilder.Owns(ownedType, ownedResourcePredicate)
+		}
+	}
+
+	// Secrets created by the postgres operator or the user aren't owned by the workspace, so we map them back to the workspaces using them.
+	controllerBuilder = controllerBuilder.Watches(
+		&source.Kind{Type: &corev1.Secret{}},
+		handler.EnqueueRequestsFromMapFunc(r.findWorkspacesForSecret),
+		ownedResourcePredicate,
+	)
+
+	controllerBuilder = controllerBuilder.Watches(
+		&source.Kind{Type: &corev1.ConfigMap{}},
+		handler.EnqueueRequestsFromMapFunc(r.findWorkspacesForConfigMap),
+		ownedResourcePredicate,
+	)
+
+	return controllerBuilder.Complete(r)
 }
@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	postgres "github.com/crunchydata/postgres-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
+	"github.com/go-logr/logr"
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// workspaceFinalizer keeps the workspace around until the deletion policy for its data is carried out.
+	workspaceFinalizer = "mlops.aigency.com/finalizer"
+
+	// retainedLabel marks resources that outlived their workspace, so a new workspace with the same name can adopt them.
+	retainedLabel = "mlops.aigency.com/retained"
+
+	// pgBackRestBackupAnnotation tells crunchy to start the manual backup configured in the postgres cluster.
+	pgBackRestBackupAnnotation = "postgres-operator.crunchydata.com/pgbackrest-backup"
+
+	// snapshotPollInterval is the time we wait before checking the progress of the final backup again.
+	snapshotPollInterval = 10 * time.Second
+)
+
+// finalizeWorkspace carries out the deletion policy of the workspace and then releases the finalizer.
+// Kubernetes removes the resources that are still owned by the workspace once the finalizer is gone.
+func (r *WorkspaceReconciler) finalizeWorkspace(ctx context.Context, workspace *mlopsv1alpha1.Workspace) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues(
+		"workspace", workspace.GetName(),
+		"namespace", workspace.GetNamespace())
+
+	if !controllerutil.ContainsFinalizer(workspace, workspaceFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	if workspace.Spec.DeletionPolicy != mlopsv1alpha1.DeletionPolicyDelete && workspace.Spec.DeletionPolicy != "" {
+		if err := r.retainArtifactVolume(ctx, logger, workspace); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if err := r.retainObjectStorage(ctx, logger, workspace); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	switch workspace.Spec.DeletionPolicy {
+	case mlopsv1alpha1.DeletionPolicyRetain:
+		if err := r.retainDatabase(ctx, logger, workspace); err != nil {
+			return ctrl.Result{}, err
+		}
+	case mlopsv1alpha1.DeletionPolicySnapshot:
+		completed, err := r.snapshotDatabase(ctx, logger, workspace)
+
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if !completed {
+			return ctrl.Result{RequeueAfter: snapshotPollInterval}, nil
+		}
+
+		if err := r.retainDatabase(ctx, logger, workspace); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	patch := client.MergeFrom(workspace.DeepCopy())
+	controllerutil.RemoveFinalizer(workspace, workspaceFinalizer)
+
+	if err := r.Patch(ctx, workspace, patch); err != nil {
+		logger.Error(err, "Failed to remove the finalizer from the workspace")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// retainDatabase removes the workspace as owner of the postgres cluster, so the database and its
+// backup storage are kept when the workspace is removed. With the Snapshot policy we also shut the
+// cluster down, so it no longer runs pods. Its data and backup volumes are kept either way.
+func (r *WorkspaceReconciler) retainDatabase(ctx context.Context, logger logr.Logger, workspace *mlopsv1alpha1.Workspace) error {
+	cluster := &postgres.PostgresCluster{}
+
+	if err := r.Get(ctx, types.NamespacedName{Name: workspace.GetName(), Namespace: workspace.GetNamespace()}, cluster); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		logger.Error(err, "Failed to get the postgres cluster for the workspace")
+		return err
+	}
+
+	if workspace.Spec.DeletionPolicy == mlopsv1alpha1.DeletionPolicySnapshot {
+		patch := client.MergeFrom(cluster.DeepCopy())
+		cluster.Spec.Shutdown = pointer.Bool(true)
+
+		if err := r.Patch(ctx, cluster, patch); err != nil {
+			logger.Error(err, "Failed to shut down the postgres cluster")
+			return err
+		}
+	}
+
+	if err := r.releaseResource(ctx, workspace, cluster); err != nil {
+		logger.Error(err, "Failed to release the postgres cluster from the workspace")
+		return err
+	}
+
+	logger.Info("Retained the postgres cluster of the workspace", "cluster", cluster.GetName())
+
+	r.Recorder.Eventf(workspace, corev1.EventTypeNormal, "Retained",
+		"Kept postgres cluster %s because the deletion policy is %s", cluster.GetName(), workspace.Spec.DeletionPolicy)
+
+	return nil
+}
+
+// retainArtifactVolume removes the workspace as owner of the volume with experiment tracking artifacts.
+// The artifacts belong to the experiments in the database, so we keep them whenever we keep the data.
+func (r *WorkspaceReconciler) retainArtifactVolume(ctx context.Context, logger logr.Logger, workspace *mlopsv1alpha1.Workspace) error {
+	volumeClaim := &corev1.PersistentVolumeClaim{}
+	volumeClaimName := types.NamespacedName{Name: newArtifactVolumeClaimName(workspace), Namespace: workspace.GetNamespace()}
+
+	if err := r.Get(ctx, volumeClaimName, volumeClaim); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		logger.Error(err, "Failed to get the artifact volume for the workspace")
+		return err
+	}
+
+	if err := r.releaseResource(ctx, workspace, volumeClaim); err != nil {
+		logger.Error(err, "Failed to release the artifact volume from the workspace")
+		return err
+	}
+
+	r.Recorder.Eventf(workspace, corev1.EventTypeNormal, "Retained",
+		"Kept artifact volume %s because the deletion policy is %s", volumeClaim.GetName(), workspace.Spec.DeletionPolicy)
+
+	return nil
+}
+
+// retainObjectStorage removes the workspace as owner of the object storage volume and its credentials.
+// Like the artifacts, the objects belong to the data of the workspace.
+func (r *WorkspaceReconciler) retainObjectStorage(ctx context.Context, logger logr.Logger, workspace *mlopsv1alpha1.Workspace) error {
+	resources := []client.Object{
+		&corev1.PersistentVolumeClaim{},
+		&corev1.Secret{},
+	}
+
+	resourceNames := []string{
+		newObjectStorageName(workspace),
+		newObjectStorageCredentialsName(workspace),
+	}
+
+	for index, resource := range resources {
+		if err := r.Get(ctx, types.NamespacedName{Name: resourceNames[index], Namespace: workspace.GetNamespace()}, resource); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+
+			logger.Error(err, "Failed to get the object storage resource", "resource", resourceNames[index])
+			return err
+		}
+
+		if err := r.releaseResource(ctx, workspace, resource); err != nil {
+			logger.Error(err, "Failed to release the object storage resource", "resource", resourceNames[index])
+			return err
+		}
+
+		r.Recorder.Eventf(workspace, corev1.EventTypeNormal, "Retained",
+			"Kept object storage resource %s because the deletion policy is %s", resource.GetName(), workspace.Spec.DeletionPolicy)
+	}
+
+	return nil
+}
+
+// snapshotDatabase starts a final backup of the postgres cluster and reports whether it completed.
+// The backup is identified by the deletion timestamp, so we start it only once for every deletion.
+func (r *WorkspaceReconciler) snapshotDatabase(ctx context.Context, logger logr.Logger, workspace *mlopsv1alpha1.Workspace) (bool, error) {
+	cluster := &postgres.PostgresCluster{}
+
+	if err := r.Get(ctx, types.NamespacedName{Name: workspace.GetName(), Namespace: workspace.GetNamespace()}, cluster); err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+
+		logger.Error(err, "Failed to get the postgres cluster for the workspace")
+		return false, err
+	}
+
+	backupID := fmt.Sprintf("final-%d", workspace.GetDeletionTimestamp().Unix())
+
+	if cluster.GetAnnotations()[pgBackRestBackupAnnotation] != backupID {
+		patch := client.MergeFrom(cluster.DeepCopy())
+
+		cluster.Spec.Backups.PGBackRest.Manual = &postgres.PGBackRestManualBackup{
+			RepoName: "repo1",
+			Options:  []string{"--type=full"},
+		}
+
+		metav1.SetMetaDataAnnotation(&cluster.ObjectMeta, pgBackRestBackupAnnotation, backupID)
+
+		if err := r.Patch(ctx, cluster, patch); err != nil {
+			logger.Error(err, "Failed to start the final backup of the postgres cluster")
+			return false, err
+		}
+
+		logger.Info("Started the final backup of the postgres cluster", "backup", backupID)
+
+		r.Recorder.Eventf(workspace, corev1.EventTypeNormal, "Snapshotting",
+			"Started final backup %s of postgres cluster %s", backupID, cluster.GetName())
+
+		return false, nil
+	}
+
+	if cluster.Status.PGBackRest == nil || cluster.Status.PGBackRest.ManualBackup == nil {
+		return false, nil
+	}
+
+	backupStatus := cluster.Status.PGBackRest.ManualBackup
+
+	if backupStatus.ID != backupID || !backupStatus.Finished {
+		return false, nil
+	}
+
+	if backupStatus.Succeeded == 0 {
+		r.Recorder.Eventf(workspace, corev1.EventTypeWarning, "SnapshotFailed",
+			"The final backup %s of postgres cluster %s failed, change the deletion policy to remove the workspace anyway",
+			backupID, cluster.GetName())
+
+		return false, fmt.Errorf("the final backup %s of postgres cluster %s failed", backupID, cluster.GetName())
+	}
+
+	r.Recorder.Eventf(workspace, corev1.EventTypeNormal, "Snapshotted",
+		"Completed final backup %s of postgres cluster %s", backupID, cluster.GetName())
+
+	return true, nil
+}
+
+// adoptRetainedResource removes the retained label from a resource that was kept by an earlier workspace
+// with the same name. Applying the resource already made the workspace its controller again.
+func (r *WorkspaceReconciler) adoptRetainedResource(ctx context.Context, workspace *mlopsv1alpha1.Workspace, resource client.Object) error {
+	if resource.GetLabels()[retainedLabel] == "" {
+		return nil
+	}
+
+	patch := client.MergeFrom(resource.DeepCopyObject().(client.Object))
+
+	labels := resource.GetLabels()
+	delete(labels, retainedLabel)
+	resource.SetLabels(labels)
+
+	if err := r.Patch(ctx, resource, patch); err != nil {
+		return err
+	}
+
+	r.Recorder.Eventf(workspace, corev1.EventTypeNormal, "Adopted",
+		"Adopted %s retained by an earlier workspace", resource.GetName())
+
+	return nil
+}
+
+// releaseResource removes all owner references from a resource and labels it as retained for the workspace.
+func (r *WorkspaceReconciler) releaseResource(ctx context.Context, workspace *mlopsv1alpha1.Workspace, resource client.Object) error {
+	patch := client.MergeFrom(resource.DeepCopyObject().(client.Object))
+
+	labels := resource.GetLabels()
+
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	labels["mlops.aigency.com/workspace"] = workspace.GetName()
+	labels[retainedLabel] = "true"
+
+	resource.SetLabels(labels)
+	resource.SetOwnerReferences(nil)
+
+	return r.Patch(ctx, resource, patch)
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	postgres "github.com/crunchydata/postgres-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+var _ = Describe("finalizeWorkspace", func() {
+	It("Should remove the workspace with the Delete policy", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForFinalizer(ctx, "test-deletion-delete", mlopsv1alpha1.DeletionPolicyDelete)
+
+		Expect(k8sClient.Delete(ctx, workspace)).To(Succeed())
+
+		Eventually(func() bool {
+			_, err := getWorkspace(workspace)
+			return errors.IsNotFound(err)
+		}, time.Minute, time.Second).Should(BeTrue())
+	})
+
+	It("Should keep the postgres cluster with the Retain policy", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForFinalizer(ctx, "test-deletion-retain", mlopsv1alpha1.DeletionPolicyRetain)
+		cluster := getPostgresCluster(ctx, workspace)
+
+		Expect(k8sClient.Delete(ctx, workspace)).To(Succeed())
+
+		Eventually(func() bool {
+			_, err := getWorkspace(workspace)
+			return errors.IsNotFound(err)
+		}, time.Minute, time.Second).Should(BeTrue())
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+		Expect(cluster.GetOwnerReferences()).To(BeEmpty())
+		Expect(cluster.GetLabels()).To(HaveKeyWithValue(retainedLabel, "true"))
+	})
+
+	It("Should create a final backup and keep the backup volumes with the Snapshot policy", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForFinalizer(ctx, "test-deletion-snapshot", mlopsv1alpha1.DeletionPolicySnapshot)
+		cluster := getPostgresCluster(ctx, workspace)
+
+		backupVolume := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-repo1", workspace.GetName()),
+				Namespace: workspace.GetNamespace(),
+				Labels: map[string]string{
+					"postgres-operator.crunchydata.com/cluster":           workspace.GetName(),
+					"postgres-operator.crunchydata.com/pgbackrest-volume": "",
+				},
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{"storage": resource.MustParse("1Gi")},
+				},
+			},
+		}
+
+		Expect(controllerutil.SetOwnerReference(cluster, backupVolume, k8sClient.Scheme())).To(Succeed())
+		Expect(k8sClient.Create(ctx, backupVolume)).To(Succeed())
+
+		Expect(k8sClient.Delete(ctx, workspace)).To(Succeed())
+
+		var backupID string
+
+		Eventually(func() error {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), cluster); err != nil {
+				return err
+			}
+
+			backupID = cluster.GetAnnotations()[pgBackRestBackupAnnotation]
+
+			if backupID == "" {
+				return fmt.Errorf("expected the final backup to be started")
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+
+		// The workspace must wait for the backup to complete.
+		Consistently(func() error {
+			_, err := getWorkspace(workspace)
+			return err
+		}, 5*time.Second, time.Second).Should(Succeed())
+
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), cluster); err != nil {
+				return err
+			}
+
+			cluster.Status.PGBackRest = &postgres.PGBackRestStatus{
+				ManualBackup: &postgres.PGBackRestJobStatus{ID: backupID, Finished: true, Succeeded: 1},
+			}
+
+			return k8sClient.Status().Update(ctx, cluster)
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() bool {
+			_, err := getWorkspace(workspace)
+			return errors.IsNotFound(err)
+		}, time.Minute, time.Second).Should(BeTrue())
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+		Expect(cluster.GetOwnerReferences()).To(BeEmpty())
+		Expect(cluster.GetLabels()).To(HaveKeyWithValue(retainedLabel, "true"))
+		Expect(cluster.Spec.Shutdown).To(Equal(pointer.Bool(true)))
+
+		// The backup volume stays owned by the postgres cluster, which no longer belongs to the workspace.
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(backupVolume), backupVolume)).To(Succeed())
+		Expect(backupVolume.GetOwnerReferences()).To(HaveLen(1))
+		Expect(backupVolume.GetOwnerReferences()[0].UID).To(Equal(cluster.GetUID()))
+	})
+})
+
+func createWorkspaceAndWaitForFinalizer(ctx context.Context, name string, deletionPolicy mlopsv1alpha1.DeletionPolicy) *mlopsv1alpha1.Workspace {
+	workspace := newTestWorkspace(name)
+	workspace.Spec.DeletionPolicy = deletionPolicy
+
+	err := k8sClient.Create(ctx, workspace)
+	Expect(err).NotTo(HaveOccurred())
+
+	Eventually(func() error {
+		updatedWorkspace, err := getWorkspace(workspace)
+
+		if err != nil {
+			return err
+		}
+
+		if !controllerutil.ContainsFinalizer(updatedWorkspace, workspaceFinalizer) {
+			return fmt.Errorf("expected the workspace to have a finalizer")
+		}
+
+		return nil
+	}, time.Minute, time.Second).Should(Succeed())
+
+	return workspace
+}
+
+func getPostgresCluster(ctx context.Context, workspace *mlopsv1alpha1.Workspace) *postgres.PostgresCluster {
+	cluster := &postgres.PostgresCluster{}
+
+	Eventually(func() error {
+		return k8sClient.Get(ctx, client.ObjectKeyFromObject(workspace), cluster)
+	}, time.Minute, time.Second).Should(Succeed())
+
+	return cluster
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const endpointsComponentName = "endpoints"
+
+// workspaceEndpoints contains the addresses of the services in a workspace.
+// We compute the addresses in one place, so every workload in the workspace uses the same addresses.
+type workspaceEndpoints struct {
+	experimentTrackingURL string
+	workflowsAPIURL       string
+	rayClientAddress      string
+	rayDashboardURL       string
+	objectStorageURL      string
+}
+
+func newWorkspaceEndpoints(workspace *mlopsv1alpha1.Workspace) workspaceEndpoints {
+	// KubeRay creates the service for the ray controller based on the name of the cluster.
+	rayHeadServiceName := fmt.Sprintf("%s-head-svc", newRayClusterName(workspace))
+
+	endpoints := workspaceEndpoints{
+		experimentTrackingURL: fmt.Sprintf("http://%s-mlflow-server:5000", workspace.GetName()),
+		workflowsAPIURL:       fmt.Sprintf("http://%s-orion-server:4200/api", workspace.GetName()),
+		rayClientAddress:      fmt.Sprintf("ray://%s:10001", rayHeadServiceName),
+		rayDashboardURL:       fmt.Sprintf("http://%s:8265", rayHeadServiceName),
+	}
+
+	if workspace.Spec.ObjectStorage.Enabled {
+		endpoints.objectStorageURL = fmt.Sprintf("http://%s:9000", newObjectStorageName(workspace))
+	}
+
+	return endpoints
+}
+
+// data returns the endpoints using the names of the environment variables the client libraries read them from.
+func (e workspaceEndpoints) data() map[string]string {
+	data := map[string]string{
+		"MLFLOW_TRACKING_URI": e.experimentTrackingURL,
+		"PREFECT_API_URL":     e.workflowsAPIURL,
+		"RAY_ADDRESS":         e.rayClientAddress,
+		"RAY_DASHBOARD_URL":   e.rayDashboardURL,
+	}
+
+	// Boto3 reads AWS_ENDPOINT_URL, while MLflow uses its own variable for the S3 endpoint.
+	if e.objectStorageURL != "" {
+		data["AWS_ENDPOINT_URL"] = e.objectStorageURL
+		data["MLFLOW_S3_ENDPOINT_URL"] = e.objectStorageURL
+	}
+
+	return data
+}
+
+// newEnvVars creates the environment variables for workloads that connect to the services in the workspace.
+func (e workspaceEndpoints) newEnvVars() []corev1.EnvVar {
+	data := e.data()
+	names := []string{}
+
+	for name := range data {
+		names = append(names, name)
+	}
+
+	// The order of the variables must be stable, otherwise every reconcile rolls out the workloads again.
+	sort.Strings(names)
+
+	envVars := []corev1.EnvVar{}
+
+	for _, name := range names {
+		envVars = append(envVars, corev1.EnvVar{Name: name, Value: data[name]})
+	}
+
+	return envVars
+}
+
+// newComputeEnvVars creates the environment variables for the pods in the ray cluster.
+// Ray code running inside the cluster connects to the local ray instance. Setting RAY_ADDRESS there would
+// send it through the ray client instead.
+func (e workspaceEndpoints) newComputeEnvVars() []corev1.EnvVar {
+	envVars := []corev1.EnvVar{}
+
+	for _, envVar := range e.newEnvVars() {
+		if envVar.Name != "RAY_ADDRESS" {
+			envVars = append(envVars, envVar)
+		}
+	}
+
+	return envVars
+}
+
+// endpointsComponent publishes the endpoints of the workspace in a config map.
+// Notebooks and CI pipelines can mount the config map to connect to the workspace.
+type endpointsComponent struct {
+	componentBase
+}
+
+func (c *endpointsComponent) Name() string {
+	return endpointsComponentName
+}
+
+func (c *endpointsComponent) ConditionType() string {
+	return ""
+}
+
+func (c *endpointsComponent) OwnedTypes() []client.Object {
+	return []client.Object{&corev1.ConfigMap{}}
+}
+
+func (c *endpointsComponent) Render(workspace *mlopsv1alpha1.Workspace) ([]client.Object, error) {
+	return []client.Object{newEndpointsConfigMap(workspace)}, nil
+}
+
+func (c *endpointsComponent) Ready(ctx context.Context, workspace *mlopsv1alpha1.Workspace) (componentCondition, error) {
+	configMap := &corev1.ConfigMap{}
+	configMapName := newEndpointsConfigMapName(workspace)
+
+	if err := c.reconciler.Get(ctx, types.NamespacedName{Name: configMapName, Namespace: workspace.GetNamespace()}, configMap); err != nil {
+		if errors.IsNotFound(err) {
+			return componentCondition{reason: reasonNotFound, message: "The endpoints config map does not exist yet"}, nil
+		}
+
+		return componentCondition{}, err
+	}
+
+	return componentCondition{ready: true, reason: reasonAvailable, message: "The endpoints are published"}, nil
+}
+
+func newEndpointsConfigMapName(workspace *mlopsv1alpha1.Workspace) string {
+	return fmt.Sprintf("%s-endpoints", workspace.GetName())
+}
+
+func newEndpointsConfigMap(workspace *mlopsv1alpha1.Workspace) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      newEndpointsConfigMapName(workspace),
+			Namespace: workspace.GetNamespace(),
+			Labels:    newComponentLabels(workspace, endpointsComponentName),
+		},
+		Data: newWorkspaceEndpoints(workspace).data(),
+	}
+}
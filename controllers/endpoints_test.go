@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("endpointsComponent", func() {
+	It("Should publish the endpoints of the workspace", func() {
+		ctx := context.Background()
+		workspace := newTestWorkspace("test-endpoints")
+
+		err := k8sClient.Create(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() error {
+			configMap := &corev1.ConfigMap{}
+			configMapName := types.NamespacedName{
+				Name:      fmt.Sprintf("%s-endpoints", workspace.GetName()),
+				Namespace: workspace.GetNamespace(),
+			}
+
+			if err := k8sClient.Get(ctx, configMapName, configMap); err != nil {
+				return err
+			}
+
+			expectedData := map[string]string{
+				"MLFLOW_TRACKING_URI": "http://test-endpoints-mlflow-server:5000",
+				"PREFECT_API_URL":     "http://test-endpoints-orion-server:4200/api",
+				"RAY_ADDRESS":         "ray://test-endpoints-ray-head-svc:10001",
+				"RAY_DASHBOARD_URL":   "http://test-endpoints-ray-head-svc:8265",
+			}
+
+			for key, value := range expectedData {
+				if configMap.Data[key] != value {
+					return fmt.Errorf("expected %s to be %s, got %s", key, value, configMap.Data[key])
+				}
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+	})
+
+	It("Should point the ray workers at the experiment tracking server", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-endpoints-compute")
+
+		rayCluster, err := getRayCluster(workspace)
+		Expect(err).NotTo(HaveOccurred())
+
+		workerEnv := rayCluster.Spec.WorkerGroupSpecs[0].Template.Spec.Containers[0].Env
+
+		Expect(workerEnv).To(ContainElement(corev1.EnvVar{
+			Name:  "MLFLOW_TRACKING_URI",
+			Value: "http://test-endpoints-compute-mlflow-server:5000",
+		}))
+
+		Expect(workerEnv).NotTo(ContainElement(HaveField("Name", "RAY_ADDRESS")))
+	})
+
+	It("Should give the workflow agents access to the compute cluster", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForAgentPoolDeployment(ctx, "test-endpoints-agents")
+
+		Eventually(func() error {
+			statefulSet, err := getWorkflowAgentPool(workspace, "test")
+
+			if err != nil {
+				return err
+			}
+
+			expectedEnvVar := corev1.EnvVar{Name: "RAY_ADDRESS", Value: "ray://test-endpoints-agents-ray-head-svc:10001"}
+
+			for _, envVar := range statefulSet.Spec.Template.Spec.Containers[0].Env {
+				if envVar == expectedEnvVar {
+					return nil
+				}
+			}
+
+			return fmt.Errorf("expected the agent to have %s set to %s", expectedEnvVar.Name, expectedEnvVar.Value)
+		}, time.Minute, time.Second).Should(Succeed())
+	})
+})
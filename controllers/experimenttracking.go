@@ -3,53 +3,68 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"reflect"
 
-	"github.com/go-logr/logr"
 	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func (r *WorkspaceReconciler) reconcileExperimentTracking(ctx context.Context, workspace *mlopsv1alpha1.Workspace) error {
-	if err := r.reconcileExperimentTrackingDeployment(ctx, workspace); err != nil {
-		return err
-	}
+const experimentTrackingComponentName = "experiment-tracking"
 
-	if err := r.reconcileExperimentTrackingService(ctx, workspace); err != nil {
-		return err
-	}
+// experimentTrackingComponent manages the MLflow server that tracks experiments and models in the workspace.
+type experimentTrackingComponent struct {
+	componentBase
+}
+
+func (c *experimentTrackingComponent) Name() string {
+	return experimentTrackingComponentName
+}
 
-	return nil
+func (c *experimentTrackingComponent) ConditionType() string {
+	return mlopsv1alpha1.ConditionTypeExperimentTrackingReady
 }
 
-func (r *WorkspaceReconciler) reconcileExperimentTrackingDeployment(ctx context.Context, workspace *mlopsv1alpha1.Workspace) error {
-	logger := log.FromContext(ctx).WithValues("workspace", workspace.GetName(), "namespace", workspace.GetNamespace())
+func (c *experimentTrackingComponent) OwnedTypes() []client.Object {
+	return []client.Object{&appsv1.Deployment{}, &corev1.Service{}, &corev1.PersistentVolumeClaim{}, &corev1.ServiceAccount{}}
+}
+
+func (c *experimentTrackingComponent) Render(workspace *mlopsv1alpha1.Workspace) ([]client.Object, error) {
+	resources := []client.Object{}
+
+	if workspace.Spec.ExperimentTracking.Artifacts.PersistentVolume != nil {
+		resources = append(resources, newArtifactVolumeClaim(workspace))
+	}
+
+	resources = append(resources,
+		newExperimentTrackingServiceAccount(workspace),
+		newExperimentTrackingDeployment(workspace),
+		newExperimentTrackingService(workspace))
+
+	return resources, nil
+}
 
+func (c *experimentTrackingComponent) Ready(ctx context.Context, workspace *mlopsv1alpha1.Workspace) (componentCondition, error) {
 	deploymentName := fmt.Sprintf("%s-mlflow-server", workspace.GetName())
 	deployment := &appsv1.Deployment{}
 
-	if err := r.Get(ctx, types.NamespacedName{Name: deploymentName, Namespace: workspace.GetNamespace()}, deployment); err != nil {
+	if err := c.reconciler.Get(ctx, types.NamespacedName{Name: deploymentName, Namespace: workspace.GetNamespace()}, deployment); err != nil {
 		if errors.IsNotFound(err) {
-			return r.createExperimentTrackingDeployment(ctx, logger, workspace)
+			return componentCondition{reason: reasonNotFound, message: "The experiment tracking server does not exist yet"}, nil
 		}
 
-		logger.Error(err, "Failed to get deployment")
-		return err
+		return componentCondition{}, err
 	}
 
-	return r.updateExperimentTrackingDeployment(ctx, logger, workspace, deployment)
+	return newDeploymentCondition(deployment), nil
 }
 
-func (r *WorkspaceReconciler) createExperimentTrackingDeployment(ctx context.Context, logger logr.Logger, workspace *mlopsv1alpha1.Workspace) error {
+func newExperimentTrackingDeployment(workspace *mlopsv1alpha1.Workspace) *appsv1.Deployment {
 	deploymentLabels := newComponentLabels(workspace, "experiment-tracking")
-	databaseSecretName := fmt.Sprintf("%s-pguser-mlflow", workspace.GetName())
+	databaseSecretName := newDatabaseSecretName(workspace, experimentTrackingComponentName)
 	deploymentName := fmt.Sprintf("%s-mlflow-server", workspace.GetName())
 
 	container := newContainer(
@@ -58,7 +73,8 @@ func (r *WorkspaceReconciler) createExperimentTrackingDeployment(ctx context.Con
 		workspace.Spec.ExperimentTracking.Resources,
 	)
 
-	container.Env = newDatabaseSecretEnvVars(databaseSecretName)
+	container.Env = append(newDatabaseSecretEnvVars(databaseSecretName), newArtifactStorageEnvVars(workspace)...)
+	container.Env = append(container.Env, newExperimentTrackingUIEnvVars(workspace)...)
 
 	container.Ports = []corev1.ContainerPort{
 		{
@@ -67,6 +83,17 @@ func (r *WorkspaceReconciler) createExperimentTrackingDeployment(ctx context.Con
 		},
 	}
 
+	artifactVolume := newArtifactVolume(workspace)
+
+	if artifactVolume != nil {
+		container.VolumeMounts = []corev1.VolumeMount{
+			{
+				Name:      artifactVolume.Name,
+				MountPath: artifactsMountPath,
+			},
+		}
+	}
+
 	deployment := newDeployment(
 		workspace.GetNamespace(),
 		deploymentName,
@@ -75,91 +102,39 @@ func (r *WorkspaceReconciler) createExperimentTrackingDeployment(ctx context.Con
 		container,
 	)
 
-	if err := ctrl.SetControllerReference(workspace, deployment, r.Scheme); err != nil {
-		logger.Error(err, "Failed to set controller reference for deployment of experiment tracking server")
-		return err
-	}
+	deployment.Spec.Template.Spec.ServiceAccountName = deploymentName
+	applyScheduling(&deployment.Spec.Template.Spec, deploymentLabels, workspace.Spec.ExperimentTracking.Scheduling)
 
-	if err := r.Create(ctx, deployment); err != nil {
-		logger.Error(err, "Failed to create deployment for experiment tracking server")
-		return err
+	if artifactVolume != nil {
+		deployment.Spec.Template.Spec.Volumes = append(deployment.Spec.Template.Spec.Volumes, *artifactVolume)
 	}
 
-	return nil
+	return deployment
 }
 
-func (r *WorkspaceReconciler) updateExperimentTrackingDeployment(ctx context.Context, logger logr.Logger, workspace *mlopsv1alpha1.Workspace, deployment *appsv1.Deployment) error {
-	deploymentChanged := false
-
-	if *deployment.Spec.Replicas != *workspace.Spec.ExperimentTracking.Replicas {
-		deployment.Spec.Replicas = workspace.Spec.ExperimentTracking.Replicas
-		deploymentChanged = true
-	}
-
-	if deployment.Spec.Template.Spec.Containers[0].Image != workspace.Spec.ExperimentTracking.Image {
-		deployment.Spec.Template.Spec.Containers[0].Image = workspace.Spec.ExperimentTracking.Image
-		deploymentChanged = true
-	}
-
-	if !reflect.DeepEqual(deployment.Spec.Template.Spec.Containers[0].Resources, workspace.Spec.ExperimentTracking.Resources) {
-		deployment.Spec.Template.Spec.Containers[0].Resources = workspace.Spec.ExperimentTracking.Resources
-		deploymentChanged = true
-	}
-
-	if deploymentChanged {
-		if err := r.Update(ctx, deployment); err != nil {
-			logger.Error(err, "Failed to update deployment for experiment tracking server")
-			return err
-		}
-	}
-
-	return nil
+func newExperimentTrackingServiceAccount(workspace *mlopsv1alpha1.Workspace) *corev1.ServiceAccount {
+	return newServiceAccount(
+		workspace.GetNamespace(),
+		fmt.Sprintf("%s-mlflow-server", workspace.GetName()),
+		newComponentLabels(workspace, "experiment-tracking"),
+		workspace.Spec.ExperimentTracking.ServiceAccount,
+	)
 }
 
-func (r *WorkspaceReconciler) reconcileExperimentTrackingService(ctx context.Context, workspace *mlopsv1alpha1.Workspace) error {
-	logger := log.FromContext(ctx).WithValues("workspace", workspace.GetName(), "namespace", workspace.GetNamespace())
-
+func newExperimentTrackingService(workspace *mlopsv1alpha1.Workspace) *corev1.Service {
 	serviceLabels := newComponentLabels(workspace, "experiment-tracking")
 	serviceName := fmt.Sprintf("%s-mlflow-server", workspace.GetName())
 
-	service := &corev1.Service{}
-
-	if err := r.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: workspace.GetNamespace()}, service); err != nil {
-		if errors.IsNotFound(err) {
-			service = &corev1.Service{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      serviceName,
-					Namespace: workspace.GetNamespace(),
-					Labels:    serviceLabels,
-				},
-				Spec: corev1.ServiceSpec{
-					Type:     corev1.ServiceTypeClusterIP,
-					Selector: serviceLabels,
-					Ports: []corev1.ServicePort{
-						{
-							Name:       "http-mlflow",
-							Port:       5000,
-							TargetPort: intstr.FromInt(5000),
-						},
-					},
-				},
-			}
-
-			if err := ctrl.SetControllerReference(workspace, service, r.Scheme); err != nil {
-				logger.Error(err, "Failed to set controller reference for service of experiment tracking server")
-				return err
-			}
-
-			if err := r.Create(ctx, service); err != nil {
-				logger.Error(err, "Failed to create service for experiment tracking server")
-				return err
-			}
-
-			return nil
-		}
+	service := newService(serviceName, workspace.GetNamespace(), serviceLabels)
 
-		return err
+	service.Spec.Ports = []corev1.ServicePort{
+		{
+			Name:       "http-mlflow",
+			Protocol:   corev1.ProtocolTCP,
+			Port:       5000,
+			TargetPort: intstr.FromInt(5000),
+		},
 	}
 
-	return nil
+	return service
 }
@@ -32,14 +32,30 @@ var _ = Describe("reconcileExperimentTracking", func() {
 		}, time.Minute, time.Second).Should(Succeed())
 	})
 
+	It("Should run the experiment tracking component with the restricted security context", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForExperimentTrackingDeployment(ctx, "test-experimenttracking-security")
+
+		deployment, err := getExperimentTrackingDeployment(workspace)
+		Expect(err).NotTo(HaveOccurred())
+
+		podSpec := deployment.Spec.Template.Spec
+		Expect(podSpec.SecurityContext.RunAsNonRoot).To(Equal(pointer.Bool(true)))
+		Expect(podSpec.SecurityContext.SeccompProfile.Type).To(Equal(corev1.SeccompProfileTypeRuntimeDefault))
+
+		container := podSpec.Containers[0]
+		Expect(container.SecurityContext.ReadOnlyRootFilesystem).To(Equal(pointer.Bool(true)))
+		Expect(container.SecurityContext.Capabilities.Drop).To(ConsistOf(corev1.Capability("ALL")))
+		Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: "tmp", MountPath: "/tmp"}))
+	})
+
 	It("Should update the experiment tracking component image", func() {
 		ctx := context.Background()
 		workspace := createWorkspaceAndWaitForExperimentTrackingDeployment(ctx, "test-experimenttracking-image")
 
-		workspace.Spec.ExperimentTracking.Image = "willemmeints/experimenttracking:unknown"
-
-		err := k8sClient.Update(ctx, workspace)
-		Expect(err).NotTo(HaveOccurred())
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.ExperimentTracking.Image = "willemmeints/experimenttracking:unknown"
+		})
 
 		Eventually(func() error {
 			deployment, err := getExperimentTrackingDeployment(workspace)
@@ -60,19 +76,18 @@ var _ = Describe("reconcileExperimentTracking", func() {
 		ctx := context.Background()
 		workspace := createWorkspaceAndWaitForExperimentTrackingDeployment(ctx, "test-experimenttracking-resources")
 
-		workspace.Spec.ExperimentTracking.Resources = corev1.ResourceRequirements{
-			Limits: corev1.ResourceList{
-				corev1.ResourceCPU:    resource.MustParse("2"),
-				corev1.ResourceMemory: resource.MustParse("2Gi"),
-			},
-			Requests: corev1.ResourceList{
-				corev1.ResourceCPU:    resource.MustParse("1"),
-				corev1.ResourceMemory: resource.MustParse("1Gi"),
-			},
-		}
-
-		err := k8sClient.Update(ctx, workspace)
-		Expect(err).NotTo(HaveOccurred())
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.ExperimentTracking.Resources = corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("2"),
+					corev1.ResourceMemory: resource.MustParse("2Gi"),
+				},
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				},
+			}
+		})
 
 		Eventually(func() error {
 			deployment, err := getExperimentTrackingDeployment(workspace)
@@ -105,10 +120,9 @@ var _ = Describe("reconcileExperimentTracking", func() {
 		ctx := context.Background()
 		workspace := createWorkspaceAndWaitForExperimentTrackingDeployment(ctx, "test-experimenttracking-replicas")
 
-		workspace.Spec.ExperimentTracking.Replicas = pointer.Int32(2)
-
-		err := k8sClient.Update(ctx, workspace)
-		Expect(err).NotTo(HaveOccurred())
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.ExperimentTracking.Replicas = pointer.Int32(2)
+		})
 
 		Eventually(func() error {
 			deployment, err := getExperimentTrackingDeployment(workspace)
@@ -124,6 +138,258 @@ var _ = Describe("reconcileExperimentTracking", func() {
 			return nil
 		}, time.Minute, time.Second).Should(Succeed())
 	})
+
+	It("Should recreate the experiment tracking deployment when it is deleted", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForExperimentTrackingDeployment(ctx, "test-experimenttracking-deleted")
+
+		var originalUID types.UID
+
+		Eventually(func() error {
+			deployment, err := getExperimentTrackingDeployment(workspace)
+			originalUID = deployment.GetUID()
+			return err
+		}, time.Minute, time.Second).Should(Succeed())
+
+		deployment, err := getExperimentTrackingDeployment(workspace)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = k8sClient.Delete(ctx, deployment)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() error {
+			deployment, err := getExperimentTrackingDeployment(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			if deployment.GetUID() == originalUID {
+				return fmt.Errorf("expected deployment to be recreated")
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+	})
+
+	It("Should restore the environment variables of the experiment tracking deployment", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForExperimentTrackingDeployment(ctx, "test-experimenttracking-env-drift")
+
+		Eventually(func() error {
+			deployment, err := getExperimentTrackingDeployment(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			deployment.Spec.Template.Spec.Containers[0].Env = []corev1.EnvVar{
+				{Name: "DB_HOST", Value: "modified"},
+			}
+
+			return k8sClient.Update(ctx, deployment)
+		}, time.Minute, time.Second).Should(Succeed())
+
+		Eventually(func() error {
+			deployment, err := getExperimentTrackingDeployment(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			for _, envVar := range deployment.Spec.Template.Spec.Containers[0].Env {
+				if envVar.Name == "DB_HOST" && envVar.ValueFrom == nil {
+					return fmt.Errorf("expected DB_HOST to be loaded from the database secret")
+				}
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+	})
+
+	It("Should restore the ports of the experiment tracking service", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForExperimentTrackingDeployment(ctx, "test-experimenttracking-service-drift")
+
+		serviceName := types.NamespacedName{
+			Name:      fmt.Sprintf("%s-mlflow-server", workspace.GetName()),
+			Namespace: workspace.GetNamespace(),
+		}
+
+		Eventually(func() error {
+			service := &corev1.Service{}
+
+			if err := k8sClient.Get(ctx, serviceName, service); err != nil {
+				return err
+			}
+
+			service.Spec.Ports[0].Port = 8080
+
+			return k8sClient.Update(ctx, service)
+		}, time.Minute, time.Second).Should(Succeed())
+
+		Eventually(func() error {
+			service := &corev1.Service{}
+
+			if err := k8sClient.Get(ctx, serviceName, service); err != nil {
+				return err
+			}
+
+			for _, port := range service.Spec.Ports {
+				if port.Port == 5000 {
+					return nil
+				}
+			}
+
+			return fmt.Errorf("expected the service to expose port 5000")
+		}, time.Minute, time.Second).Should(Succeed())
+	})
+
+	It("Should keep fields of the experiment tracking deployment set by other tools", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForExperimentTrackingDeployment(ctx, "test-experimenttracking-other-fields")
+
+		Eventually(func() error {
+			deployment, err := getExperimentTrackingDeployment(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			deployment.Spec.Template.Annotations = map[string]string{
+				"example.com/restarted-at": "now",
+			}
+
+			return k8sClient.Update(ctx, deployment)
+		}, time.Minute, time.Second).Should(Succeed())
+
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.ExperimentTracking.Image = "willemmeints/experimenttracking:other-fields"
+		})
+
+		Eventually(func() error {
+			deployment, err := getExperimentTrackingDeployment(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			if deployment.Spec.Template.Spec.Containers[0].Image != "willemmeints/experimenttracking:other-fields" {
+				return fmt.Errorf("expected image to be updated, got %s", deployment.Spec.Template.Spec.Containers[0].Image)
+			}
+
+			if deployment.Spec.Template.Annotations["example.com/restarted-at"] != "now" {
+				return fmt.Errorf("expected annotation set by another tool to be preserved")
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+	})
+
+	It("Should store artifacts in an S3-compatible bucket", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForExperimentTrackingDeployment(ctx, "test-experimenttracking-s3")
+
+		// We use a MinIO server as a stand-in for S3 here.
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.ExperimentTracking.Artifacts.S3 = &mlopsv1alpha1.S3ArtifactStorageSpec{
+				Bucket:   "artifacts",
+				Path:     "mlflow",
+				Endpoint: "http://minio:9000",
+				CredentialsSecret: corev1.LocalObjectReference{
+					Name: "minio-credentials",
+				},
+			}
+		})
+
+		Eventually(func() error {
+			deployment, err := getExperimentTrackingDeployment(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			envVars := map[string]corev1.EnvVar{}
+
+			for _, envVar := range deployment.Spec.Template.Spec.Containers[0].Env {
+				envVars[envVar.Name] = envVar
+			}
+
+			if envVars["MLFLOW_ARTIFACTS_DESTINATION"].Value != "s3://artifacts/mlflow" {
+				return fmt.Errorf("expected artifacts destination to be s3://artifacts/mlflow, got %s", envVars["MLFLOW_ARTIFACTS_DESTINATION"].Value)
+			}
+
+			if envVars["MLFLOW_S3_ENDPOINT_URL"].Value != "http://minio:9000" {
+				return fmt.Errorf("expected the S3 endpoint to be http://minio:9000, got %s", envVars["MLFLOW_S3_ENDPOINT_URL"].Value)
+			}
+
+			accessKey := envVars["AWS_ACCESS_KEY_ID"]
+
+			if accessKey.ValueFrom == nil || accessKey.ValueFrom.SecretKeyRef.Name != "minio-credentials" {
+				return fmt.Errorf("expected the access key to come from the credentials secret")
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+	})
+
+	It("Should store artifacts on a persistent volume", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForExperimentTrackingDeployment(ctx, "test-experimenttracking-volume")
+
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.ExperimentTracking.Artifacts.PersistentVolume = &mlopsv1alpha1.PersistentVolumeArtifactStorageSpec{
+				Size: resource.MustParse("5Gi"),
+			}
+		})
+
+		Eventually(func() error {
+			volumeClaim := &corev1.PersistentVolumeClaim{}
+			volumeClaimName := types.NamespacedName{
+				Name:      fmt.Sprintf("%s-mlflow-artifacts", workspace.GetName()),
+				Namespace: workspace.GetNamespace(),
+			}
+
+			if err := k8sClient.Get(ctx, volumeClaimName, volumeClaim); err != nil {
+				return err
+			}
+
+			if volumeClaim.Spec.AccessModes[0] != corev1.ReadWriteMany {
+				return fmt.Errorf("expected the volume to be ReadWriteMany, got %s", volumeClaim.Spec.AccessModes[0])
+			}
+
+			deployment, err := getExperimentTrackingDeployment(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			for _, volume := range deployment.Spec.Template.Spec.Volumes {
+				if volume.Name == "artifacts" && volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == volumeClaimName.Name {
+					return nil
+				}
+			}
+
+			return fmt.Errorf("expected the deployment to mount the artifact volume")
+		}, time.Minute, time.Second).Should(Succeed())
+	})
+
+	It("Should store artifacts on a writable volume without an artifact backend", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForExperimentTrackingDeployment(ctx, "test-experimenttracking-no-backend")
+
+		deployment, err := getExperimentTrackingDeployment(workspace)
+		Expect(err).NotTo(HaveOccurred())
+
+		podSpec := deployment.Spec.Template.Spec
+
+		Expect(podSpec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "MLFLOW_ARTIFACTS_DESTINATION", Value: artifactsMountPath}))
+		Expect(podSpec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: "artifacts", MountPath: artifactsMountPath}))
+		Expect(podSpec.Volumes).To(ContainElement(corev1.Volume{
+			Name:         "artifacts",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		}))
+	})
 })
 
 func getExperimentTrackingDeployment(workspace *mlopsv1alpha1.Workspace) (*appsv1.Deployment, error) {
@@ -143,6 +409,8 @@ func createWorkspaceAndWaitForExperimentTrackingDeployment(ctx context.Context,
 	err := k8sClient.Create(ctx, workspace)
 	Expect(err).NotTo(HaveOccurred())
 
+	simulateDatabaseReady(ctx, workspace)
+
 	Eventually(func() error {
 		_, err := getExperimentTrackingDeployment(workspace)
 		return err
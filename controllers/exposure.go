@@ -0,0 +1,557 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const exposureComponentName = "exposure"
+
+// The Gateway API and cert-manager are optional in the cluster, so we use unstructured resources for them.
+// This way the operator doesn't depend on their packages and still starts when they aren't installed.
+var (
+	httpRouteGroupVersionKind   = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "HTTPRoute"}
+	certificateGroupVersionKind = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+)
+
+// exposedInterface is a user interface in the workspace that we expose outside the cluster.
+// Interfaces that serve the path prefix themselves get the prefix as base path, the others only see paths without it.
+type exposedInterface struct {
+	name         string
+	serviceName  string
+	port         int32
+	servesPrefix bool
+}
+
+// exposedRoute routes requests with a path prefix to a user interface. The server receives the replacement
+// instead of the prefix.
+type exposedRoute struct {
+	prefix      string
+	replacement string
+}
+
+func newExposedInterfaces(workspace *mlopsv1alpha1.Workspace) []exposedInterface {
+	return []exposedInterface{
+		{name: "mlflow", serviceName: fmt.Sprintf("%s-mlflow-server", workspace.GetName()), port: 5000, servesPrefix: true},
+		{name: "prefect", serviceName: fmt.Sprintf("%s-orion-server", workspace.GetName()), port: 4200, servesPrefix: true},
+		{name: "ray", serviceName: fmt.Sprintf("%s-head-svc", newRayClusterName(workspace)), port: 8265},
+	}
+}
+
+func getExposedInterface(workspace *mlopsv1alpha1.Workspace, name string) exposedInterface {
+	for _, exposed := range newExposedInterfaces(workspace) {
+		if exposed.name == name {
+			return exposed
+		}
+	}
+
+	return exposedInterface{name: name}
+}
+
+func isWorkspaceExposed(workspace *mlopsv1alpha1.Workspace) bool {
+	mode := workspace.Spec.Exposure.Mode
+	return mode == mlopsv1alpha1.ExposureModeIngress || mode == mlopsv1alpha1.ExposureModeGateway
+}
+
+// newExposedHost returns the hostname of a user interface. With host routing every interface gets its own subdomain.
+func newExposedHost(workspace *mlopsv1alpha1.Workspace, exposed exposedInterface) string {
+	if workspace.Spec.Exposure.Routing == mlopsv1alpha1.ExposureRoutingPath {
+		return workspace.Spec.Exposure.Hostname
+	}
+
+	return fmt.Sprintf("%s.%s", exposed.name, workspace.Spec.Exposure.Hostname)
+}
+
+// newExposedPath returns the path of a user interface. With path routing every interface gets its own prefix.
+func newExposedPath(workspace *mlopsv1alpha1.Workspace, exposed exposedInterface) string {
+	if workspace.Spec.Exposure.Routing == mlopsv1alpha1.ExposureRoutingPath {
+		return fmt.Sprintf("/%s", exposed.name)
+	}
+
+	return "/"
+}
+
+// newExposedBasePath returns the path prefix an interface serves its pages under, or an empty string
+// when it serves them at the root.
+func newExposedBasePath(workspace *mlopsv1alpha1.Workspace, exposed exposedInterface) string {
+	if !exposed.servesPrefix || !isWorkspaceExposed(workspace) || workspace.Spec.Exposure.Routing != mlopsv1alpha1.ExposureRoutingPath {
+		return ""
+	}
+
+	return newExposedPath(workspace, exposed)
+}
+
+// newExposedRoutes returns the routes to a user interface. MLflow and Prefect serve their pages under the prefix,
+// and only their API at /api is moved to the root of the server. The Ray dashboard uses relative links,
+// so it receives all requests without the prefix.
+func newExposedRoutes(workspace *mlopsv1alpha1.Workspace, exposed exposedInterface) []exposedRoute {
+	exposedPath := newExposedPath(workspace, exposed)
+
+	if basePath := newExposedBasePath(workspace, exposed); basePath != "" {
+		return []exposedRoute{
+			{prefix: basePath + "/api", replacement: "/api"},
+			{prefix: basePath, replacement: basePath},
+		}
+	}
+
+	return []exposedRoute{{prefix: exposedPath, replacement: "/"}}
+}
+
+func newExposedHosts(workspace *mlopsv1alpha1.Workspace) []string {
+	hosts := []string{}
+
+	for _, exposed := range newExposedInterfaces(workspace) {
+		host := newExposedHost(workspace, exposed)
+
+		if len(hosts) == 0 || hosts[len(hosts)-1] != host {
+			hosts = append(hosts, host)
+		}
+	}
+
+	return hosts
+}
+
+// newExposedURL returns the address of a user interface. Paths end with a slash, so the relative links
+// in the user interfaces resolve under the prefix.
+func newExposedURL(workspace *mlopsv1alpha1.Workspace, exposed exposedInterface) string {
+	scheme := "http"
+
+	if workspace.Spec.Exposure.TLS != nil {
+		scheme = "https"
+	}
+
+	exposedPath := newExposedPath(workspace, exposed)
+
+	if exposedPath != "/" {
+		exposedPath = exposedPath + "/"
+	}
+
+	return fmt.Sprintf("%s://%s%s", scheme, newExposedHost(workspace, exposed), exposedPath)
+}
+
+// newWorkspaceURLs returns the addresses of the exposed user interfaces for the status of the workspace.
+func newWorkspaceURLs(workspace *mlopsv1alpha1.Workspace) mlopsv1alpha1.WorkspaceURLs {
+	if !isWorkspaceExposed(workspace) {
+		return mlopsv1alpha1.WorkspaceURLs{}
+	}
+
+	urls := map[string]string{}
+
+	for _, exposed := range newExposedInterfaces(workspace) {
+		urls[exposed.name] = newExposedURL(workspace, exposed)
+	}
+
+	return mlopsv1alpha1.WorkspaceURLs{
+		ExperimentTracking: urls["mlflow"],
+		Workflows:          urls["prefect"],
+		ComputeDashboard:   urls["ray"],
+	}
+}
+
+// exposureComponent exposes the user interfaces in the workspace with an Ingress or Gateway API HTTPRoutes.
+type exposureComponent struct {
+	componentBase
+}
+
+func (c *exposureComponent) Name() string {
+	return exposureComponentName
+}
+
+func (c *exposureComponent) ConditionType() string {
+	return mlopsv1alpha1.ConditionTypeExposureReady
+}
+
+// OwnedTypes only includes the Ingress. The HTTPRoutes and certificates are optional APIs, and watching them
+// fails when they aren't installed in the cluster.
+func (c *exposureComponent) OwnedTypes() []client.Object {
+	return []client.Object{&networkingv1.Ingress{}}
+}
+
+func (c *exposureComponent) Render(workspace *mlopsv1alpha1.Workspace) ([]client.Object, error) {
+	switch workspace.Spec.Exposure.Mode {
+	case mlopsv1alpha1.ExposureModeIngress:
+		return []client.Object{newExposureIngress(workspace)}, nil
+	case mlopsv1alpha1.ExposureModeGateway:
+		resources := []client.Object{}
+
+		if workspace.Spec.Exposure.TLS != nil {
+			resources = append(resources, newExposureCertificate(workspace))
+		}
+
+		for _, exposed := range newExposedInterfaces(workspace) {
+			resources = append(resources, newExposureHTTPRoute(workspace, exposed))
+		}
+
+		return resources, nil
+	}
+
+	return []client.Object{}, nil
+}
+
+func (c *exposureComponent) Ready(ctx context.Context, workspace *mlopsv1alpha1.Workspace) (componentCondition, error) {
+	var resource client.Object
+
+	switch workspace.Spec.Exposure.Mode {
+	case mlopsv1alpha1.ExposureModeIngress:
+		resource = &networkingv1.Ingress{}
+	case mlopsv1alpha1.ExposureModeGateway:
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(httpRouteGroupVersionKind)
+		resource = route
+	default:
+		return componentCondition{ready: true, reason: reasonDisabled, message: "The workspace is not exposed outside the cluster"}, nil
+	}
+
+	// The first interface is representative, we render the resources for all interfaces together.
+	resourceName := newExposureResourceName(workspace, newExposedInterfaces(workspace)[0])
+
+	if err := c.reconciler.Get(ctx, types.NamespacedName{Name: resourceName, Namespace: workspace.GetNamespace()}, resource); err != nil {
+		if meta.IsNoMatchError(err) {
+			return componentCondition{reason: reasonFailed, message: "The Gateway API is not installed in the cluster"}, nil
+		}
+
+		if errors.IsNotFound(err) {
+			return componentCondition{reason: reasonNotFound, message: "The routes for the user interfaces do not exist yet"}, nil
+		}
+
+		return componentCondition{}, err
+	}
+
+	return componentCondition{ready: true, reason: reasonAvailable, message: "The user interfaces are exposed"}, nil
+}
+
+// Cleanup removes the resources of the exposure modes that aren't in use.
+func (c *exposureComponent) Cleanup(ctx context.Context, workspace *mlopsv1alpha1.Workspace) error {
+	logger := log.FromContext(ctx).WithValues(
+		"workspace", workspace.GetName(),
+		"namespace", workspace.GetNamespace())
+
+	obsoleteResources := []client.Object{}
+	mode := workspace.Spec.Exposure.Mode
+
+	if mode != mlopsv1alpha1.ExposureModeIngress {
+		obsoleteResources = append(obsoleteResources, &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: newExposureName(workspace), Namespace: workspace.GetNamespace()},
+		})
+	}
+
+	if mode != mlopsv1alpha1.ExposureModeGateway {
+		for _, exposed := range newExposedInterfaces(workspace) {
+			obsoleteResources = append(obsoleteResources, newExposureHTTPRoute(workspace, exposed))
+		}
+	}
+
+	if mode != mlopsv1alpha1.ExposureModeGateway || workspace.Spec.Exposure.TLS == nil {
+		obsoleteResources = append(obsoleteResources, newExposureCertificate(workspace))
+	}
+
+	for _, resource := range obsoleteResources {
+		if err := c.reconciler.deleteOwnedResource(ctx, workspace, resource); err != nil {
+			logger.Error(err, "Failed to remove obsolete exposure resource", "resource", resource.GetName())
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteOwnedResource removes a resource when it exists and is controlled by the workspace.
+// Resources of APIs that aren't installed in the cluster don't exist, so we ignore them.
+func (r *WorkspaceReconciler) deleteOwnedResource(ctx context.Context, workspace *mlopsv1alpha1.Workspace, resource client.Object) error {
+	if err := r.Get(ctx, client.ObjectKeyFromObject(resource), resource); err != nil {
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	if !metav1.IsControlledBy(resource, workspace) {
+		return nil
+	}
+
+	if err := r.Delete(ctx, resource); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+func newExposureName(workspace *mlopsv1alpha1.Workspace) string {
+	return fmt.Sprintf("%s-ui", workspace.GetName())
+}
+
+func newExposureTLSSecretName(workspace *mlopsv1alpha1.Workspace) string {
+	return fmt.Sprintf("%s-ui-tls", workspace.GetName())
+}
+
+// newExposureResourceName returns the name of the resource that routes to an interface.
+// The Ingress covers all interfaces, while there's a HTTPRoute for every interface.
+func newExposureResourceName(workspace *mlopsv1alpha1.Workspace, exposed exposedInterface) string {
+	if workspace.Spec.Exposure.Mode == mlopsv1alpha1.ExposureModeIngress {
+		return newExposureName(workspace)
+	}
+
+	return fmt.Sprintf("%s-%s", newExposureName(workspace), exposed.name)
+}
+
+func newExposureAnnotations(workspace *mlopsv1alpha1.Workspace, annotations map[string]string) map[string]string {
+	for key, value := range workspace.Spec.Exposure.Annotations {
+		annotations[key] = value
+	}
+
+	return annotations
+}
+
+// newExposureIngress creates a single Ingress for all user interfaces. Path routing replaces the prefixes
+// with the rewrite annotations of ingress-nginx, other ingress controllers need their own annotations.
+func newExposureIngress(workspace *mlopsv1alpha1.Workspace) *networkingv1.Ingress {
+	exposure := workspace.Spec.Exposure
+	annotations := map[string]string{}
+
+	if exposure.Routing == mlopsv1alpha1.ExposureRoutingPath {
+		annotations["nginx.ingress.kubernetes.io/use-regex"] = "true"
+		annotations["nginx.ingress.kubernetes.io/rewrite-target"] = "/$2"
+	}
+
+	if exposure.TLS != nil {
+		switch exposure.TLS.IssuerRef.Kind {
+		case "ClusterIssuer":
+			annotations["cert-manager.io/cluster-issuer"] = exposure.TLS.IssuerRef.Name
+		default:
+			annotations["cert-manager.io/issuer"] = exposure.TLS.IssuerRef.Name
+		}
+	}
+
+	rules := []networkingv1.IngressRule{}
+
+	for _, exposed := range newExposedInterfaces(workspace) {
+		backendName, backendPort := newExposedBackend(workspace, exposed)
+		paths := []networkingv1.HTTPIngressPath{}
+
+		for _, route := range newExposedRoutes(workspace, exposed) {
+			path := networkingv1.HTTPIngressPath{
+				Path:     route.prefix,
+				PathType: newPathType(networkingv1.PathTypePrefix),
+				Backend: networkingv1.IngressBackend{
+					Service: &networkingv1.IngressServiceBackend{
+						Name: backendName,
+						Port: networkingv1.ServiceBackendPort{Number: backendPort},
+					},
+				},
+			}
+
+			if exposure.Routing == mlopsv1alpha1.ExposureRoutingPath {
+				path.Path = newIngressPathExpression(route)
+				path.PathType = newPathType(networkingv1.PathTypeImplementationSpecific)
+			}
+
+			paths = append(paths, path)
+		}
+
+		host := newExposedHost(workspace, exposed)
+
+		// Path routing puts all interfaces under one hostname, so they share a rule.
+		if len(rules) > 0 && rules[len(rules)-1].Host == host {
+			rules[len(rules)-1].HTTP.Paths = append(rules[len(rules)-1].HTTP.Paths, paths...)
+			continue
+		}
+
+		rules = append(rules, networkingv1.IngressRule{
+			Host: host,
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: paths,
+				},
+			},
+		})
+	}
+
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        newExposureName(workspace),
+			Namespace:   workspace.GetNamespace(),
+			Labels:      newComponentLabels(workspace, exposureComponentName),
+			Annotations: newExposureAnnotations(workspace, annotations),
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: exposure.IngressClassName,
+			Rules:            rules,
+		},
+	}
+
+	if exposure.TLS != nil {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{
+			{
+				Hosts:      newExposedHosts(workspace),
+				SecretName: newExposureTLSSecretName(workspace),
+			},
+		}
+	}
+
+	return ingress
+}
+
+// newIngressPathExpression returns the path of a route for ingress-nginx. The rewrite target of the Ingress
+// is /$2, so the second group matches the path the server receives without the leading slash.
+func newIngressPathExpression(route exposedRoute) string {
+	if route.replacement == "/" {
+		return fmt.Sprintf("%s(/|$)(.*)", route.prefix)
+	}
+
+	removedPrefix := strings.TrimSuffix(route.prefix, route.replacement)
+	return fmt.Sprintf("%s/()(%s(/.*|$))", removedPrefix, strings.TrimPrefix(route.replacement, "/"))
+}
+
+// newExposureHTTPRoute creates a route for a user interface on the configured gateway.
+// Path routing replaces the prefix with a URLRewrite filter where the server doesn't expect it.
+func newExposureHTTPRoute(workspace *mlopsv1alpha1.Workspace, exposed exposedInterface) *unstructured.Unstructured {
+	exposure := workspace.Spec.Exposure
+	backendName, backendPort := newExposedBackend(workspace, exposed)
+	rules := []interface{}{}
+
+	for _, route := range newExposedRoutes(workspace, exposed) {
+		rule := map[string]interface{}{
+			"matches": []interface{}{
+				map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":  "PathPrefix",
+						"value": route.prefix,
+					},
+				},
+			},
+			"backendRefs": []interface{}{
+				map[string]interface{}{
+					"name": backendName,
+					"port": int64(backendPort),
+				},
+			},
+		}
+
+		if route.replacement != route.prefix {
+			rule["filters"] = []interface{}{
+				map[string]interface{}{
+					"type": "URLRewrite",
+					"urlRewrite": map[string]interface{}{
+						"path": map[string]interface{}{
+							"type":               "ReplacePrefixMatch",
+							"replacePrefixMatch": route.replacement,
+						},
+					},
+				},
+			}
+		}
+
+		rules = append(rules, rule)
+	}
+
+	spec := map[string]interface{}{
+		"hostnames": []interface{}{newExposedHost(workspace, exposed)},
+		"rules":     rules,
+	}
+
+	if exposure.Gateway != nil {
+		parentRef := map[string]interface{}{"name": exposure.Gateway.Name}
+
+		if exposure.Gateway.Namespace != "" {
+			parentRef["namespace"] = exposure.Gateway.Namespace
+		}
+
+		if exposure.Gateway.SectionName != "" {
+			parentRef["sectionName"] = exposure.Gateway.SectionName
+		}
+
+		spec["parentRefs"] = []interface{}{parentRef}
+	}
+
+	route := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	route.SetGroupVersionKind(httpRouteGroupVersionKind)
+	route.SetName(fmt.Sprintf("%s-%s", newExposureName(workspace), exposed.name))
+	route.SetNamespace(workspace.GetNamespace())
+	route.SetLabels(newComponentLabels(workspace, exposureComponentName))
+	route.SetAnnotations(newExposureAnnotations(workspace, map[string]string{}))
+
+	return route
+}
+
+// newExposureCertificate requests a certificate for the hostnames of the user interfaces.
+// TLS terminates at the gateway, so the gateway needs a listener that uses the certificate secret.
+func newExposureCertificate(workspace *mlopsv1alpha1.Workspace) *unstructured.Unstructured {
+	dnsNames := []interface{}{}
+
+	for _, host := range newExposedHosts(workspace) {
+		dnsNames = append(dnsNames, host)
+	}
+
+	spec := map[string]interface{}{
+		"secretName": newExposureTLSSecretName(workspace),
+		"dnsNames":   dnsNames,
+	}
+
+	if workspace.Spec.Exposure.TLS != nil {
+		spec["issuerRef"] = map[string]interface{}{
+			"name":  workspace.Spec.Exposure.TLS.IssuerRef.Name,
+			"kind":  workspace.Spec.Exposure.TLS.IssuerRef.Kind,
+			"group": "cert-manager.io",
+		}
+	}
+
+	certificate := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	certificate.SetGroupVersionKind(certificateGroupVersionKind)
+	certificate.SetName(newExposureTLSSecretName(workspace))
+	certificate.SetNamespace(workspace.GetNamespace())
+	certificate.SetLabels(newComponentLabels(workspace, exposureComponentName))
+
+	return certificate
+}
+
+// newWorkflowsUIEnvVars tells the Prefect user interface where to find the API when the workspace is exposed.
+// The browser can't reach the address of the API inside the cluster. With path routing the interface is served
+// under its prefix.
+func newWorkflowsUIEnvVars(workspace *mlopsv1alpha1.Workspace) []corev1.EnvVar {
+	if !isWorkspaceExposed(workspace) {
+		return []corev1.EnvVar{}
+	}
+
+	envVars := []corev1.EnvVar{
+		{
+			Name:  "PREFECT_UI_API_URL",
+			Value: strings.TrimSuffix(newWorkspaceURLs(workspace).Workflows, "/") + "/api",
+		},
+	}
+
+	if basePath := newExposedBasePath(workspace, getExposedInterface(workspace, "prefect")); basePath != "" {
+		envVars = append(envVars, corev1.EnvVar{Name: "PREFECT_UI_SERVE_BASE", Value: basePath})
+	}
+
+	return envVars
+}
+
+// newExperimentTrackingUIEnvVars serves the MLflow user interface under its prefix with path routing.
+// MLflow keeps serving its REST API at the root.
+func newExperimentTrackingUIEnvVars(workspace *mlopsv1alpha1.Workspace) []corev1.EnvVar {
+	basePath := newExposedBasePath(workspace, getExposedInterface(workspace, "mlflow"))
+
+	if basePath == "" {
+		return []corev1.EnvVar{}
+	}
+
+	return []corev1.EnvVar{{Name: "MLFLOW_STATIC_PREFIX", Value: basePath}}
+}
+
+func newPathType(pathType networkingv1.PathType) *networkingv1.PathType {
+	return &pathType
+}
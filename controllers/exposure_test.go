@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("exposureComponent", func() {
+	It("Should expose the user interfaces with host-based routing", func() {
+		ctx := context.Background()
+		workspace := newTestWorkspace("test-exposure-host")
+		workspace.Spec.Exposure = mlopsv1alpha1.ExposureSpec{
+			Mode:     mlopsv1alpha1.ExposureModeIngress,
+			Routing:  mlopsv1alpha1.ExposureRoutingHost,
+			Hostname: "ml.example.com",
+			TLS: &mlopsv1alpha1.ExposureTLSSpec{
+				IssuerRef: mlopsv1alpha1.IssuerReference{Name: "letsencrypt", Kind: "ClusterIssuer"},
+			},
+		}
+
+		Expect(k8sClient.Create(ctx, workspace)).To(Succeed())
+
+		ingress := getExposureIngress(ctx, workspace)
+
+		Expect(ingress.Spec.Rules).To(HaveLen(3))
+		Expect(ingress.Spec.Rules[0].Host).To(Equal("mlflow.ml.example.com"))
+		Expect(ingress.Spec.TLS[0].Hosts).To(ConsistOf("mlflow.ml.example.com", "prefect.ml.example.com", "ray.ml.example.com"))
+		Expect(ingress.GetAnnotations()).To(HaveKeyWithValue("cert-manager.io/cluster-issuer", "letsencrypt"))
+
+		Eventually(func() error {
+			updatedWorkspace, err := getWorkspace(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			expectedURLs := mlopsv1alpha1.WorkspaceURLs{
+				ExperimentTracking: "https://mlflow.ml.example.com/",
+				Workflows:          "https://prefect.ml.example.com/",
+				ComputeDashboard:   "https://ray.ml.example.com/",
+			}
+
+			if updatedWorkspace.Status.URLs != expectedURLs {
+				return fmt.Errorf("expected the urls %v, got %v", expectedURLs, updatedWorkspace.Status.URLs)
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+	})
+
+	It("Should expose the user interfaces with path-based routing", func() {
+		ctx := context.Background()
+		workspace := newTestWorkspace("test-exposure-path")
+		workspace.Spec.Exposure = mlopsv1alpha1.ExposureSpec{
+			Mode:     mlopsv1alpha1.ExposureModeIngress,
+			Routing:  mlopsv1alpha1.ExposureRoutingPath,
+			Hostname: "ml.example.com",
+		}
+
+		Expect(k8sClient.Create(ctx, workspace)).To(Succeed())
+
+		ingress := getExposureIngress(ctx, workspace)
+
+		Expect(ingress.Spec.Rules).To(HaveLen(1))
+		Expect(ingress.Spec.Rules[0].Host).To(Equal("ml.example.com"))
+		Expect(ingress.Spec.Rules[0].HTTP.Paths).To(HaveLen(5))
+		Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Path).To(Equal("/mlflow/()(api(/.*|$))"))
+		Expect(ingress.Spec.Rules[0].HTTP.Paths[1].Path).To(Equal("/()(mlflow(/.*|$))"))
+		Expect(ingress.Spec.Rules[0].HTTP.Paths[4].Path).To(Equal("/ray(/|$)(.*)"))
+		Expect(ingress.Annotations).To(HaveKeyWithValue("nginx.ingress.kubernetes.io/rewrite-target", "/$2"))
+
+		Expect(newWorkflowsUIEnvVars(workspace)).To(ConsistOf(
+			corev1.EnvVar{Name: "PREFECT_UI_API_URL", Value: "http://ml.example.com/prefect/api"},
+			corev1.EnvVar{Name: "PREFECT_UI_SERVE_BASE", Value: "/prefect"},
+		))
+		Expect(newExperimentTrackingUIEnvVars(workspace)).To(ConsistOf(corev1.EnvVar{Name: "MLFLOW_STATIC_PREFIX", Value: "/mlflow"}))
+	})
+
+	It("Should remove the ingress when the workspace is no longer exposed", func() {
+		ctx := context.Background()
+		workspace := newTestWorkspace("test-exposure-removed")
+		workspace.Spec.Exposure = mlopsv1alpha1.ExposureSpec{
+			Mode:     mlopsv1alpha1.ExposureModeIngress,
+			Routing:  mlopsv1alpha1.ExposureRoutingHost,
+			Hostname: "ml.example.com",
+		}
+
+		Expect(k8sClient.Create(ctx, workspace)).To(Succeed())
+
+		ingress := getExposureIngress(ctx, workspace)
+
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.Exposure.Mode = mlopsv1alpha1.ExposureModeNone
+		})
+
+		Eventually(func() bool {
+			ingressName := types.NamespacedName{Name: ingress.GetName(), Namespace: ingress.GetNamespace()}
+
+			err := k8sClient.Get(ctx, ingressName, &networkingv1.Ingress{})
+			return errors.IsNotFound(err)
+		}, time.Minute, time.Second).Should(BeTrue())
+	})
+})
+
+func getExposureIngress(ctx context.Context, workspace *mlopsv1alpha1.Workspace) *networkingv1.Ingress {
+	ingress := &networkingv1.Ingress{}
+	ingressName := types.NamespacedName{Name: fmt.Sprintf("%s-ui", workspace.GetName()), Namespace: workspace.GetNamespace()}
+
+	Eventually(func() error {
+		return k8sClient.Get(ctx, ingressName, ingress)
+	}, time.Minute, time.Second).Should(Succeed())
+
+	return ingress
+}
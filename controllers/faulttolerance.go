@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	faultToleranceComponentName = "fault-tolerance"
+
+	// redisPort is the port of the Redis instance that stores the state of the ray head.
+	redisPort = 6379
+)
+
+// faultToleranceComponent manages the Redis instance that stores the state of the ray head.
+// KubeRay restores the state of the head from Redis after a restart, so the workers and running jobs survive.
+type faultToleranceComponent struct {
+	componentBase
+}
+
+func (c *faultToleranceComponent) Name() string {
+	return faultToleranceComponentName
+}
+
+func (c *faultToleranceComponent) ConditionType() string {
+	return mlopsv1alpha1.ConditionTypeFaultToleranceReady
+}
+
+func (c *faultToleranceComponent) OwnedTypes() []client.Object {
+	return []client.Object{
+		&appsv1.StatefulSet{},
+		&corev1.Service{},
+		&corev1.PersistentVolumeClaim{},
+		&corev1.Secret{},
+		&corev1.ServiceAccount{},
+	}
+}
+
+func (c *faultToleranceComponent) Render(workspace *mlopsv1alpha1.Workspace) ([]client.Object, error) {
+	if !isRedisDeployed(workspace) {
+		return []client.Object{}, nil
+	}
+
+	return []client.Object{
+		newRedisServiceAccount(workspace),
+		newRedisVolumeClaim(workspace),
+		newRedisStatefulSet(workspace),
+		newRedisService(workspace),
+	}, nil
+}
+
+// Apply generates the password of the Redis instance before applying the other resources.
+func (c *faultToleranceComponent) Apply(ctx context.Context, workspace *mlopsv1alpha1.Workspace, resources []client.Object) error {
+	if !isRedisDeployed(workspace) {
+		return nil
+	}
+
+	keyLengths := map[string]int{"password": 20}
+
+	if err := c.reconciler.createGeneratedSecret(ctx, workspace, newRedisName(workspace), faultToleranceComponentName, keyLengths); err != nil {
+		return err
+	}
+
+	return c.reconciler.applyResources(ctx, workspace, resources)
+}
+
+func (c *faultToleranceComponent) Ready(ctx context.Context, workspace *mlopsv1alpha1.Workspace) (componentCondition, error) {
+	faultTolerance := workspace.Spec.Compute.FaultTolerance
+
+	if !faultTolerance.Enabled {
+		return componentCondition{ready: true, reason: reasonDisabled, message: "Fault tolerance is disabled"}, nil
+	}
+
+	if faultTolerance.ExternalRedis != nil {
+		return componentCondition{ready: true, reason: reasonAvailable, message: "The ray head stores its state in the external Redis instance"}, nil
+	}
+
+	statefulSet := &appsv1.StatefulSet{}
+	statefulSetName := newRedisName(workspace)
+
+	if err := c.reconciler.Get(ctx, types.NamespacedName{Name: statefulSetName, Namespace: workspace.GetNamespace()}, statefulSet); err != nil {
+		if errors.IsNotFound(err) {
+			return componentCondition{reason: reasonNotFound, message: "The Redis instance does not exist yet"}, nil
+		}
+
+		return componentCondition{}, err
+	}
+
+	return newStatefulSetCondition(statefulSet), nil
+}
+
+// Cleanup removes the Redis instance when the workspace no longer needs it. The volume and password
+// are removed together with the workspace.
+func (c *faultToleranceComponent) Cleanup(ctx context.Context, workspace *mlopsv1alpha1.Workspace) error {
+	if isRedisDeployed(workspace) {
+		return nil
+	}
+
+	logger := log.FromContext(ctx).WithValues(
+		"workspace", workspace.GetName(),
+		"namespace", workspace.GetNamespace())
+
+	objectMeta := metav1.ObjectMeta{Name: newRedisName(workspace), Namespace: workspace.GetNamespace()}
+
+	obsoleteResources := []client.Object{
+		&appsv1.StatefulSet{ObjectMeta: objectMeta},
+		&corev1.Service{ObjectMeta: objectMeta},
+		&corev1.ServiceAccount{ObjectMeta: objectMeta},
+	}
+
+	for _, resource := range obsoleteResources {
+		if err := c.reconciler.deleteOwnedResource(ctx, workspace, resource); err != nil {
+			logger.Error(err, "Failed to remove Redis resource", "resource", resource.GetName())
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isRedisDeployed returns true when the operator deploys a Redis instance in the workspace.
+func isRedisDeployed(workspace *mlopsv1alpha1.Workspace) bool {
+	faultTolerance := workspace.Spec.Compute.FaultTolerance
+	return faultTolerance.Enabled && faultTolerance.ExternalRedis == nil
+}
+
+func newRedisName(workspace *mlopsv1alpha1.Workspace) string {
+	return fmt.Sprintf("%s-redis", workspace.GetName())
+}
+
+// newRedisAddress returns the host and port of the Redis instance the ray head uses.
+func newRedisAddress(workspace *mlopsv1alpha1.Workspace) string {
+	if externalRedis := workspace.Spec.Compute.FaultTolerance.ExternalRedis; externalRedis != nil {
+		return externalRedis.Address
+	}
+
+	return fmt.Sprintf("%s:%d", newRedisName(workspace), redisPort)
+}
+
+// newRedisPasswordEnvVars returns the password of the Redis instance as the REDIS_PASSWORD variable.
+// An external Redis instance doesn't need a password.
+func newRedisPasswordEnvVars(workspace *mlopsv1alpha1.Workspace) []corev1.EnvVar {
+	externalRedis := workspace.Spec.Compute.FaultTolerance.ExternalRedis
+
+	if externalRedis == nil {
+		return []corev1.EnvVar{newSecretEnvVar("REDIS_PASSWORD", newRedisName(workspace), "password")}
+	}
+
+	if externalRedis.PasswordSecretRef == nil {
+		return []corev1.EnvVar{}
+	}
+
+	return []corev1.EnvVar{
+		newSecretEnvVar("REDIS_PASSWORD", externalRedis.PasswordSecretRef.Name, externalRedis.PasswordSecretRef.Key),
+	}
+}
+
+func newRedisVolumeClaim(workspace *mlopsv1alpha1.Workspace) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      newRedisName(workspace),
+			Namespace: workspace.GetNamespace(),
+			Labels:    newComponentLabels(workspace, faultToleranceComponentName),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{
+				corev1.ReadWriteOnce,
+			},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: workspace.Spec.Storage.FaultTolerance,
+				},
+			},
+		},
+	}
+}
+
+// newRedisStatefulSet creates a single Redis instance. Redis writes every change to its append-only file,
+// so the state of the ray head survives a restart of Redis too.
+func newRedisStatefulSet(workspace *mlopsv1alpha1.Workspace) *appsv1.StatefulSet {
+	statefulSetName := newRedisName(workspace)
+	statefulSetLabels := newComponentLabels(workspace, faultToleranceComponentName)
+	faultTolerance := workspace.Spec.Compute.FaultTolerance
+
+	container := newContainer("redis", faultTolerance.Image, faultTolerance.Resources)
+	container.Command = []string{"redis-server"}
+	container.Args = []string{"--appendonly", "yes", "--dir", "/data", "--requirepass", "$(REDIS_PASSWORD)"}
+	container.Env = newRedisPasswordEnvVars(workspace)
+
+	container.Ports = []corev1.ContainerPort{
+		{
+			Name:          "tcp-redis",
+			ContainerPort: redisPort,
+		},
+	}
+
+	container.VolumeMounts = []corev1.VolumeMount{
+		{
+			Name:      "data",
+			MountPath: "/data",
+		},
+	}
+
+	statefulSet := newStatefulSet(workspace.GetNamespace(), statefulSetName, statefulSetLabels, pointer.Int32(1), container)
+	statefulSet.Spec.ServiceName = statefulSetName
+	statefulSet.Spec.Template.Spec.ServiceAccountName = statefulSetName
+	applyScheduling(&statefulSet.Spec.Template.Spec, statefulSetLabels, faultTolerance.Scheduling)
+
+	statefulSet.Spec.Template.Spec.Volumes = append(statefulSet.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: "data",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: newRedisName(workspace),
+			},
+		},
+	})
+
+	return statefulSet
+}
+
+func newRedisServiceAccount(workspace *mlopsv1alpha1.Workspace) *corev1.ServiceAccount {
+	return newServiceAccount(
+		workspace.GetNamespace(),
+		newRedisName(workspace),
+		newComponentLabels(workspace, faultToleranceComponentName),
+		mlopsv1alpha1.ServiceAccountSpec{},
+	)
+}
+
+func newRedisService(workspace *mlopsv1alpha1.Workspace) *corev1.Service {
+	serviceLabels := newComponentLabels(workspace, faultToleranceComponentName)
+	service := newService(newRedisName(workspace), workspace.GetNamespace(), serviceLabels)
+
+	service.Spec.Ports = []corev1.ServicePort{
+		{
+			Name:       "tcp-redis",
+			Protocol:   corev1.ProtocolTCP,
+			Port:       redisPort,
+			TargetPort: intstr.FromInt(redisPort),
+		},
+	}
+
+	return service
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("faultToleranceComponent", func() {
+	It("Should store the state of the ray head in the Redis instance of the workspace", func() {
+		ctx := context.Background()
+		workspace := newFaultTolerantTestWorkspace("test-fault-tolerance")
+
+		Expect(k8sClient.Create(ctx, workspace)).To(Succeed())
+
+		simulateDatabaseReady(ctx, workspace)
+		simulateDeploymentAvailable(ctx, workspace.GetNamespace(), "test-fault-tolerance-mlflow-server")
+		simulateStatefulSetReady(ctx, workspace.GetNamespace(), "test-fault-tolerance-redis")
+
+		secret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "test-fault-tolerance-redis", Namespace: workspace.GetNamespace()}, secret)).To(Succeed())
+		Expect(secret.Data).To(HaveKey("password"))
+
+		redisName := types.NamespacedName{Name: "test-fault-tolerance-redis", Namespace: workspace.GetNamespace()}
+		statefulSet := &appsv1.StatefulSet{}
+
+		Expect(k8sClient.Get(ctx, redisName, statefulSet)).To(Succeed())
+		Expect(statefulSet.Spec.Template.Spec.ServiceAccountName).To(Equal(redisName.Name))
+		Expect(k8sClient.Get(ctx, redisName, &corev1.ServiceAccount{})).To(Succeed())
+
+		Eventually(func() error {
+			_, err := getRayCluster(workspace)
+			return err
+		}, time.Minute, time.Second).Should(Succeed())
+
+		computeCluster, err := getRayCluster(workspace)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(computeCluster.Annotations).To(HaveKeyWithValue("ray.io/ft-enabled", "true"))
+		Expect(computeCluster.Spec.HeadGroupSpec.RayStartParams).To(HaveKeyWithValue("redis-password", "$REDIS_PASSWORD"))
+
+		headContainer := computeCluster.Spec.HeadGroupSpec.Template.Spec.Containers[0]
+		Expect(headContainer.Env).To(ContainElements(
+			corev1.EnvVar{Name: "RAY_REDIS_ADDRESS", Value: "test-fault-tolerance-redis:6379"},
+			newSecretEnvVar("REDIS_PASSWORD", "test-fault-tolerance-redis", "password"),
+		))
+
+		workerContainer := computeCluster.Spec.WorkerGroupSpecs[0].Template.Spec.Containers[0]
+		Expect(workerContainer.Env).To(ContainElement(corev1.EnvVar{Name: "RAY_gcs_rpc_server_reconnect_timeout_s", Value: "600"}))
+	})
+
+	It("Should use the external Redis instance instead of deploying one", func() {
+		ctx := context.Background()
+		workspace := newFaultTolerantTestWorkspace("test-fault-tolerance-external")
+		workspace.Spec.Compute.FaultTolerance.ExternalRedis = &mlopsv1alpha1.ExternalRedisSpec{
+			Address: "redis.example.com:6379",
+			PasswordSecretRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "external-redis"},
+				Key:                  "redis-password",
+			},
+		}
+
+		Expect(k8sClient.Create(ctx, workspace)).To(Succeed())
+
+		simulateDatabaseReady(ctx, workspace)
+		simulateDeploymentAvailable(ctx, workspace.GetNamespace(), "test-fault-tolerance-external-mlflow-server")
+
+		Eventually(func() error {
+			_, err := getRayCluster(workspace)
+			return err
+		}, time.Minute, time.Second).Should(Succeed())
+
+		computeCluster, err := getRayCluster(workspace)
+		Expect(err).NotTo(HaveOccurred())
+
+		headContainer := computeCluster.Spec.HeadGroupSpec.Template.Spec.Containers[0]
+		Expect(headContainer.Env).To(ContainElements(
+			corev1.EnvVar{Name: "RAY_REDIS_ADDRESS", Value: "redis.example.com:6379"},
+			newSecretEnvVar("REDIS_PASSWORD", "external-redis", "redis-password"),
+		))
+
+		statefulSet := &appsv1.StatefulSet{}
+		statefulSetName := types.NamespacedName{Name: "test-fault-tolerance-external-redis", Namespace: workspace.GetNamespace()}
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, statefulSetName, statefulSet))).To(BeTrue())
+	})
+})
+
+func newFaultTolerantTestWorkspace(name string) *mlopsv1alpha1.Workspace {
+	workspace := newTestWorkspace(name)
+	workspace.Spec.Storage.FaultTolerance = resource.MustParse("1Gi")
+	workspace.Spec.Compute.FaultTolerance = mlopsv1alpha1.FaultToleranceSpec{
+		Enabled: true,
+		Image:   "redis:7.0.10",
+	}
+
+	return workspace
+}
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const membersComponentName = "members"
+
+// workspaceRoles are the roles of the members, from the role with the least permissions to the most permissions.
+var workspaceRoles = []mlopsv1alpha1.WorkspaceRole{
+	mlopsv1alpha1.WorkspaceRoleViewer,
+	mlopsv1alpha1.WorkspaceRoleContributor,
+	mlopsv1alpha1.WorkspaceRoleOwner,
+}
+
+// membersComponent generates a Role for each workspace role and binds the members of the workspace to them.
+type membersComponent struct {
+	componentBase
+}
+
+func (c *membersComponent) Name() string {
+	return membersComponentName
+}
+
+func (c *membersComponent) ConditionType() string {
+	return ""
+}
+
+func (c *membersComponent) OwnedTypes() []client.Object {
+	return []client.Object{&rbacv1.Role{}, &rbacv1.RoleBinding{}}
+}
+
+// Render always creates the roles and bindings, so removing the last member of a role removes its subjects too.
+func (c *membersComponent) Render(workspace *mlopsv1alpha1.Workspace) ([]client.Object, error) {
+	resources := []client.Object{}
+
+	for _, role := range workspaceRoles {
+		resources = append(resources, newWorkspaceRole(workspace, role), newWorkspaceRoleBinding(workspace, role))
+	}
+
+	return resources, nil
+}
+
+func (c *membersComponent) Ready(ctx context.Context, workspace *mlopsv1alpha1.Workspace) (componentCondition, error) {
+	for _, role := range workspaceRoles {
+		roleBinding := &rbacv1.RoleBinding{}
+		roleBindingName := newWorkspaceRoleName(workspace, role)
+
+		if err := c.reconciler.Get(ctx, types.NamespacedName{Name: roleBindingName, Namespace: workspace.GetNamespace()}, roleBinding); err != nil {
+			if errors.IsNotFound(err) {
+				return componentCondition{reason: reasonNotFound, message: fmt.Sprintf("Role binding %s does not exist yet", roleBindingName)}, nil
+			}
+
+			return componentCondition{}, err
+		}
+	}
+
+	return componentCondition{ready: true, reason: reasonAvailable, message: "The members of the workspace are bound to their roles"}, nil
+}
+
+func newWorkspaceRoleName(workspace *mlopsv1alpha1.Workspace, role mlopsv1alpha1.WorkspaceRole) string {
+	return fmt.Sprintf("%s-%s", workspace.GetName(), strings.ToLower(string(role)))
+}
+
+// newWorkspaceRoleRules returns the permissions of a role. Each role has the permissions of the roles before it.
+// Kubernetes can't limit access to pods by label, so the permissions for pods cover the whole namespace.
+// The operator can only grant permissions it has itself, so its role needs every rule in this list.
+func newWorkspaceRoleRules(workspace *mlopsv1alpha1.Workspace, role mlopsv1alpha1.WorkspaceRole) []rbacv1.PolicyRule {
+	readVerbs := []string{"get", "list", "watch"}
+	writeVerbs := []string{"create", "update", "patch", "delete"}
+
+	rules := []rbacv1.PolicyRule{
+		{
+			APIGroups:     []string{"mlops.aigency.com"},
+			Resources:     []string{"workspaces", "workspaces/status"},
+			ResourceNames: []string{workspace.GetName()},
+			Verbs:         []string{"get"},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods", "pods/log", "services", "configmaps", "events"},
+			Verbs:     readVerbs,
+		},
+		{
+			APIGroups: []string{"apps"},
+			Resources: []string{"deployments", "statefulsets"},
+			Verbs:     readVerbs,
+		},
+		{
+			APIGroups: []string{"ray.io"},
+			Resources: []string{"rayclusters", "rayjobs", "rayservices"},
+			Verbs:     readVerbs,
+		},
+		{
+			APIGroups: []string{"mlops.aigency.com"},
+			Resources: []string{"trainingjobs", "trainingjobs/status", "modeldeployments", "modeldeployments/status"},
+			Verbs:     readVerbs,
+		},
+	}
+
+	if role == mlopsv1alpha1.WorkspaceRoleViewer {
+		return rules
+	}
+
+	rules = append(rules,
+		rbacv1.PolicyRule{
+			APIGroups: []string{""},
+			Resources: []string{"pods/portforward"},
+			Verbs:     []string{"create"},
+		},
+		rbacv1.PolicyRule{
+			APIGroups: []string{"ray.io"},
+			Resources: []string{"rayjobs"},
+			Verbs:     writeVerbs,
+		},
+		rbacv1.PolicyRule{
+			APIGroups: []string{"mlops.aigency.com"},
+			Resources: []string{"trainingjobs", "modeldeployments"},
+			Verbs:     writeVerbs,
+		},
+		rbacv1.PolicyRule{
+			APIGroups:     []string{""},
+			Resources:     []string{"secrets"},
+			ResourceNames: []string{newObjectStorageCredentialsName(workspace)},
+			Verbs:         []string{"get"},
+		},
+	)
+
+	if role == mlopsv1alpha1.WorkspaceRoleContributor {
+		return rules
+	}
+
+	databaseSecretNames := []string{}
+
+	for _, componentName := range []string{experimentTrackingComponentName, workflowsComponentName} {
+		databaseSecretNames = append(databaseSecretNames, newDatabaseSecretName(workspace, componentName))
+	}
+
+	return append(rules,
+		rbacv1.PolicyRule{
+			APIGroups:     []string{"mlops.aigency.com"},
+			Resources:     []string{"workspaces"},
+			ResourceNames: []string{workspace.GetName()},
+			Verbs:         []string{"update", "patch", "delete"},
+		},
+		rbacv1.PolicyRule{
+			APIGroups:     []string{""},
+			Resources:     []string{"secrets"},
+			ResourceNames: databaseSecretNames,
+			Verbs:         []string{"get"},
+		},
+		rbacv1.PolicyRule{
+			APIGroups: []string{""},
+			Resources: []string{"pods"},
+			Verbs:     []string{"delete"},
+		},
+	)
+}
+
+func newWorkspaceRole(workspace *mlopsv1alpha1.Workspace, role mlopsv1alpha1.WorkspaceRole) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      newWorkspaceRoleName(workspace, role),
+			Namespace: workspace.GetNamespace(),
+			Labels:    newComponentLabels(workspace, membersComponentName),
+		},
+		Rules: newWorkspaceRoleRules(workspace, role),
+	}
+}
+
+func newWorkspaceRoleBinding(workspace *mlopsv1alpha1.Workspace, role mlopsv1alpha1.WorkspaceRole) *rbacv1.RoleBinding {
+	subjects := []rbacv1.Subject{}
+
+	for _, member := range workspace.Spec.Members {
+		if member.Role != role {
+			continue
+		}
+
+		subjectKind := rbacv1.UserKind
+
+		if member.Kind == mlopsv1alpha1.WorkspaceMemberKindGroup {
+			subjectKind = rbacv1.GroupKind
+		}
+
+		subjects = append(subjects, rbacv1.Subject{
+			Kind:     subjectKind,
+			APIGroup: rbacv1.GroupName,
+			Name:     member.Name,
+		})
+	}
+
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      newWorkspaceRoleName(workspace, role),
+			Namespace: workspace.GetNamespace(),
+			Labels:    newComponentLabels(workspace, membersComponentName),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     newWorkspaceRoleName(workspace, role),
+		},
+		Subjects: subjects,
+	}
+}
+
+// newMemberUsers returns the users that are a member of the workspace.
+func newMemberUsers(workspace *mlopsv1alpha1.Workspace) []string {
+	users := []string{}
+
+	for _, member := range workspace.Spec.Members {
+		if member.Kind == mlopsv1alpha1.WorkspaceMemberKindUser {
+			users = append(users, member.Name)
+		}
+	}
+
+	return users
+}
+
+// newMemberGroups returns the groups that are a member of the workspace.
+// MLflow and Prefect don't have permissions of their own, so every member group can use the user interfaces.
+func newMemberGroups(workspace *mlopsv1alpha1.Workspace) []string {
+	groups := []string{}
+
+	for _, member := range workspace.Spec.Members {
+		if member.Kind == mlopsv1alpha1.WorkspaceMemberKindGroup {
+			groups = append(groups, member.Name)
+		}
+	}
+
+	return groups
+}
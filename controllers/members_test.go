@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("membersComponent", func() {
+	It("Should bind the members of the workspace to their roles", func() {
+		ctx := context.Background()
+		workspace := newTestWorkspace("test-members")
+		workspace.Spec.Members = []mlopsv1alpha1.WorkspaceMember{
+			{Kind: mlopsv1alpha1.WorkspaceMemberKindUser, Name: "jane@example.com", Role: mlopsv1alpha1.WorkspaceRoleOwner},
+			{Kind: mlopsv1alpha1.WorkspaceMemberKindGroup, Name: "data-scientists", Role: mlopsv1alpha1.WorkspaceRoleContributor},
+		}
+
+		Expect(k8sClient.Create(ctx, workspace)).To(Succeed())
+
+		ownerBinding := getWorkspaceRoleBinding(ctx, workspace, "owner")
+
+		Expect(ownerBinding.RoleRef.Name).To(Equal("test-members-owner"))
+		Expect(ownerBinding.Subjects).To(ConsistOf(rbacv1.Subject{
+			Kind:     rbacv1.UserKind,
+			APIGroup: rbacv1.GroupName,
+			Name:     "jane@example.com",
+		}))
+
+		contributorBinding := getWorkspaceRoleBinding(ctx, workspace, "contributor")
+
+		Expect(contributorBinding.Subjects).To(ConsistOf(rbacv1.Subject{
+			Kind:     rbacv1.GroupKind,
+			APIGroup: rbacv1.GroupName,
+			Name:     "data-scientists",
+		}))
+
+		contributorRole := &rbacv1.Role{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "test-members-contributor", Namespace: workspace.GetNamespace()}, contributorRole)).To(Succeed())
+
+		Expect(contributorRole.Rules).To(ContainElement(rbacv1.PolicyRule{
+			APIGroups: []string{""},
+			Resources: []string{"pods/portforward"},
+			Verbs:     []string{"create"},
+		}))
+	})
+
+	It("Should remove members from their role", func() {
+		ctx := context.Background()
+		workspace := newTestWorkspace("test-members-removed")
+		workspace.Spec.Members = []mlopsv1alpha1.WorkspaceMember{
+			{Kind: mlopsv1alpha1.WorkspaceMemberKindUser, Name: "jane@example.com", Role: mlopsv1alpha1.WorkspaceRoleViewer},
+		}
+
+		Expect(k8sClient.Create(ctx, workspace)).To(Succeed())
+
+		getWorkspaceRoleBinding(ctx, workspace, "viewer")
+
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.Members = nil
+		})
+
+		Eventually(func() error {
+			roleBinding := getWorkspaceRoleBinding(ctx, workspace, "viewer")
+
+			if len(roleBinding.Subjects) > 0 {
+				return fmt.Errorf("expected the viewer role to have no members, got %v", roleBinding.Subjects)
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+	})
+})
+
+func getWorkspaceRoleBinding(ctx context.Context, workspace *mlopsv1alpha1.Workspace, role string) *rbacv1.RoleBinding {
+	roleBinding := &rbacv1.RoleBinding{}
+	roleBindingName := types.NamespacedName{Name: fmt.Sprintf("%s-%s", workspace.GetName(), role), Namespace: workspace.GetNamespace()}
+
+	Eventually(func() error {
+		return k8sClient.Get(ctx, roleBindingName, roleBinding)
+	}, time.Minute, time.Second).Should(Succeed())
+
+	return roleBinding
+}
@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+)
+
+// experimentTracker records training jobs as runs in the MLflow server of a workspace.
+type experimentTracker interface {
+	// FindRun returns the run in the experiment that has all the tags, so a run is only created once for a training job.
+	FindRun(ctx context.Context, workspace *mlopsv1alpha1.Workspace, experimentName string, tags map[string]string) (experimentRun, bool, error)
+
+	// CreateRun starts a run in the experiment, creating the experiment when it doesn't exist yet.
+	CreateRun(ctx context.Context, workspace *mlopsv1alpha1.Workspace, experimentName string, runName string, tags map[string]string) (experimentRun, error)
+
+	// EndRun marks the run as finished with one of the MLflow run statuses FINISHED, FAILED, or KILLED.
+	EndRun(ctx context.Context, workspace *mlopsv1alpha1.Workspace, runID string, status string, endTime time.Time) error
+}
+
+// modelRegistry looks up the versions of registered models in the MLflow server of a workspace.
+type modelRegistry interface {
+	// GetLatestModelVersion returns the latest version of the model in the stage, or an empty string when the stage has no versions.
+	GetLatestModelVersion(ctx context.Context, workspace *mlopsv1alpha1.Workspace, modelName string, stage string) (string, error)
+
+	// GetServerVersion returns the version of MLflow the server runs, so clients can install the same version.
+	GetServerVersion(ctx context.Context, workspace *mlopsv1alpha1.Workspace) (string, error)
+}
+
+// experimentRun identifies a run in MLflow.
+type experimentRun struct {
+	experimentID string
+	runID        string
+}
+
+// mlflowClient talks to the REST API of the MLflow server in a workspace.
+type mlflowClient struct {
+	httpClient *http.Client
+}
+
+func newMLflowClient() *mlflowClient {
+	return &mlflowClient{httpClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// mlflowError is the body MLflow returns for failed requests.
+type mlflowError struct {
+	ErrorCode string `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+type mlflowTag struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func (t *mlflowClient) FindRun(ctx context.Context, workspace *mlopsv1alpha1.Workspace, experimentName string, tags map[string]string) (experimentRun, bool, error) {
+	experimentID, err := t.getExperiment(ctx, workspace, experimentName)
+
+	if err != nil || experimentID == "" {
+		return experimentRun{}, false, err
+	}
+
+	// The tag keys contain dots and slashes, so they need backticks in the filter.
+	filters := []string{}
+
+	for key, value := range tags {
+		filters = append(filters, fmt.Sprintf("tags.`%s` = '%s'", key, value))
+	}
+
+	sort.Strings(filters)
+
+	request := map[string]interface{}{
+		"experiment_ids": []string{experimentID},
+		"filter":         strings.Join(filters, " and "),
+		"max_results":    1,
+	}
+
+	response := struct {
+		Runs []struct {
+			Info struct {
+				RunID string `json:"run_id"`
+			} `json:"info"`
+		} `json:"runs"`
+	}{}
+
+	if _, err := t.do(ctx, workspace, http.MethodPost, "runs/search", request, &response); err != nil {
+		return experimentRun{}, false, err
+	}
+
+	if len(response.Runs) == 0 {
+		return experimentRun{}, false, nil
+	}
+
+	return experimentRun{experimentID: experimentID, runID: response.Runs[0].Info.RunID}, true, nil
+}
+
+func (t *mlflowClient) CreateRun(ctx context.Context, workspace *mlopsv1alpha1.Workspace, experimentName string, runName string, tags map[string]string) (experimentRun, error) {
+	experimentID, err := t.getOrCreateExperiment(ctx, workspace, experimentName)
+
+	if err != nil {
+		return experimentRun{}, err
+	}
+
+	runTags := []mlflowTag{{Key: "mlflow.runName", Value: runName}}
+
+	for key, value := range tags {
+		runTags = append(runTags, mlflowTag{Key: key, Value: value})
+	}
+
+	request := map[string]interface{}{
+		"experiment_id": experimentID,
+		"run_name":      runName,
+		"start_time":    time.Now().UnixMilli(),
+		"tags":          runTags,
+	}
+
+	response := struct {
+		Run struct {
+			Info struct {
+				RunID string `json:"run_id"`
+			} `json:"info"`
+		} `json:"run"`
+	}{}
+
+	if _, err := t.do(ctx, workspace, http.MethodPost, "runs/create", request, &response); err != nil {
+		return experimentRun{}, err
+	}
+
+	return experimentRun{experimentID: experimentID, runID: response.Run.Info.RunID}, nil
+}
+
+func (t *mlflowClient) EndRun(ctx context.Context, workspace *mlopsv1alpha1.Workspace, runID string, status string, endTime time.Time) error {
+	request := map[string]interface{}{
+		"run_id":   runID,
+		"status":   status,
+		"end_time": endTime.UnixMilli(),
+	}
+
+	_, err := t.do(ctx, workspace, http.MethodPost, "runs/update", request, nil)
+	return err
+}
+
+func (t *mlflowClient) GetLatestModelVersion(ctx context.Context, workspace *mlopsv1alpha1.Workspace, modelName string, stage string) (string, error) {
+	request := map[string]interface{}{
+		"name":   modelName,
+		"stages": []string{stage},
+	}
+
+	response := struct {
+		ModelVersions []struct {
+			Version string `json:"version"`
+		} `json:"model_versions"`
+	}{}
+
+	if _, err := t.do(ctx, workspace, http.MethodPost, "registered-models/get-latest-versions", request, &response); err != nil {
+		return "", err
+	}
+
+	if len(response.ModelVersions) == 0 {
+		return "", nil
+	}
+
+	return response.ModelVersions[0].Version, nil
+}
+
+func (t *mlflowClient) GetServerVersion(ctx context.Context, workspace *mlopsv1alpha1.Workspace) (string, error) {
+	// MLflow serves the version with its user interface, under the prefix of the interface.
+	basePath := newExposedBasePath(workspace, getExposedInterface(workspace, "mlflow"))
+	requestURL := fmt.Sprintf("%s%s/version", newExperimentTrackingServiceURL(workspace), basePath)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	response, err := t.httpClient.Do(request)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return "", fmt.Errorf("MLflow request version failed with status %d", response.StatusCode)
+	}
+
+	version, err := io.ReadAll(response.Body)
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(version)), nil
+}
+
+// getExperiment returns the ID of the experiment, or an empty string when the experiment doesn't exist.
+func (t *mlflowClient) getExperiment(ctx context.Context, workspace *mlopsv1alpha1.Workspace, experimentName string) (string, error) {
+	existing := struct {
+		Experiment struct {
+			ExperimentID string `json:"experiment_id"`
+		} `json:"experiment"`
+	}{}
+
+	path := fmt.Sprintf("experiments/get-by-name?experiment_name=%s", url.QueryEscape(experimentName))
+	errorCode, err := t.do(ctx, workspace, http.MethodGet, path, nil, &existing)
+
+	if err == nil {
+		return existing.Experiment.ExperimentID, nil
+	}
+
+	if errorCode == "RESOURCE_DOES_NOT_EXIST" {
+		return "", nil
+	}
+
+	return "", err
+}
+
+func (t *mlflowClient) getOrCreateExperiment(ctx context.Context, workspace *mlopsv1alpha1.Workspace, experimentName string) (string, error) {
+	experimentID, err := t.getExperiment(ctx, workspace, experimentName)
+
+	if err != nil || experimentID != "" {
+		return experimentID, err
+	}
+
+	created := struct {
+		ExperimentID string `json:"experiment_id"`
+	}{}
+
+	if _, err := t.do(ctx, workspace, http.MethodPost, "experiments/create", map[string]string{"name": experimentName}, &created); err != nil {
+		return "", err
+	}
+
+	return created.ExperimentID, nil
+}
+
+// do sends a request to the MLflow API. It returns the MLflow error code when the request fails.
+func (t *mlflowClient) do(ctx context.Context, workspace *mlopsv1alpha1.Workspace, method string, path string, body interface{}, result interface{}) (string, error) {
+	var requestBody bytes.Buffer
+
+	if body != nil {
+		if err := json.NewEncoder(&requestBody).Encode(body); err != nil {
+			return "", err
+		}
+	}
+
+	requestURL := fmt.Sprintf("%s/api/2.0/mlflow/%s", newExperimentTrackingServiceURL(workspace), path)
+	request, err := http.NewRequestWithContext(ctx, method, requestURL, &requestBody)
+
+	if err != nil {
+		return "", err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := t.httpClient.Do(request)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		errorBody := mlflowError{}
+		_ = json.NewDecoder(response.Body).Decode(&errorBody)
+
+		return errorBody.ErrorCode, fmt.Errorf("MLflow request %s failed with status %d: %s", path, response.StatusCode, errorBody.Message)
+	}
+
+	if result == nil {
+		return "", nil
+	}
+
+	return "", json.NewDecoder(response.Body).Decode(result)
+}
+
+// newExperimentTrackingServiceURL returns the address of the MLflow server for clients outside the namespace of the workspace,
+// like the operator itself.
+func newExperimentTrackingServiceURL(workspace *mlopsv1alpha1.Workspace) string {
+	return fmt.Sprintf("http://%s-mlflow-server.%s.svc:5000", workspace.GetName(), workspace.GetNamespace())
+}
@@ -0,0 +1,463 @@
+/*
+Copyright 2023 Willem Meints.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	ray "github.com/ray-project/kuberay/ray-operator/apis/ray/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+)
+
+const (
+	// modelDeploymentWorkspaceIndexKey indexes the model deployments by the name of their workspace.
+	modelDeploymentWorkspaceIndexKey = ".spec.workspace"
+
+	// modelServerDirectory is where the ray serve application that loads the model is mounted.
+	modelServerDirectory = "/opt/model-server"
+
+	// modelServerDeploymentName is the name of the ray serve deployment in the model server application.
+	modelServerDeploymentName = "ModelServer"
+
+	// modelVersionPollInterval is how often the controller checks MLflow for a new version of a model in a stage.
+	modelVersionPollInterval = time.Minute
+)
+
+// modelServerSource is the ray serve application that serves a model from MLflow.
+//
+//go:embed modelserver/model_server.py
+var modelServerSource string
+
+// ModelDeploymentReconciler reconciles a ModelDeployment object
+type ModelDeploymentReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	models       modelRegistry
+	pollInterval time.Duration
+}
+
+//+kubebuilder:rbac:groups=mlops.aigency.com,resources=modeldeployments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=mlops.aigency.com,resources=modeldeployments/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=mlops.aigency.com,resources=modeldeployments/finalizers,verbs=update
+//+kubebuilder:rbac:groups=ray.io,resources=rayservices,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile looks up the version of the model to serve in MLflow and serves it with a RayService.
+// The version is part of the cluster spec, so KubeRay prepares a second cluster for a new version and only switches
+// the traffic over when it serves the model. The previous version keeps serving requests in the meantime.
+func (r *ModelDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("modelDeployment", req.NamespacedName, "namespace", req.Namespace)
+
+	modelDeployment := &mlopsv1alpha1.ModelDeployment{}
+
+	if err := r.Get(ctx, req.NamespacedName, modelDeployment); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("Model deployment not found. Skipping reconciliation.", "modelDeploymentName", req.Name, "namespace", req.Namespace)
+			return ctrl.Result{}, nil
+		}
+
+		logger.Error(err, "Failed to get the model deployment")
+		return ctrl.Result{}, err
+	}
+
+	modelDeployment.Default()
+
+	originalStatus := modelDeployment.Status.DeepCopy()
+	modelDeployment.Status.ObservedGeneration = modelDeployment.GetGeneration()
+
+	if modelDeployment.Status.Phase == "" {
+		modelDeployment.Status.Phase = mlopsv1alpha1.ModelDeploymentPhasePending
+	}
+
+	workspace := &mlopsv1alpha1.Workspace{}
+	workspaceName := types.NamespacedName{Name: modelDeployment.Spec.Workspace, Namespace: modelDeployment.GetNamespace()}
+
+	if err := r.Get(ctx, workspaceName, workspace); err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "Failed to get the workspace of the model deployment")
+			return ctrl.Result{}, err
+		}
+
+		// The workspace watch picks the deployment up again when the workspace is created.
+		modelDeployment.Status.Message = fmt.Sprintf("Workspace %s does not exist", modelDeployment.Spec.Workspace)
+		return ctrl.Result{}, r.updateModelDeploymentStatus(ctx, modelDeployment, originalStatus)
+	}
+
+	if !meta.IsStatusConditionTrue(workspace.Status.Conditions, mlopsv1alpha1.ConditionTypeExperimentTrackingReady) {
+		modelDeployment.Status.Message = fmt.Sprintf("Waiting for the experiment tracking in workspace %s to become ready", workspace.GetName())
+		return ctrl.Result{}, r.updateModelDeploymentStatus(ctx, modelDeployment, originalStatus)
+	}
+
+	workerPools, ok := getModelWorkerPools(modelDeployment, workspace)
+
+	if !ok {
+		modelDeployment.Status.Message = fmt.Sprintf("Worker pool %s does not exist in workspace %s", modelDeployment.Spec.WorkerPool, workspace.GetName())
+		return ctrl.Result{}, r.updateModelDeploymentStatus(ctx, modelDeployment, originalStatus)
+	}
+
+	modelVersion, err := r.resolveModelVersion(ctx, modelDeployment, workspace)
+
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if modelVersion == "" {
+		modelDeployment.Status.Message = fmt.Sprintf("Model %s has no version in stage %s", modelDeployment.Spec.ModelName, modelDeployment.Spec.Stage)
+		return r.newModelDeploymentResult(modelDeployment), r.updateModelDeploymentStatus(ctx, modelDeployment, originalStatus)
+	}
+
+	rayService, err := r.applyModelServer(ctx, modelDeployment, workspace, workerPools, modelVersion)
+
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if modelVersion != originalStatus.ModelVersion {
+		r.Recorder.Eventf(modelDeployment, corev1.EventTypeNormal, "Deploying",
+			"Deploying version %s of model %s", modelVersion, modelDeployment.Spec.ModelName)
+	}
+
+	modelDeployment.Status.ModelVersion = modelVersion
+	modelDeployment.Status.URL = newModelDeploymentURL(modelDeployment)
+
+	setModelDeploymentStatus(modelDeployment, rayService)
+
+	return r.newModelDeploymentResult(modelDeployment), r.updateModelDeploymentStatus(ctx, modelDeployment, originalStatus)
+}
+
+// resolveModelVersion returns the version of the model to serve. When the stage of the model no longer has a version,
+// the deployment keeps serving the version it already serves.
+func (r *ModelDeploymentReconciler) resolveModelVersion(ctx context.Context, modelDeployment *mlopsv1alpha1.ModelDeployment, workspace *mlopsv1alpha1.Workspace) (string, error) {
+	logger := log.FromContext(ctx).WithValues(
+		"modelDeployment", modelDeployment.GetName(),
+		"namespace", modelDeployment.GetNamespace())
+
+	if modelDeployment.Spec.Version != "" {
+		return modelDeployment.Spec.Version, nil
+	}
+
+	modelVersion, err := r.models.GetLatestModelVersion(ctx, workspace, modelDeployment.Spec.ModelName, string(modelDeployment.Spec.Stage))
+
+	if err != nil {
+		logger.Error(err, "Failed to get the latest version of the model", "modelName", modelDeployment.Spec.ModelName)
+		return "", err
+	}
+
+	if modelVersion == "" {
+		return modelDeployment.Status.ModelVersion, nil
+	}
+
+	return modelVersion, nil
+}
+
+// applyModelServer applies the config map with the ray serve application and the RayService that runs it.
+func (r *ModelDeploymentReconciler) applyModelServer(ctx context.Context, modelDeployment *mlopsv1alpha1.ModelDeployment, workspace *mlopsv1alpha1.Workspace, workerPools []mlopsv1alpha1.ComputeWorkerPoolSpec, modelVersion string) (*ray.RayService, error) {
+	logger := log.FromContext(ctx).WithValues(
+		"modelDeployment", modelDeployment.GetName(),
+		"namespace", modelDeployment.GetNamespace())
+
+	configMap := newModelServerConfigMap(modelDeployment)
+
+	if err := applyOwnedResource(ctx, r.Client, r.Scheme, modelDeployment, configMap); err != nil {
+		logger.Error(err, "Failed to apply the config map with the model server")
+		return nil, err
+	}
+
+	// The model is loaded with the MLflow version that logged it, so it doesn't hit incompatible model formats.
+	mlflowVersion, err := r.models.GetServerVersion(ctx, workspace)
+
+	if err != nil {
+		logger.Error(err, "Failed to get the version of the MLflow server")
+		return nil, err
+	}
+
+	rayService, err := newRayService(modelDeployment, workspace, workerPools, modelVersion, mlflowVersion)
+
+	if err != nil {
+		logger.Error(err, "Failed to render the RayService for the model deployment")
+		return nil, err
+	}
+
+	if err := applyOwnedResource(ctx, r.Client, r.Scheme, modelDeployment, rayService); err != nil {
+		logger.Error(err, "Failed to apply the RayService for the model deployment")
+		return nil, err
+	}
+
+	return rayService, nil
+}
+
+func (r *ModelDeploymentReconciler) updateModelDeploymentStatus(ctx context.Context, modelDeployment *mlopsv1alpha1.ModelDeployment, originalStatus *mlopsv1alpha1.ModelDeploymentStatus) error {
+	logger := log.FromContext(ctx).WithValues(
+		"modelDeployment", modelDeployment.GetName(),
+		"namespace", modelDeployment.GetNamespace())
+
+	if reflect.DeepEqual(originalStatus, &modelDeployment.Status) {
+		return nil
+	}
+
+	if err := r.Status().Update(ctx, modelDeployment); err != nil {
+		logger.Error(err, "Failed to update the status of the model deployment")
+		return err
+	}
+
+	return nil
+}
+
+// newModelDeploymentResult polls MLflow for new versions of deployments that follow a stage.
+// MLflow doesn't notify us when a version moves to another stage.
+func (r *ModelDeploymentReconciler) newModelDeploymentResult(modelDeployment *mlopsv1alpha1.ModelDeployment) ctrl.Result {
+	if modelDeployment.Spec.Stage == "" {
+		return ctrl.Result{}
+	}
+
+	return ctrl.Result{RequeueAfter: r.pollInterval}
+}
+
+// setModelDeploymentStatus copies the state of the RayService to the status of the model deployment.
+// While KubeRay prepares a new cluster for a new version, the previous one keeps serving requests.
+func setModelDeploymentStatus(modelDeployment *mlopsv1alpha1.ModelDeployment, rayService *ray.RayService) {
+	activeStatus := rayService.Status.ActiveServiceStatus
+	pendingStatus := rayService.Status.PendingServiceStatus
+
+	switch {
+	case pendingStatus.ApplicationStatus.Status == ray.ApplicationStatusEnum.DEPLOY_FAILED:
+		modelDeployment.Status.Phase = mlopsv1alpha1.ModelDeploymentPhaseFailed
+		modelDeployment.Status.Message = pendingStatus.ApplicationStatus.Message
+	case activeStatus.ApplicationStatus.Status == ray.ApplicationStatusEnum.DEPLOY_FAILED:
+		modelDeployment.Status.Phase = mlopsv1alpha1.ModelDeploymentPhaseFailed
+		modelDeployment.Status.Message = activeStatus.ApplicationStatus.Message
+	case rayService.Status.ServiceStatus == ray.Running && pendingStatus.RayClusterName == "":
+		modelDeployment.Status.Phase = mlopsv1alpha1.ModelDeploymentPhaseAvailable
+		modelDeployment.Status.Message = ""
+	default:
+		modelDeployment.Status.Phase = mlopsv1alpha1.ModelDeploymentPhaseDeploying
+		modelDeployment.Status.Message = fmt.Sprintf("Waiting for RayService %s to serve the model", rayService.GetName())
+	}
+}
+
+// newRayService creates the RayService for a model deployment. The version of the model is passed to the replicas
+// through the environment of the ray pods, so a new version changes the cluster spec.
+func newRayService(modelDeployment *mlopsv1alpha1.ModelDeployment, workspace *mlopsv1alpha1.Workspace, workerPools []mlopsv1alpha1.ComputeWorkerPoolSpec, modelVersion string, mlflowVersion string) (*ray.RayService, error) {
+	runtimeEnvironment, err := json.Marshal(map[string]interface{}{
+		"pip": append([]string{fmt.Sprintf("mlflow==%s", mlflowVersion)}, modelDeployment.Spec.PipPackages...),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	serveConfig := ray.ServeConfigSpec{
+		Name:        modelServerDeploymentName,
+		NumReplicas: modelDeployment.Spec.Replicas,
+	}
+
+	if autoscaling := modelDeployment.Spec.Autoscaling; autoscaling != nil {
+		autoscalingConfig, err := newAutoscalingConfig(autoscaling)
+
+		if err != nil {
+			return nil, err
+		}
+
+		serveConfig.NumReplicas = nil
+		serveConfig.AutoscalingConfig = autoscalingConfig
+	}
+
+	rayClusterSpec := newModelRayClusterSpec(modelDeployment, workspace, workerPools)
+	mountConfigMapDirectory(rayClusterSpec, "model-server", newModelServerConfigMapName(modelDeployment), modelServerDirectory)
+
+	for _, podSpec := range newRayClusterPodSpecs(rayClusterSpec) {
+		container := &podSpec.Containers[0]
+		container.Env = append(container.Env, corev1.EnvVar{Name: "MODEL_URI", Value: newModelURI(modelDeployment, modelVersion)})
+	}
+
+	rayService := &ray.RayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      modelDeployment.GetName(),
+			Namespace: modelDeployment.GetNamespace(),
+			Labels:    newModelDeploymentLabels(modelDeployment),
+		},
+		Spec: ray.RayServiceSpec{
+			ServeDeploymentGraphSpec: ray.ServeDeploymentGraphSpec{
+				ImportPath:       "model_server:deployment",
+				RuntimeEnv:       string(runtimeEnvironment),
+				ServeConfigSpecs: []ray.ServeConfigSpec{serveConfig},
+			},
+			RayClusterSpec: *rayClusterSpec,
+		},
+	}
+
+	return rayService, nil
+}
+
+// newModelRayClusterSpec creates the ray cluster that serves a model. It has the head of the compute cluster in the workspace
+// and only the worker pool of the model deployment. The autoscaler only runs when ray serve scales the replicas of the model.
+func newModelRayClusterSpec(modelDeployment *mlopsv1alpha1.ModelDeployment, workspace *mlopsv1alpha1.Workspace, workerPools []mlopsv1alpha1.ComputeWorkerPoolSpec) *ray.RayClusterSpec {
+	modelWorkspace := workspace.DeepCopy()
+	modelWorkspace.Spec.Compute.WorkerPools = workerPools
+	modelWorkspace.Spec.Compute.Autoscaling.Enabled = modelDeployment.Spec.Autoscaling != nil
+
+	return newStandaloneRayClusterSpec(modelWorkspace)
+}
+
+// getModelWorkerPools returns the worker pool of the workspace that runs the replicas of the model. It returns false
+// when the workspace doesn't have the worker pool, and no worker pools when the workspace has none to default to.
+func getModelWorkerPools(modelDeployment *mlopsv1alpha1.ModelDeployment, workspace *mlopsv1alpha1.Workspace) ([]mlopsv1alpha1.ComputeWorkerPoolSpec, bool) {
+	workerPools := workspace.Spec.Compute.WorkerPools
+
+	if modelDeployment.Spec.WorkerPool == "" {
+		if len(workerPools) == 0 {
+			return []mlopsv1alpha1.ComputeWorkerPoolSpec{}, true
+		}
+
+		return workerPools[:1], true
+	}
+
+	for _, workerPool := range workerPools {
+		if workerPool.Name == modelDeployment.Spec.WorkerPool {
+			return []mlopsv1alpha1.ComputeWorkerPoolSpec{workerPool}, true
+		}
+	}
+
+	return nil, false
+}
+
+// newAutoscalingConfig creates the autoscaling config of the ray serve deployment.
+func newAutoscalingConfig(autoscaling *mlopsv1alpha1.ModelAutoscalingSpec) (string, error) {
+	autoscalingConfig := map[string]interface{}{
+		"min_replicas": autoscaling.MinReplicas,
+		"max_replicas": autoscaling.MaxReplicas,
+	}
+
+	if autoscaling.TargetOngoingRequests != nil {
+		autoscalingConfig["target_num_ongoing_requests_per_replica"] = *autoscaling.TargetOngoingRequests
+	}
+
+	autoscalingConfigJSON, err := json.Marshal(autoscalingConfig)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(autoscalingConfigJSON), nil
+}
+
+func newModelURI(modelDeployment *mlopsv1alpha1.ModelDeployment, modelVersion string) string {
+	return fmt.Sprintf("models:/%s/%s", modelDeployment.Spec.ModelName, modelVersion)
+}
+
+func newModelServerConfigMap(modelDeployment *mlopsv1alpha1.ModelDeployment) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      newModelServerConfigMapName(modelDeployment),
+			Namespace: modelDeployment.GetNamespace(),
+			Labels:    newModelDeploymentLabels(modelDeployment),
+		},
+		Data: map[string]string{
+			"model_server.py": modelServerSource,
+		},
+	}
+}
+
+func newModelServerConfigMapName(modelDeployment *mlopsv1alpha1.ModelDeployment) string {
+	return fmt.Sprintf("%s-model-server", modelDeployment.GetName())
+}
+
+func newModelDeploymentLabels(modelDeployment *mlopsv1alpha1.ModelDeployment) map[string]string {
+	return map[string]string{
+		"mlops.aigency.com/workspace":        modelDeployment.Spec.Workspace,
+		"mlops.aigency.com/model-deployment": modelDeployment.GetName(),
+	}
+}
+
+// newModelDeploymentURL returns the address of the service KubeRay creates for the ray serve application.
+func newModelDeploymentURL(modelDeployment *mlopsv1alpha1.ModelDeployment) string {
+	return fmt.Sprintf("http://%s-serve-svc.%s.svc:8000", modelDeployment.GetName(), modelDeployment.GetNamespace())
+}
+
+// findModelDeploymentsForWorkspace maps a workspace to the model deployments that use it,
+// so deployments waiting for the workspace continue when it becomes ready and follow changes to the compute cluster.
+func (r *ModelDeploymentReconciler) findModelDeploymentsForWorkspace(workspace client.Object) []reconcile.Request {
+	modelDeployments := &mlopsv1alpha1.ModelDeploymentList{}
+
+	listOptions := []client.ListOption{
+		client.InNamespace(workspace.GetNamespace()),
+		client.MatchingFields{modelDeploymentWorkspaceIndexKey: workspace.GetName()},
+	}
+
+	if err := r.List(context.Background(), modelDeployments, listOptions...); err != nil {
+		return []reconcile.Request{}
+	}
+
+	requests := []reconcile.Request{}
+
+	for _, modelDeployment := range modelDeployments.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: modelDeployment.GetName(), Namespace: modelDeployment.GetNamespace()},
+		})
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ModelDeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.models == nil {
+		r.models = newMLflowClient()
+	}
+
+	if r.pollInterval == 0 {
+		r.pollInterval = modelVersionPollInterval
+	}
+
+	indexWorkspace := func(object client.Object) []string {
+		return []string{object.(*mlopsv1alpha1.ModelDeployment).Spec.Workspace}
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &mlopsv1alpha1.ModelDeployment{}, modelDeploymentWorkspaceIndexKey, indexWorkspace); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&mlopsv1alpha1.ModelDeployment{}).
+		Owns(&ray.RayService{}).
+		Owns(&corev1.ConfigMap{}).
+		Watches(
+			&source.Kind{Type: &mlopsv1alpha1.Workspace{}},
+			handler.EnqueueRequestsFromMapFunc(r.findModelDeploymentsForWorkspace),
+		).
+		Complete(r)
+}
@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	ray "github.com/ray-project/kuberay/ray-operator/apis/ray/v1alpha1"
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("ModelDeploymentReconciler", func() {
+	It("Should serve the production version of the model with a RayService", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-model-deployment")
+
+		testMLflowClient.setModelVersion("test-model-deployment-model", "Production", "1")
+
+		modelDeployment := newTestModelDeployment(workspace, "test-model-deployment")
+		modelDeployment.Spec.PipPackages = []string{"scikit-learn"}
+
+		Expect(k8sClient.Create(ctx, modelDeployment)).To(Succeed())
+
+		rayService := getRayService(ctx, modelDeployment)
+
+		Expect(rayService.OwnerReferences).To(HaveLen(1))
+		Expect(rayService.Spec.ServeDeploymentGraphSpec.ImportPath).To(Equal("model_server:deployment"))
+		Expect(rayService.Spec.ServeDeploymentGraphSpec.ServeConfigSpecs).To(HaveLen(1))
+		Expect(*rayService.Spec.ServeDeploymentGraphSpec.ServeConfigSpecs[0].NumReplicas).To(Equal(int32(1)))
+
+		runtimeEnvironment := getRuntimeEnvironment(rayService)
+
+		Expect(runtimeEnvironment).To(HaveKeyWithValue("pip", ConsistOf("mlflow==2.1.1", "scikit-learn")))
+		Expect(runtimeEnvironment).NotTo(HaveKey("env_vars"))
+
+		headContainer := rayService.Spec.RayClusterSpec.HeadGroupSpec.Template.Spec.Containers[0]
+
+		Expect(headContainer.Env).To(ContainElement(corev1.EnvVar{Name: "PYTHONPATH", Value: "/opt/model-server"}))
+		Expect(headContainer.Env).To(ContainElement(corev1.EnvVar{Name: "MODEL_URI", Value: "models:/test-model-deployment-model/1"}))
+
+		Expect(rayService.Spec.RayClusterSpec.WorkerGroupSpecs).To(HaveLen(1))
+		Expect(rayService.Spec.RayClusterSpec.WorkerGroupSpecs[0].GroupName).To(Equal("test"))
+		Expect(*rayService.Spec.RayClusterSpec.EnableInTreeAutoscaling).To(BeFalse())
+
+		configMap := &corev1.ConfigMap{}
+		configMapName := types.NamespacedName{Name: "test-model-deployment-model-server", Namespace: workspace.GetNamespace()}
+
+		Expect(k8sClient.Get(ctx, configMapName, configMap)).To(Succeed())
+		Expect(configMap.Data).To(HaveKeyWithValue("model_server.py", ContainSubstring("class ModelServer")))
+	})
+
+	It("Should roll out a new version when the stage of the model changes", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-model-deployment-rollout")
+
+		testMLflowClient.setModelVersion("test-model-deployment-rollout-model", "Production", "1")
+
+		modelDeployment := newTestModelDeployment(workspace, "test-model-deployment-rollout")
+		Expect(k8sClient.Create(ctx, modelDeployment)).To(Succeed())
+
+		getRayService(ctx, modelDeployment)
+
+		testMLflowClient.setModelVersion("test-model-deployment-rollout-model", "Production", "2")
+
+		// A new version changes the cluster spec, so KubeRay serves it from a second cluster before it switches over.
+		Eventually(func() ([]corev1.EnvVar, error) {
+			rayService := &ray.RayService{}
+
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(modelDeployment), rayService); err != nil {
+				return nil, err
+			}
+
+			return rayService.Spec.RayClusterSpec.WorkerGroupSpecs[0].Template.Spec.Containers[0].Env, nil
+		}, time.Minute, time.Second).Should(ContainElement(corev1.EnvVar{Name: "MODEL_URI", Value: "models:/test-model-deployment-rollout-model/2"}))
+
+		Eventually(func() (string, error) {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(modelDeployment), modelDeployment); err != nil {
+				return "", err
+			}
+
+			return modelDeployment.Status.ModelVersion, nil
+		}, time.Minute, time.Second).Should(Equal("2"))
+	})
+
+	It("Should change the cluster spec for a new version of the model", func() {
+		workspace := newTestWorkspace("test-model-deployment-version")
+		workspace.Default()
+
+		modelDeployment := newTestModelDeployment(workspace, "test-model-deployment-version")
+		modelDeployment.Default()
+
+		workerPools, _ := getModelWorkerPools(modelDeployment, workspace)
+
+		currentRayService, err := newRayService(modelDeployment, workspace, workerPools, "1", "2.1.1")
+		Expect(err).NotTo(HaveOccurred())
+
+		newVersionRayService, err := newRayService(modelDeployment, workspace, workerPools, "2", "2.1.1")
+		Expect(err).NotTo(HaveOccurred())
+
+		// KubeRay only prepares a second cluster and switches over to it when the cluster spec changes.
+		// A change to the serve config alone replaces the replicas on the running cluster.
+		Expect(newVersionRayService.Spec.RayClusterSpec).NotTo(Equal(currentRayService.Spec.RayClusterSpec))
+		Expect(newVersionRayService.Spec.ServeDeploymentGraphSpec).To(Equal(currentRayService.Spec.ServeDeploymentGraphSpec))
+	})
+
+	It("Should report the model as available when the RayService runs", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-model-deployment-status")
+
+		modelDeployment := newTestModelDeployment(workspace, "test-model-deployment-status")
+		modelDeployment.Spec.Stage = ""
+		modelDeployment.Spec.Version = "4"
+		modelDeployment.Spec.Autoscaling = &mlopsv1alpha1.ModelAutoscalingSpec{MinReplicas: 1, MaxReplicas: 3}
+
+		Expect(k8sClient.Create(ctx, modelDeployment)).To(Succeed())
+
+		rayService := getRayService(ctx, modelDeployment)
+
+		Expect(rayService.Spec.ServeDeploymentGraphSpec.ServeConfigSpecs[0].NumReplicas).To(BeNil())
+		Expect(rayService.Spec.ServeDeploymentGraphSpec.ServeConfigSpecs[0].AutoscalingConfig).To(MatchJSON(`{"min_replicas":1,"max_replicas":3}`))
+		Expect(*rayService.Spec.RayClusterSpec.EnableInTreeAutoscaling).To(BeTrue())
+
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(rayService), rayService); err != nil {
+				return err
+			}
+
+			rayService.Status.ServiceStatus = ray.Running
+			rayService.Status.ActiveServiceStatus.RayClusterName = "test-model-deployment-status-raycluster-abc"
+			rayService.Status.ActiveServiceStatus.ApplicationStatus = ray.AppStatus{
+				Status:         ray.ApplicationStatusEnum.RUNNING,
+				LastUpdateTime: &metav1.Time{Time: time.Now()},
+			}
+
+			return k8sClient.Status().Update(ctx, rayService)
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() (mlopsv1alpha1.ModelDeploymentPhase, error) {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(modelDeployment), modelDeployment); err != nil {
+				return "", err
+			}
+
+			return modelDeployment.Status.Phase, nil
+		}, time.Minute, time.Second).Should(Equal(mlopsv1alpha1.ModelDeploymentPhaseAvailable))
+
+		Expect(modelDeployment.Status.ModelVersion).To(Equal("4"))
+		Expect(modelDeployment.Status.URL).To(Equal("http://test-model-deployment-status-serve-svc.default.svc:8000"))
+	})
+
+	It("Should wait for the worker pool in the workspace", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-model-deployment-pool")
+
+		testMLflowClient.setModelVersion("test-model-deployment-pool-model", "Production", "1")
+
+		modelDeployment := newTestModelDeployment(workspace, "test-model-deployment-pool")
+		modelDeployment.Spec.WorkerPool = "serving"
+
+		Expect(k8sClient.Create(ctx, modelDeployment)).To(Succeed())
+
+		Eventually(func() (string, error) {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(modelDeployment), modelDeployment); err != nil {
+				return "", err
+			}
+
+			return modelDeployment.Status.Message, nil
+		}, time.Minute, time.Second).Should(Equal("Worker pool serving does not exist in workspace test-model-deployment-pool"))
+
+		rayService := &ray.RayService{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(modelDeployment), rayService)).NotTo(Succeed())
+	})
+
+	It("Should wait for a version in the stage", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-model-deployment-unregistered")
+
+		modelDeployment := newTestModelDeployment(workspace, "test-model-deployment-unregistered")
+		Expect(k8sClient.Create(ctx, modelDeployment)).To(Succeed())
+
+		Eventually(func() (string, error) {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(modelDeployment), modelDeployment); err != nil {
+				return "", err
+			}
+
+			return modelDeployment.Status.Message, nil
+		}, time.Minute, time.Second).Should(Equal("Model test-model-deployment-unregistered-model has no version in stage Production"))
+
+		Expect(modelDeployment.Status.Phase).To(Equal(mlopsv1alpha1.ModelDeploymentPhasePending))
+	})
+})
+
+func newTestModelDeployment(workspace *mlopsv1alpha1.Workspace, name string) *mlopsv1alpha1.ModelDeployment {
+	return &mlopsv1alpha1.ModelDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: workspace.GetNamespace(),
+		},
+		Spec: mlopsv1alpha1.ModelDeploymentSpec{
+			Workspace: workspace.GetName(),
+			ModelName: name + "-model",
+			Stage:     mlopsv1alpha1.ModelStageProduction,
+		},
+	}
+}
+
+func getRayService(ctx context.Context, modelDeployment *mlopsv1alpha1.ModelDeployment) *ray.RayService {
+	rayService := &ray.RayService{}
+
+	Eventually(func() error {
+		return k8sClient.Get(ctx, client.ObjectKeyFromObject(modelDeployment), rayService)
+	}, time.Minute, time.Second).Should(Succeed())
+
+	return rayService
+}
+
+func getRuntimeEnvironment(rayService *ray.RayService) map[string]interface{} {
+	runtimeEnvironment := map[string]interface{}{}
+	Expect(json.Unmarshal([]byte(rayService.Spec.ServeDeploymentGraphSpec.RuntimeEnv), &runtimeEnvironment)).To(Succeed())
+
+	return runtimeEnvironment
+}
@@ -0,0 +1,48 @@
+"""Ray Serve application that serves a registered MLflow model.
+
+The operator sets MODEL_URI to the version of the model to serve, and MLFLOW_TRACKING_URI to the MLflow server
+of the workspace. The replicas install the requirements MLflow logged with the model before they load it.
+Requests use the input formats of the MLflow scoring server: dataframe_split, dataframe_records, instances,
+or inputs.
+"""
+import os
+import subprocess
+import sys
+
+import mlflow.pyfunc
+import pandas as pd
+from ray import serve
+from starlette.requests import Request
+
+
+@serve.deployment
+class ModelServer:
+    def __init__(self):
+        self.model_uri = os.environ["MODEL_URI"]
+
+        requirements = mlflow.pyfunc.get_model_dependencies(self.model_uri)
+        subprocess.check_call([sys.executable, "-m", "pip", "install", "--quiet", "-r", requirements])
+
+        self.model = mlflow.pyfunc.load_model(self.model_uri)
+
+    async def __call__(self, request: Request):
+        payload = await request.json()
+
+        if "dataframe_split" in payload:
+            data = pd.DataFrame(**payload["dataframe_split"])
+        elif "dataframe_records" in payload:
+            data = pd.DataFrame(payload["dataframe_records"])
+        else:
+            data = payload.get("instances", payload.get("inputs"))
+
+        predictions = self.model.predict(data)
+
+        if isinstance(predictions, pd.DataFrame):
+            predictions = predictions.to_dict(orient="records")
+        elif hasattr(predictions, "tolist"):
+            predictions = predictions.tolist()
+
+        return {"model_uri": self.model_uri, "predictions": predictions}
+
+
+deployment = ModelServer.bind()
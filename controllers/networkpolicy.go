@@ -0,0 +1,378 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/strings/slices"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const networkPolicyComponentName = "network-policy"
+
+// networkPolicyComponent isolates the workspace by only allowing traffic along the edges of the component graph.
+// Network policies only restrict incoming traffic, so the components can still reach services outside the workspace.
+type networkPolicyComponent struct {
+	componentBase
+}
+
+func (c *networkPolicyComponent) Name() string {
+	return networkPolicyComponentName
+}
+
+func (c *networkPolicyComponent) ConditionType() string {
+	return ""
+}
+
+func (c *networkPolicyComponent) OwnedTypes() []client.Object {
+	return []client.Object{&networkingv1.NetworkPolicy{}}
+}
+
+func (c *networkPolicyComponent) Render(workspace *mlopsv1alpha1.Workspace) ([]client.Object, error) {
+	resources := []client.Object{}
+
+	if !workspace.Spec.NetworkPolicy.Enabled {
+		return resources, nil
+	}
+
+	resources = append(resources,
+		newDatabaseNetworkPolicy(workspace),
+		newComputeNetworkPolicy(workspace),
+		newObjectStorageNetworkPolicy(workspace))
+
+	if isRedisDeployed(workspace) {
+		resources = append(resources, newRedisNetworkPolicy(workspace))
+	}
+
+	for _, exposed := range newExposedInterfaces(workspace) {
+		// The dashboard of the compute cluster is part of the policy for the ray head.
+		if exposed.name == "ray" {
+			continue
+		}
+
+		resources = append(resources, newUserInterfaceNetworkPolicy(workspace, exposed))
+	}
+
+	if workspace.Spec.Authentication != nil {
+		for _, exposed := range newExposedInterfaces(workspace) {
+			resources = append(resources, newAuthenticationProxyNetworkPolicy(workspace, exposed))
+		}
+	}
+
+	return resources, nil
+}
+
+func (c *networkPolicyComponent) Ready(ctx context.Context, workspace *mlopsv1alpha1.Workspace) (componentCondition, error) {
+	if !workspace.Spec.NetworkPolicy.Enabled {
+		return componentCondition{ready: true, reason: reasonDisabled, message: "Network policies are disabled"}, nil
+	}
+
+	return componentCondition{ready: true, reason: reasonAvailable, message: "The network policies are applied"}, nil
+}
+
+// Cleanup removes the policies that are no longer rendered, for example after disabling network policies or authentication.
+func (c *networkPolicyComponent) Cleanup(ctx context.Context, workspace *mlopsv1alpha1.Workspace) error {
+	logger := log.FromContext(ctx).WithValues(
+		"workspace", workspace.GetName(),
+		"namespace", workspace.GetNamespace())
+
+	resources, err := c.Render(workspace)
+
+	if err != nil {
+		return err
+	}
+
+	renderedNames := []string{}
+
+	for _, resource := range resources {
+		renderedNames = append(renderedNames, resource.GetName())
+	}
+
+	for _, policyName := range newNetworkPolicyNames(workspace) {
+		if slices.Contains(renderedNames, policyName) {
+			continue
+		}
+
+		networkPolicy := &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: policyName, Namespace: workspace.GetNamespace()},
+		}
+
+		if err := c.reconciler.deleteOwnedResource(ctx, workspace, networkPolicy); err != nil {
+			logger.Error(err, "Failed to remove network policy", "resource", policyName)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newNetworkPolicyName(workspace *mlopsv1alpha1.Workspace, target string) string {
+	return fmt.Sprintf("%s-%s", workspace.GetName(), target)
+}
+
+// newNetworkPolicyNames returns the names of all policies the component can render for the workspace.
+func newNetworkPolicyNames(workspace *mlopsv1alpha1.Workspace) []string {
+	policyNames := []string{
+		newNetworkPolicyName(workspace, "database"),
+		newNetworkPolicyName(workspace, "compute"),
+		newNetworkPolicyName(workspace, "object-storage"),
+		newNetworkPolicyName(workspace, "redis"),
+	}
+
+	for _, exposed := range newExposedInterfaces(workspace) {
+		policyNames = append(policyNames,
+			newNetworkPolicyName(workspace, exposed.name),
+			newAuthenticationProxyName(workspace, exposed))
+	}
+
+	return policyNames
+}
+
+func newNetworkPolicy(workspace *mlopsv1alpha1.Workspace, policyName string, podSelector metav1.LabelSelector, rules []networkingv1.NetworkPolicyIngressRule) *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      policyName,
+			Namespace: workspace.GetNamespace(),
+			Labels:    newComponentLabels(workspace, networkPolicyComponentName),
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: podSelector,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     rules,
+		},
+	}
+}
+
+func newComponentPeer(workspace *mlopsv1alpha1.Workspace, componentName string) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		PodSelector: &metav1.LabelSelector{MatchLabels: newComponentLabels(workspace, componentName)},
+	}
+}
+
+// newRayPeer selects the pods of the compute cluster. KubeRay pods carry their own workspace label.
+func newRayPeer(workspace *mlopsv1alpha1.Workspace) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		PodSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"mlops.aigency.com/workspace": workspace.GetName()},
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{
+					Key:      "mlops.aigency.com/component",
+					Operator: metav1.LabelSelectorOpIn,
+					Values:   []string{"ray-controller", "ray-worker"},
+				},
+			},
+		},
+	}
+}
+
+// newWorkspacePeers selects every pod that belongs to the workspace, including the pods of the compute cluster.
+func newWorkspacePeers(workspace *mlopsv1alpha1.Workspace) []networkingv1.NetworkPolicyPeer {
+	return []networkingv1.NetworkPolicyPeer{
+		{
+			PodSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"mlops.aigency.com/environment": workspace.GetName()},
+			},
+		},
+		newRayPeer(workspace),
+	}
+}
+
+// newIngressPeers selects the ingress controller or gateway that sends requests from outside the cluster.
+func newIngressPeers(workspace *mlopsv1alpha1.Workspace) []networkingv1.NetworkPolicyPeer {
+	if workspace.Spec.NetworkPolicy.IngressNamespaceSelector == nil {
+		return []networkingv1.NetworkPolicyPeer{}
+	}
+
+	return []networkingv1.NetworkPolicyPeer{
+		{NamespaceSelector: workspace.Spec.NetworkPolicy.IngressNamespaceSelector},
+	}
+}
+
+// newKubeRayOperatorPeer selects the KubeRay operator, which submits jobs and deploys served models on the ray head.
+// Pod labels can be set by anyone who can create pods, so the peer only matches pods in the namespace of the operator.
+func newKubeRayOperatorPeer(workspace *mlopsv1alpha1.Workspace) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: workspace.Spec.NetworkPolicy.KubeRayNamespaceSelector,
+		PodSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"app.kubernetes.io/component": "kuberay-operator"},
+		},
+	}
+}
+
+// newOperatorPeer selects this operator in its own namespace, which records training jobs in MLflow.
+func newOperatorPeer(workspace *mlopsv1alpha1.Workspace) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: workspace.Spec.NetworkPolicy.OperatorNamespaceSelector,
+		PodSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"control-plane":             "controller-manager",
+				"app.kubernetes.io/part-of": "cartographer",
+			},
+		},
+	}
+}
+
+func newTCPPorts(ports ...int) []networkingv1.NetworkPolicyPort {
+	policyPorts := []networkingv1.NetworkPolicyPort{}
+
+	for _, port := range ports {
+		protocol := corev1.ProtocolTCP
+		portNumber := intstr.FromInt(port)
+
+		policyPorts = append(policyPorts, networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &portNumber})
+	}
+
+	return policyPorts
+}
+
+// newDatabaseNetworkPolicy only lets the components with a database user reach the database.
+// The pods of the postgres cluster can reach each other for replication and backups.
+func newDatabaseNetworkPolicy(workspace *mlopsv1alpha1.Workspace) *networkingv1.NetworkPolicy {
+	clusterPeer := networkingv1.NetworkPolicyPeer{
+		PodSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"postgres-operator.crunchydata.com/cluster": workspace.GetName()},
+		},
+	}
+
+	rules := []networkingv1.NetworkPolicyIngressRule{
+		{
+			From: []networkingv1.NetworkPolicyPeer{clusterPeer},
+		},
+		{
+			From: []networkingv1.NetworkPolicyPeer{
+				newComponentPeer(workspace, "experiment-tracking"),
+				newComponentPeer(workspace, "workflow-server"),
+			},
+			Ports: newTCPPorts(5432),
+		},
+	}
+
+	return newNetworkPolicy(workspace, newNetworkPolicyName(workspace, "database"), *clusterPeer.PodSelector, rules)
+}
+
+// newComputeNetworkPolicy only lets the ray cluster itself and the workflow agents reach the GCS and client ports.
+// KubeRay submits jobs through the dashboard and deploys served models through the dashboard agent,
+// which accepts requests from outside the cluster as well when authentication is disabled.
+// Served models accept requests from the workspace and the allowed peers.
+func newComputeNetworkPolicy(workspace *mlopsv1alpha1.Workspace) *networkingv1.NetworkPolicy {
+	rayPeer := newRayPeer(workspace)
+
+	rules := []networkingv1.NetworkPolicyIngressRule{
+		{
+			From: []networkingv1.NetworkPolicyPeer{rayPeer, newComponentPeer(workspace, "workflow-agent")},
+		},
+		{
+			From:  []networkingv1.NetworkPolicyPeer{newKubeRayOperatorPeer(workspace)},
+			Ports: newTCPPorts(8265, 52365),
+		},
+		{
+			From:  append(newWorkspacePeers(workspace), workspace.Spec.NetworkPolicy.AllowedPeers...),
+			Ports: newTCPPorts(8000),
+		},
+	}
+
+	for _, exposed := range newExposedInterfaces(workspace) {
+		if exposed.name == "ray" {
+			rules = append(rules, newUserInterfaceIngressRules(workspace, exposed)...)
+		}
+	}
+
+	return newNetworkPolicy(workspace, newNetworkPolicyName(workspace, "compute"), *rayPeer.PodSelector, rules)
+}
+
+func newObjectStorageNetworkPolicy(workspace *mlopsv1alpha1.Workspace) *networkingv1.NetworkPolicy {
+	peers := append(newWorkspacePeers(workspace), workspace.Spec.NetworkPolicy.AllowedPeers...)
+
+	rules := []networkingv1.NetworkPolicyIngressRule{
+		{
+			From:  peers,
+			Ports: newTCPPorts(9000),
+		},
+	}
+
+	podSelector := metav1.LabelSelector{MatchLabels: newComponentLabels(workspace, objectStorageComponentName)}
+
+	return newNetworkPolicy(workspace, newNetworkPolicyName(workspace, "object-storage"), podSelector, rules)
+}
+
+// newRedisNetworkPolicy only lets the ray head reach Redis, since the head is the only pod that stores its state there.
+func newRedisNetworkPolicy(workspace *mlopsv1alpha1.Workspace) *networkingv1.NetworkPolicy {
+	rayHeadPeer := networkingv1.NetworkPolicyPeer{
+		PodSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"mlops.aigency.com/workspace": workspace.GetName(),
+				"mlops.aigency.com/component": "ray-controller",
+			},
+		},
+	}
+
+	rules := []networkingv1.NetworkPolicyIngressRule{
+		{
+			From:  []networkingv1.NetworkPolicyPeer{rayHeadPeer},
+			Ports: newTCPPorts(redisPort),
+		},
+	}
+
+	podSelector := metav1.LabelSelector{MatchLabels: newComponentLabels(workspace, faultToleranceComponentName)}
+
+	return newNetworkPolicy(workspace, newNetworkPolicyName(workspace, "redis"), podSelector, rules)
+}
+
+// newUserInterfaceIngressRules lets the workspace and the allowed peers use a user interface. Requests from outside
+// the cluster come in through the authentication proxy when authentication is enabled, or straight from the ingress namespace.
+func newUserInterfaceIngressRules(workspace *mlopsv1alpha1.Workspace, exposed exposedInterface) []networkingv1.NetworkPolicyIngressRule {
+	peers := append(newWorkspacePeers(workspace), workspace.Spec.NetworkPolicy.AllowedPeers...)
+
+	if workspace.Spec.Authentication == nil {
+		peers = append(peers, newIngressPeers(workspace)...)
+	}
+
+	return []networkingv1.NetworkPolicyIngressRule{
+		{
+			From:  peers,
+			Ports: newTCPPorts(int(exposed.port)),
+		},
+	}
+}
+
+func newUserInterfaceNetworkPolicy(workspace *mlopsv1alpha1.Workspace, exposed exposedInterface) *networkingv1.NetworkPolicy {
+	componentNames := map[string]string{
+		"mlflow":  "experiment-tracking",
+		"prefect": "workflow-server",
+	}
+
+	podSelector := metav1.LabelSelector{MatchLabels: newComponentLabels(workspace, componentNames[exposed.name])}
+	rules := newUserInterfaceIngressRules(workspace, exposed)
+
+	if exposed.name == "mlflow" {
+		rules = append(rules, networkingv1.NetworkPolicyIngressRule{
+			From:  []networkingv1.NetworkPolicyPeer{newOperatorPeer(workspace)},
+			Ports: newTCPPorts(int(exposed.port)),
+		})
+	}
+
+	return newNetworkPolicy(workspace, newNetworkPolicyName(workspace, exposed.name), podSelector, rules)
+}
+
+// newAuthenticationProxyNetworkPolicy only lets the ingress namespace reach an authentication proxy.
+// A rule without peers allows traffic from everywhere, so the policy has no rules at all without an ingress namespace.
+func newAuthenticationProxyNetworkPolicy(workspace *mlopsv1alpha1.Workspace, exposed exposedInterface) *networkingv1.NetworkPolicy {
+	rules := []networkingv1.NetworkPolicyIngressRule{}
+
+	if ingressPeers := newIngressPeers(workspace); len(ingressPeers) > 0 {
+		rules = append(rules, networkingv1.NetworkPolicyIngressRule{
+			From:  ingressPeers,
+			Ports: newTCPPorts(authenticationProxyPort),
+		})
+	}
+
+	podSelector := metav1.LabelSelector{MatchLabels: newComponentLabels(workspace, fmt.Sprintf("%s-auth", exposed.name))}
+
+	return newNetworkPolicy(workspace, newAuthenticationProxyName(workspace, exposed), podSelector, rules)
+}
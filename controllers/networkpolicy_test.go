@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var _ = Describe("networkPolicyComponent", func() {
+	It("Should only let MLFlow and the Prefect server reach the database", func() {
+		ctx := context.Background()
+		workspace := newTestWorkspace("test-network-policy")
+		workspace.Spec.NetworkPolicy.Enabled = true
+
+		Expect(k8sClient.Create(ctx, workspace)).To(Succeed())
+
+		networkPolicy := getNetworkPolicy(ctx, workspace, "test-network-policy-database")
+
+		Expect(networkPolicy.Spec.PodSelector.MatchLabels).To(
+			HaveKeyWithValue("postgres-operator.crunchydata.com/cluster", "test-network-policy"))
+		Expect(networkPolicy.Spec.Ingress).To(HaveLen(2))
+		Expect(networkPolicy.Spec.Ingress[1].Ports[0].Port).To(Equal(&intstr.IntOrString{Type: intstr.Int, IntVal: 5432}))
+		Expect(networkPolicy.Spec.Ingress[1].From).To(ConsistOf(
+			newComponentPeer(workspace, "experiment-tracking"),
+			newComponentPeer(workspace, "workflow-server"),
+		))
+	})
+
+	It("Should only let the operators in their own namespaces reach the workspace", func() {
+		workspace := newTestWorkspace("test-network-policy-operators")
+		workspace.Default()
+
+		for _, peer := range []networkingv1.NetworkPolicyPeer{newKubeRayOperatorPeer(workspace), newOperatorPeer(workspace)} {
+			Expect(peer.NamespaceSelector).NotTo(BeNil())
+			Expect(peer.NamespaceSelector.MatchLabels).NotTo(BeEmpty())
+		}
+
+		Expect(newOperatorPeer(workspace).NamespaceSelector.MatchLabels).To(
+			HaveKeyWithValue("kubernetes.io/metadata.name", "cartographer-system"))
+	})
+
+	It("Should remove the network policies when they are disabled", func() {
+		ctx := context.Background()
+		workspace := newTestWorkspace("test-network-policy-disabled")
+		workspace.Spec.NetworkPolicy.Enabled = true
+
+		Expect(k8sClient.Create(ctx, workspace)).To(Succeed())
+
+		getNetworkPolicy(ctx, workspace, "test-network-policy-disabled-compute")
+
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.NetworkPolicy.Enabled = false
+		})
+
+		Eventually(func() bool {
+			networkPolicy := &networkingv1.NetworkPolicy{}
+			policyName := types.NamespacedName{Name: "test-network-policy-disabled-compute", Namespace: workspace.GetNamespace()}
+
+			return errors.IsNotFound(k8sClient.Get(ctx, policyName, networkPolicy))
+		}, time.Minute, time.Second).Should(BeTrue())
+	})
+})
+
+func getNetworkPolicy(ctx context.Context, workspace *mlopsv1alpha1.Workspace, policyName string) *networkingv1.NetworkPolicy {
+	networkPolicy := &networkingv1.NetworkPolicy{}
+
+	Eventually(func() error {
+		return k8sClient.Get(ctx, types.NamespacedName{Name: policyName, Namespace: workspace.GetNamespace()}, networkPolicy)
+	}, time.Minute, time.Second).Should(Succeed())
+
+	return networkPolicy
+}
@@ -0,0 +1,474 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	objectStorageComponentName = "object-storage"
+
+	// objectStorageClientImage is the MinIO client used to create the buckets.
+	objectStorageClientImage = "minio/mc:RELEASE.2023-03-23T20-03-04Z"
+
+	// experimentTrackingBucket is the bucket MLflow stores its artifacts in when no other artifact storage is configured.
+	experimentTrackingBucket = "mlflow"
+)
+
+// objectStorageBucketsScript creates the buckets in the object storage. The server may still be starting
+// when the job runs, so we keep trying to connect until the server accepts the credentials.
+const objectStorageBucketsScript = `set -e
+until mc alias set workspace "$ENDPOINT_URL" "$MINIO_ROOT_USER" "$MINIO_ROOT_PASSWORD" > /dev/null; do
+  echo "Waiting for the object storage to become available"
+  sleep 5
+done
+for bucket in $BUCKETS; do
+  mc mb --ignore-existing "workspace/$bucket"
+done
+`
+
+// objectStorageComponent manages the MinIO server that provides S3 compatible storage in the workspace.
+type objectStorageComponent struct {
+	componentBase
+}
+
+func (c *objectStorageComponent) Name() string {
+	return objectStorageComponentName
+}
+
+func (c *objectStorageComponent) ConditionType() string {
+	return mlopsv1alpha1.ConditionTypeObjectStorageReady
+}
+
+func (c *objectStorageComponent) OwnedTypes() []client.Object {
+	return []client.Object{
+		&appsv1.StatefulSet{},
+		&corev1.Service{},
+		&corev1.PersistentVolumeClaim{},
+		&corev1.Secret{},
+		&corev1.ServiceAccount{},
+		&batchv1.Job{},
+	}
+}
+
+func (c *objectStorageComponent) Render(workspace *mlopsv1alpha1.Workspace) ([]client.Object, error) {
+	if !workspace.Spec.ObjectStorage.Enabled {
+		return []client.Object{}, nil
+	}
+
+	return []client.Object{
+		newObjectStorageServiceAccount(workspace),
+		newObjectStorageBucketsServiceAccount(workspace),
+		newObjectStorageVolumeClaim(workspace),
+		newObjectStorageStatefulSet(workspace),
+		newObjectStorageService(workspace),
+		newObjectStorageBucketsJob(workspace),
+	}, nil
+}
+
+// Apply creates the credentials before the other resources. The credentials are generated once,
+// so we can't render them together with the other resources.
+func (c *objectStorageComponent) Apply(ctx context.Context, workspace *mlopsv1alpha1.Workspace, resources []client.Object) error {
+	if !workspace.Spec.ObjectStorage.Enabled {
+		return nil
+	}
+
+	if err := c.reconciler.createObjectStorageCredentials(ctx, workspace); err != nil {
+		return err
+	}
+
+	return c.reconciler.applyResources(ctx, workspace, resources)
+}
+
+func (c *objectStorageComponent) Ready(ctx context.Context, workspace *mlopsv1alpha1.Workspace) (componentCondition, error) {
+	if !workspace.Spec.ObjectStorage.Enabled {
+		return componentCondition{ready: true, reason: reasonDisabled, message: "The object storage is disabled"}, nil
+	}
+
+	statefulSet := &appsv1.StatefulSet{}
+	statefulSetName := newObjectStorageName(workspace)
+
+	if err := c.reconciler.Get(ctx, types.NamespacedName{Name: statefulSetName, Namespace: workspace.GetNamespace()}, statefulSet); err != nil {
+		if errors.IsNotFound(err) {
+			return componentCondition{reason: reasonNotFound, message: "The object storage server does not exist yet"}, nil
+		}
+
+		return componentCondition{}, err
+	}
+
+	return newStatefulSetCondition(statefulSet), nil
+}
+
+// Cleanup removes the jobs for earlier sets of buckets. When the object storage is disabled, we remove the server too.
+// The volume and credentials stay, so the data is back when the object storage is enabled again.
+func (c *objectStorageComponent) Cleanup(ctx context.Context, workspace *mlopsv1alpha1.Workspace) error {
+	logger := log.FromContext(ctx).WithValues(
+		"workspace", workspace.GetName(),
+		"namespace", workspace.GetNamespace())
+
+	currentJobName := ""
+
+	if workspace.Spec.ObjectStorage.Enabled {
+		currentJobName = newObjectStorageBucketsJob(workspace).GetName()
+	}
+
+	jobs := &batchv1.JobList{}
+	selector := client.MatchingLabels(newComponentLabels(workspace, "object-storage-buckets"))
+
+	if err := c.reconciler.List(ctx, jobs, client.InNamespace(workspace.GetNamespace()), selector); err != nil {
+		logger.Error(err, "Failed to list the bucket jobs of the object storage")
+		return err
+	}
+
+	obsoleteResources := []client.Object{}
+
+	for index := range jobs.Items {
+		if jobs.Items[index].GetName() != currentJobName {
+			obsoleteResources = append(obsoleteResources, &jobs.Items[index])
+		}
+	}
+
+	if !workspace.Spec.ObjectStorage.Enabled {
+		objectMeta := metav1.ObjectMeta{Name: newObjectStorageName(workspace), Namespace: workspace.GetNamespace()}
+		bucketsObjectMeta := metav1.ObjectMeta{Name: newObjectStorageBucketsName(workspace), Namespace: workspace.GetNamespace()}
+
+		obsoleteResources = append(obsoleteResources,
+			&appsv1.StatefulSet{ObjectMeta: objectMeta},
+			&corev1.Service{ObjectMeta: objectMeta},
+			&corev1.ServiceAccount{ObjectMeta: objectMeta},
+			&corev1.ServiceAccount{ObjectMeta: bucketsObjectMeta})
+	}
+
+	for _, resource := range obsoleteResources {
+		if err := c.reconciler.Delete(ctx, resource, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+			logger.Error(err, "Failed to remove obsolete object storage resource", "resource", resource.GetName())
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createObjectStorageCredentials generates the root credentials for the MinIO server, unless they already exist.
+func (r *WorkspaceReconciler) createObjectStorageCredentials(ctx context.Context, workspace *mlopsv1alpha1.Workspace) error {
+	keyLengths := map[string]int{
+		"MINIO_ROOT_USER":     10,
+		"MINIO_ROOT_PASSWORD": 20,
+	}
+
+	return r.createGeneratedSecret(ctx, workspace, newObjectStorageCredentialsName(workspace), objectStorageComponentName, keyLengths)
+}
+
+// createGeneratedSecret creates a secret with a random value for each key, unless the secret already exists.
+// The values are generated once, so we can't render the secret together with the other resources.
+func (r *WorkspaceReconciler) createGeneratedSecret(ctx context.Context, workspace *mlopsv1alpha1.Workspace, secretName string, componentName string, keyLengths map[string]int) error {
+	logger := log.FromContext(ctx).WithValues(
+		"workspace", workspace.GetName(),
+		"namespace", workspace.GetNamespace())
+
+	secret := &corev1.Secret{}
+
+	err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: workspace.GetNamespace()}, secret)
+
+	if err == nil {
+		return r.adoptRetainedCredentials(ctx, workspace, secret)
+	}
+
+	if !errors.IsNotFound(err) {
+		logger.Error(err, "Failed to get the generated secret", "secret", secretName)
+		return err
+	}
+
+	secretData := map[string]string{}
+
+	for key, length := range keyLengths {
+		value, err := newRandomString(length)
+
+		if err != nil {
+			return err
+		}
+
+		secretData[key] = value
+	}
+
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: workspace.GetNamespace(),
+			Labels:    newComponentLabels(workspace, componentName),
+		},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: secretData,
+	}
+
+	if err := ctrl.SetControllerReference(workspace, secret, r.Scheme); err != nil {
+		return err
+	}
+
+	if err := r.Create(ctx, secret); err != nil {
+		logger.Error(err, "Failed to create the generated secret", "secret", secretName)
+		return err
+	}
+
+	return nil
+}
+
+// adoptRetainedCredentials makes the workspace the controller of credentials kept by an earlier workspace
+// with the same name. We never apply the credentials, so we have to restore the owner reference ourselves.
+func (r *WorkspaceReconciler) adoptRetainedCredentials(ctx context.Context, workspace *mlopsv1alpha1.Workspace, secret *corev1.Secret) error {
+	if secret.GetLabels()[retainedLabel] == "" {
+		return nil
+	}
+
+	patch := client.MergeFrom(secret.DeepCopy())
+
+	if err := ctrl.SetControllerReference(workspace, secret, r.Scheme); err != nil {
+		return err
+	}
+
+	delete(secret.Labels, retainedLabel)
+
+	if err := r.Patch(ctx, secret, patch); err != nil {
+		return err
+	}
+
+	r.Recorder.Eventf(workspace, corev1.EventTypeNormal, "Adopted",
+		"Adopted %s retained by an earlier workspace", secret.GetName())
+
+	return nil
+}
+
+// newObjectStorageCredentialEnvVars creates the environment variables that S3 clients use to authenticate
+// with the object storage in the workspace. There are no variables when the object storage is disabled.
+func newObjectStorageCredentialEnvVars(workspace *mlopsv1alpha1.Workspace) []corev1.EnvVar {
+	if !workspace.Spec.ObjectStorage.Enabled {
+		return []corev1.EnvVar{}
+	}
+
+	secretName := newObjectStorageCredentialsName(workspace)
+
+	return []corev1.EnvVar{
+		newSecretEnvVar("AWS_ACCESS_KEY_ID", secretName, "MINIO_ROOT_USER"),
+		newSecretEnvVar("AWS_SECRET_ACCESS_KEY", secretName, "MINIO_ROOT_PASSWORD"),
+	}
+}
+
+func newObjectStorageName(workspace *mlopsv1alpha1.Workspace) string {
+	return fmt.Sprintf("%s-object-storage", workspace.GetName())
+}
+
+func newObjectStorageBucketsName(workspace *mlopsv1alpha1.Workspace) string {
+	return fmt.Sprintf("%s-object-storage-buckets", workspace.GetName())
+}
+
+func newObjectStorageCredentialsName(workspace *mlopsv1alpha1.Workspace) string {
+	return fmt.Sprintf("%s-object-storage-credentials", workspace.GetName())
+}
+
+// newObjectStorageBuckets returns the buckets to create, including the bucket for the experiment tracking artifacts.
+func newObjectStorageBuckets(workspace *mlopsv1alpha1.Workspace) []string {
+	buckets := []string{experimentTrackingBucket}
+
+	for _, bucket := range workspace.Spec.ObjectStorage.Buckets {
+		if bucket != experimentTrackingBucket {
+			buckets = append(buckets, bucket)
+		}
+	}
+
+	sort.Strings(buckets)
+
+	return buckets
+}
+
+func newObjectStorageVolumeClaim(workspace *mlopsv1alpha1.Workspace) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      newObjectStorageName(workspace),
+			Namespace: workspace.GetNamespace(),
+			Labels:    newComponentLabels(workspace, objectStorageComponentName),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{
+				corev1.ReadWriteOnce,
+			},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: workspace.Spec.Storage.ObjectStorage,
+				},
+			},
+		},
+	}
+}
+
+// newObjectStorageStatefulSet creates a single MinIO server. The server stores its data on a volume
+// that is owned by the workspace, so the deletion policy of the workspace applies to the data too.
+func newObjectStorageStatefulSet(workspace *mlopsv1alpha1.Workspace) *appsv1.StatefulSet {
+	statefulSetName := newObjectStorageName(workspace)
+	statefulSetLabels := newComponentLabels(workspace, objectStorageComponentName)
+	secretName := newObjectStorageCredentialsName(workspace)
+
+	container := newContainer("minio", workspace.Spec.ObjectStorage.Image, workspace.Spec.ObjectStorage.Resources)
+	container.Command = []string{"minio", "server", "/data", "--console-address", ":9001"}
+
+	container.Env = []corev1.EnvVar{
+		newSecretEnvVar("MINIO_ROOT_USER", secretName, "MINIO_ROOT_USER"),
+		newSecretEnvVar("MINIO_ROOT_PASSWORD", secretName, "MINIO_ROOT_PASSWORD"),
+	}
+
+	container.Ports = []corev1.ContainerPort{
+		{
+			Name:          "http-s3",
+			ContainerPort: 9000,
+		},
+		{
+			Name:          "http-console",
+			ContainerPort: 9001,
+		},
+	}
+
+	container.VolumeMounts = []corev1.VolumeMount{
+		{
+			Name:      "data",
+			MountPath: "/data",
+		},
+	}
+
+	statefulSet := newStatefulSet(workspace.GetNamespace(), statefulSetName, statefulSetLabels, pointer.Int32(1), container)
+	statefulSet.Spec.ServiceName = statefulSetName
+	statefulSet.Spec.Template.Spec.ServiceAccountName = statefulSetName
+	applyScheduling(&statefulSet.Spec.Template.Spec, statefulSetLabels, workspace.Spec.ObjectStorage.Scheduling)
+
+	statefulSet.Spec.Template.Spec.Volumes = append(statefulSet.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: "data",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: newObjectStorageName(workspace),
+			},
+		},
+	})
+
+	return statefulSet
+}
+
+func newObjectStorageServiceAccount(workspace *mlopsv1alpha1.Workspace) *corev1.ServiceAccount {
+	return newServiceAccount(
+		workspace.GetNamespace(),
+		newObjectStorageName(workspace),
+		newComponentLabels(workspace, objectStorageComponentName),
+		mlopsv1alpha1.ServiceAccountSpec{},
+	)
+}
+
+func newObjectStorageBucketsServiceAccount(workspace *mlopsv1alpha1.Workspace) *corev1.ServiceAccount {
+	return newServiceAccount(
+		workspace.GetNamespace(),
+		newObjectStorageBucketsName(workspace),
+		newComponentLabels(workspace, objectStorageComponentName),
+		mlopsv1alpha1.ServiceAccountSpec{},
+	)
+}
+
+func newObjectStorageService(workspace *mlopsv1alpha1.Workspace) *corev1.Service {
+	serviceLabels := newComponentLabels(workspace, objectStorageComponentName)
+	service := newService(newObjectStorageName(workspace), workspace.GetNamespace(), serviceLabels)
+
+	service.Spec.Ports = []corev1.ServicePort{
+		{
+			Name:       "http-s3",
+			Protocol:   corev1.ProtocolTCP,
+			Port:       9000,
+			TargetPort: intstr.FromInt(9000),
+		},
+		{
+			Name:       "http-console",
+			Protocol:   corev1.ProtocolTCP,
+			Port:       9001,
+			TargetPort: intstr.FromInt(9001),
+		},
+	}
+
+	return service
+}
+
+// newObjectStorageBucketsJob creates a job that creates the buckets in the object storage.
+// The pod template of a job can't be changed, so the name of the job includes a hash of the rendered pod template.
+// A new set of buckets or scheduling settings results in a new job and Cleanup removes the job for the old template.
+func newObjectStorageBucketsJob(workspace *mlopsv1alpha1.Workspace) *batchv1.Job {
+	buckets := newObjectStorageBuckets(workspace)
+
+	jobLabels := newComponentLabels(workspace, "object-storage-buckets")
+	secretName := newObjectStorageCredentialsName(workspace)
+
+	container := newContainer("buckets", objectStorageClientImage, corev1.ResourceRequirements{})
+	container.Command = []string{"/bin/sh", "-c", objectStorageBucketsScript}
+
+	container.Env = []corev1.EnvVar{
+		{
+			Name:  "ENDPOINT_URL",
+			Value: newWorkspaceEndpoints(workspace).objectStorageURL,
+		},
+		{
+			Name:  "BUCKETS",
+			Value: strings.Join(buckets, " "),
+		},
+		newSecretEnvVar("MINIO_ROOT_USER", secretName, "MINIO_ROOT_USER"),
+		newSecretEnvVar("MINIO_ROOT_PASSWORD", secretName, "MINIO_ROOT_PASSWORD"),
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: workspace.GetNamespace(),
+			Labels:    jobLabels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32(3),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: jobLabels,
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: newObjectStorageBucketsName(workspace),
+					RestartPolicy:      corev1.RestartPolicyOnFailure,
+					Containers: []corev1.Container{
+						container,
+					},
+				},
+			},
+		},
+	}
+
+	hardenPodSpec(&job.Spec.Template.Spec)
+	applyScheduling(&job.Spec.Template.Spec, jobLabels, workspace.Spec.ObjectStorage.Scheduling)
+
+	job.SetName(fmt.Sprintf("%s-%s", newObjectStorageBucketsName(workspace), newPodTemplateHash(&job.Spec.Template)))
+
+	return job
+}
+
+// newRandomString generates a random string of hexadecimal characters, with length bytes of randomness.
+func newRandomString(length int) (string, error) {
+	value := make([]byte, length)
+
+	if _, err := rand.Read(value); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(value), nil
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("objectStorageComponent", func() {
+	It("Should deploy the object storage with credentials and buckets", func() {
+		ctx := context.Background()
+		workspace := newTestWorkspace("test-objectstorage")
+		workspace.Spec.ObjectStorage.Enabled = true
+		workspace.Spec.ObjectStorage.Image = "minio/minio:latest"
+		workspace.Spec.ObjectStorage.Buckets = []string{"results", "datasets"}
+
+		Expect(k8sClient.Create(ctx, workspace)).To(Succeed())
+
+		objectStorageName := types.NamespacedName{Name: "test-objectstorage-object-storage", Namespace: workspace.GetNamespace()}
+
+		statefulSet := &appsv1.StatefulSet{}
+
+		Eventually(func() error {
+			return k8sClient.Get(ctx, objectStorageName, statefulSet)
+		}, time.Minute, time.Second).Should(Succeed())
+
+		Expect(statefulSet.Spec.Template.Spec.ServiceAccountName).To(Equal("test-objectstorage-object-storage"))
+		Expect(k8sClient.Get(ctx, objectStorageName, &corev1.ServiceAccount{})).To(Succeed())
+		Expect(k8sClient.Get(ctx, objectStorageName, &corev1.Service{})).To(Succeed())
+		Expect(k8sClient.Get(ctx, objectStorageName, &corev1.PersistentVolumeClaim{})).To(Succeed())
+
+		secret := &corev1.Secret{}
+		secretName := types.NamespacedName{Name: "test-objectstorage-object-storage-credentials", Namespace: workspace.GetNamespace()}
+
+		Expect(k8sClient.Get(ctx, secretName, secret)).To(Succeed())
+		Expect(secret.Data).To(HaveKey("MINIO_ROOT_USER"))
+		Expect(secret.Data).To(HaveKey("MINIO_ROOT_PASSWORD"))
+
+		jobs := &batchv1.JobList{}
+		selector := client.MatchingLabels(newComponentLabels(workspace, "object-storage-buckets"))
+
+		Eventually(func() error {
+			if err := k8sClient.List(ctx, jobs, client.InNamespace(workspace.GetNamespace()), selector); err != nil {
+				return err
+			}
+
+			if len(jobs.Items) != 1 {
+				return fmt.Errorf("expected one bucket job, got %d", len(jobs.Items))
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+
+		Expect(jobs.Items[0].Spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{
+			Name:  "BUCKETS",
+			Value: "datasets mlflow results",
+		}))
+
+		bucketsServiceAccountName := types.NamespacedName{Name: "test-objectstorage-object-storage-buckets", Namespace: workspace.GetNamespace()}
+
+		Expect(jobs.Items[0].Spec.Template.Spec.ServiceAccountName).To(Equal(bucketsServiceAccountName.Name))
+		Expect(k8sClient.Get(ctx, bucketsServiceAccountName, &corev1.ServiceAccount{})).To(Succeed())
+	})
+
+	It("Should replace the bucket job when its pod template changes", func() {
+		ctx := context.Background()
+		workspace := newTestWorkspace("test-objectstorage-scheduling")
+		workspace.Spec.ObjectStorage.Enabled = true
+		workspace.Spec.ObjectStorage.Image = "minio/minio:latest"
+
+		Expect(k8sClient.Create(ctx, workspace)).To(Succeed())
+
+		selector := client.MatchingLabels(newComponentLabels(workspace, "object-storage-buckets"))
+
+		getBucketJobs := func() ([]batchv1.Job, error) {
+			jobs := &batchv1.JobList{}
+
+			if err := k8sClient.List(ctx, jobs, client.InNamespace(workspace.GetNamespace()), selector); err != nil {
+				return nil, err
+			}
+
+			return jobs.Items, nil
+		}
+
+		Eventually(getBucketJobs, time.Minute, time.Second).Should(HaveLen(1))
+
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.ObjectStorage.Scheduling.NodeSelector = map[string]string{"storage": "fast"}
+		})
+
+		Eventually(func() (map[string]string, error) {
+			jobs, err := getBucketJobs()
+
+			if err != nil || len(jobs) != 1 {
+				return nil, err
+			}
+
+			return jobs[0].Spec.Template.Spec.NodeSelector, nil
+		}, time.Minute, time.Second).Should(HaveKeyWithValue("storage", "fast"))
+	})
+
+	It("Should inject the object storage into the experiment tracking server and agents", func() {
+		ctx := context.Background()
+		workspace := newTestWorkspace("test-objectstorage-injection")
+		workspace.Spec.ObjectStorage.Enabled = true
+		workspace.Spec.ObjectStorage.Image = "minio/minio:latest"
+
+		Expect(k8sClient.Create(ctx, workspace)).To(Succeed())
+
+		simulateDatabaseReady(ctx, workspace)
+		simulateStatefulSetReady(ctx, workspace.GetNamespace(), "test-objectstorage-injection-object-storage")
+
+		Eventually(func() error {
+			deployment, err := getExperimentTrackingDeployment(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			for _, envVar := range deployment.Spec.Template.Spec.Containers[0].Env {
+				if envVar.Name == "MLFLOW_ARTIFACTS_DESTINATION" && envVar.Value == "s3://mlflow" {
+					return nil
+				}
+			}
+
+			return fmt.Errorf("expected the artifacts to be stored in the object storage")
+		}, time.Minute, time.Second).Should(Succeed())
+
+		Eventually(func() error {
+			_, err := getWorkflowAgentPool(workspace, "test")
+			return err
+		}, time.Minute, time.Second).Should(Succeed())
+
+		agentPool, err := getWorkflowAgentPool(workspace, "test")
+		Expect(err).NotTo(HaveOccurred())
+
+		agentEnv := agentPool.Spec.Template.Spec.Containers[0].Env
+
+		Expect(agentEnv).To(ContainElement(corev1.EnvVar{
+			Name:  "AWS_ENDPOINT_URL",
+			Value: "http://test-objectstorage-injection-object-storage:9000",
+		}))
+
+		Expect(agentEnv).To(ContainElement(
+			newSecretEnvVar("AWS_ACCESS_KEY_ID", "test-objectstorage-injection-object-storage-credentials", "MINIO_ROOT_USER"),
+		))
+	})
+})
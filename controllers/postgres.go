@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"sort"
 
 	postgres "github.com/crunchydata/postgres-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
 	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
@@ -9,102 +11,242 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
-	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/log"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func (r *WorkspaceReconciler) reconcilePostgresCluster(ctx context.Context, workspace *mlopsv1alpha1.Workspace) error {
-	logger := log.FromContext(ctx).WithValues(
-		"workspace", workspace.GetName(),
-		"namespace", workspace.GetNamespace())
+const (
+	databaseComponentName = "database"
+	postgresImage         = "registry.developers.crunchydata.com/crunchydata/crunchy-postgres:ubi8-14.6-2"
+)
+
+// databaseBinding describes the postgres user and database a component uses to store its data.
+type databaseBinding struct {
+	user     string
+	database string
+}
+
+// databaseBindings maps the components that store data in the postgres cluster to their database.
+// Each component gets its own user and database, so components can't write into each other's tables.
+var databaseBindings = map[string]databaseBinding{
+	experimentTrackingComponentName: {user: "mlflow", database: "mlflow"},
+	workflowsComponentName:          {user: "prefect", database: "prefect"},
+}
+
+// newDatabaseSecretName returns the name of the secret crunchy creates with the credentials of the
+// database user bound to the component.
+func newDatabaseSecretName(workspace *mlopsv1alpha1.Workspace, componentName string) string {
+	return fmt.Sprintf("%s-pguser-%s", workspace.GetName(), databaseBindings[componentName].user)
+}
+
+// newDatabaseUsers creates a database user for each database binding.
+// The users are sorted by name, so the order in the postgres cluster spec is stable.
+func newDatabaseUsers() []postgres.PostgresUserSpec {
+	users := []postgres.PostgresUserSpec{}
+
+	for _, binding := range databaseBindings {
+		users = append(users, postgres.PostgresUserSpec{
+			Name:      postgres.PostgresIdentifier(binding.user),
+			Databases: []postgres.PostgresIdentifier{postgres.PostgresIdentifier(binding.database)},
+		})
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Name < users[j].Name
+	})
+
+	return users
+}
+
+// databaseComponent manages the postgres cluster that stores the data for the experiment tracking server
+// and the workflow server.
+type databaseComponent struct {
+	componentBase
+}
+
+func (c *databaseComponent) Name() string {
+	return databaseComponentName
+}
+
+func (c *databaseComponent) ConditionType() string {
+	return mlopsv1alpha1.ConditionTypeDatabaseReady
+}
+
+func (c *databaseComponent) OwnedTypes() []client.Object {
+	return []client.Object{&postgres.PostgresCluster{}}
+}
+
+func (c *databaseComponent) Render(workspace *mlopsv1alpha1.Workspace) ([]client.Object, error) {
+	return []client.Object{newPostgresCluster(workspace)}, nil
+}
 
+// Ready reports the database as ready once the postgres cluster is running and crunchy created the secrets
+// with the credentials for the database users. The other components can't connect to the database without them.
+func (c *databaseComponent) Ready(ctx context.Context, workspace *mlopsv1alpha1.Workspace) (componentCondition, error) {
 	cluster := &postgres.PostgresCluster{}
-	clusterName := workspace.GetName()
 
-	if err := r.Get(ctx, types.NamespacedName{Name: clusterName, Namespace: workspace.GetNamespace()}, cluster); err != nil {
+	if err := c.reconciler.Get(ctx, types.NamespacedName{Name: workspace.GetName(), Namespace: workspace.GetNamespace()}, cluster); err != nil {
 		if errors.IsNotFound(err) {
-			// The total storage capacity is the same as the sum of the storage capacity of the experiment tracking
-			// database and the workflow controller database.
-			storageQuantity := workspace.Spec.Storage.DatabaseStorage
-			backupQuantity := workspace.Spec.Storage.DatabaseBackupStorage
-
-			cluster = &postgres.PostgresCluster{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      clusterName,
-					Namespace: workspace.GetNamespace(),
-					Labels: map[string]string{
-						"mlops.aigency.com/workspace": workspace.GetName(),
-						"mlops.aigency.com/component": "postgres-cluster",
+			return componentCondition{reason: reasonNotFound, message: "The postgres cluster does not exist yet"}, nil
+		}
+
+		return componentCondition{}, err
+	}
+
+	if condition := newPostgresClusterCondition(cluster); !condition.ready {
+		return condition, nil
+	}
+
+	for _, user := range cluster.Spec.Users {
+		secretName := fmt.Sprintf("%s-pguser-%s", workspace.GetName(), user.Name)
+		secret := &corev1.Secret{}
+
+		if err := c.reconciler.Get(ctx, types.NamespacedName{Name: secretName, Namespace: workspace.GetNamespace()}, secret); err != nil {
+			if errors.IsNotFound(err) {
+				return componentCondition{
+					reason:  reasonProgressing,
+					message: fmt.Sprintf("The credentials for database user %s do not exist yet", user.Name),
+				}, nil
+			}
+
+			return componentCondition{}, err
+		}
+	}
+
+	return componentCondition{ready: true, reason: reasonAvailable, message: "The postgres cluster is ready"}, nil
+}
+
+func newPostgresCluster(workspace *mlopsv1alpha1.Workspace) *postgres.PostgresCluster {
+	// The total storage capacity is the same as the sum of the storage capacity of the experiment tracking
+	// database and the workflow controller database.
+	storageQuantity := workspace.Spec.Storage.DatabaseStorage
+	backupQuantity := workspace.Spec.Storage.DatabaseBackupStorage
+
+	return &postgres.PostgresCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      workspace.GetName(),
+			Namespace: workspace.GetNamespace(),
+			Labels: map[string]string{
+				"mlops.aigency.com/workspace": workspace.GetName(),
+				"mlops.aigency.com/component": "postgres-cluster",
+			},
+		},
+		Spec: postgres.PostgresClusterSpec{
+			Image:           postgresImage,
+			PostgresVersion: 14,
+			// A postgres cluster kept by the Snapshot policy is shut down, so we start it when a workspace adopts it.
+			Shutdown: pointer.Bool(false),
+			InstanceSets: []postgres.PostgresInstanceSetSpec{
+				{
+					Name:                      "db01",
+					Affinity:                  newPostgresAffinity(workspace.Spec.Database.Scheduling),
+					Tolerations:               workspace.Spec.Database.Scheduling.Tolerations,
+					TopologySpreadConstraints: newPostgresTopologySpreadConstraints(workspace),
+					PriorityClassName:         newPostgresPriorityClassName(workspace.Spec.Database.Scheduling),
+					DataVolumeClaimSpec: corev1.PersistentVolumeClaimSpec{
+						AccessModes: []corev1.PersistentVolumeAccessMode{
+							corev1.ReadWriteOnce,
+						},
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								"storage": storageQuantity,
+							},
+						},
 					},
 				},
-				Spec: postgres.PostgresClusterSpec{
-					Image:           "registry.developers.crunchydata.com/crunchydata/crunchy-postgres:ubi8-14.6-2",
-					PostgresVersion: 14,
-					InstanceSets: []postgres.PostgresInstanceSetSpec{
+			},
+			Backups: postgres.Backups{
+				PGBackRest: postgres.PGBackRestArchive{
+					Repos: []postgres.PGBackRestRepo{
 						{
-							Name: "db01",
-							DataVolumeClaimSpec: corev1.PersistentVolumeClaimSpec{
-								AccessModes: []corev1.PersistentVolumeAccessMode{
-									corev1.ReadWriteOnce,
-								},
-								Resources: corev1.ResourceRequirements{
-									Requests: corev1.ResourceList{
-										"storage": storageQuantity,
+							Name: "repo1",
+							Volume: &postgres.RepoPVC{
+								VolumeClaimSpec: corev1.PersistentVolumeClaimSpec{
+									AccessModes: []corev1.PersistentVolumeAccessMode{
+										corev1.ReadWriteOnce,
 									},
-								},
-							},
-						},
-					},
-					Backups: postgres.Backups{
-						PGBackRest: postgres.PGBackRestArchive{
-							Repos: []postgres.PGBackRestRepo{
-								{
-									Name: "repo1",
-									Volume: &postgres.RepoPVC{
-										VolumeClaimSpec: corev1.PersistentVolumeClaimSpec{
-											AccessModes: []corev1.PersistentVolumeAccessMode{
-												corev1.ReadWriteOnce,
-											},
-											Resources: corev1.ResourceRequirements{
-												Requests: corev1.ResourceList{
-													"storage": backupQuantity,
-												},
-											},
+									Resources: corev1.ResourceRequirements{
+										Requests: corev1.ResourceList{
+											"storage": backupQuantity,
 										},
 									},
 								},
 							},
 						},
 					},
-					Users: []postgres.PostgresUserSpec{
-						{
-							Name:      "mlflow",
-							Databases: []postgres.PostgresIdentifier{"mlflow"},
-						},
-						{
-							Name:      "prefect",
-							Databases: []postgres.PostgresIdentifier{"prefect"},
-						},
-					},
 				},
-			}
+			},
+			Users: newDatabaseUsers(),
+		},
+	}
+}
 
-			if err := ctrl.SetControllerReference(workspace, cluster, r.Scheme); err != nil {
-				logger.Error(err, "Failed to set controller reference for postgres cluster")
-				return err
-			}
+// newPostgresAffinity adds the node selector of the scheduling spec to the affinity of the database.
+// The postgres cluster has no node selector, so every node selector term must match the labels as well.
+func newPostgresAffinity(schedulingSpec mlopsv1alpha1.SchedulingSpec) *corev1.Affinity {
+	if len(schedulingSpec.NodeSelector) == 0 {
+		return schedulingSpec.Affinity
+	}
 
-			if err := r.Create(ctx, cluster); err != nil {
-				logger.Error(err, "Failed to create postgres cluster for the workspace")
-				return err
-			}
+	labelNames := []string{}
 
-			return nil
-		}
+	for labelName := range schedulingSpec.NodeSelector {
+		labelNames = append(labelNames, labelName)
+	}
+
+	// The order of the requirements must be stable, otherwise every reconcile restarts the database.
+	sort.Strings(labelNames)
+
+	requirements := []corev1.NodeSelectorRequirement{}
+
+	for _, labelName := range labelNames {
+		requirements = append(requirements, corev1.NodeSelectorRequirement{
+			Key:      labelName,
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   []string{schedulingSpec.NodeSelector[labelName]},
+		})
+	}
+
+	affinity := &corev1.Affinity{}
+
+	if schedulingSpec.Affinity != nil {
+		affinity = schedulingSpec.Affinity.DeepCopy()
+	}
+
+	if affinity.NodeAffinity == nil {
+		affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+
+	nodeSelector := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+
+	if nodeSelector == nil || len(nodeSelector.NodeSelectorTerms) == 0 {
+		nodeSelector = &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{}}}
+	}
+
+	for index := range nodeSelector.NodeSelectorTerms {
+		term := &nodeSelector.NodeSelectorTerms[index]
+		term.MatchExpressions = append(term.MatchExpressions, requirements...)
+	}
+
+	affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = nodeSelector
+
+	return affinity
+}
+
+// newPostgresTopologySpreadConstraints spreads the instances of the postgres cluster when a constraint has no label selector.
+func newPostgresTopologySpreadConstraints(workspace *mlopsv1alpha1.Workspace) []corev1.TopologySpreadConstraint {
+	podSpec := &corev1.PodSpec{}
+	podLabels := map[string]string{"postgres-operator.crunchydata.com/cluster": workspace.GetName()}
+
+	applyScheduling(podSpec, podLabels, workspace.Spec.Database.Scheduling)
+
+	return podSpec.TopologySpreadConstraints
+}
 
-		logger.Error(err, "Failed to get postgres cluster for the workspace")
-		return err
+func newPostgresPriorityClassName(schedulingSpec mlopsv1alpha1.SchedulingSpec) *string {
+	if schedulingSpec.PriorityClassName == "" {
+		return nil
 	}
 
-	return nil
+	return &schedulingSpec.PriorityClassName
 }
@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("newPostgresCluster", func() {
+	It("Should add the node selector to the affinity of the database", func() {
+		schedulingSpec := mlopsv1alpha1.SchedulingSpec{
+			NodeSelector: map[string]string{"node-pool": "database"},
+		}
+
+		affinity := newPostgresAffinity(schedulingSpec)
+
+		Expect(affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms).To(ConsistOf(
+			corev1.NodeSelectorTerm{
+				MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "node-pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"database"}},
+				},
+			},
+		))
+	})
+})
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"reflect"
+
+	postgres "github.com/crunchydata/postgres-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
+	ray "github.com/ray-project/kuberay/ray-operator/apis/ray/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// newOwnedResourcePredicate filters the events for resources owned by a workspace.
+// Changes to the spec, labels, and annotations of a resource always trigger a reconcile so we can correct drift.
+// Status updates only trigger a reconcile when they change the readiness of the component.
+func newOwnedResourcePredicate() predicate.Predicate {
+	return predicate.Or(
+		predicate.GenerationChangedPredicate{},
+		predicate.LabelChangedPredicate{},
+		predicate.AnnotationChangedPredicate{},
+		componentStateChangedPredicate{},
+	)
+}
+
+// componentStateChangedPredicate lets through update events that change the observed state of a component.
+type componentStateChangedPredicate struct {
+	predicate.Funcs
+}
+
+// Update implements the update event filter for the predicate.
+func (componentStateChangedPredicate) Update(e event.UpdateEvent) bool {
+	if e.ObjectOld == nil || e.ObjectNew == nil {
+		return false
+	}
+
+	switch newObject := e.ObjectNew.(type) {
+	case *appsv1.Deployment:
+		oldObject, ok := e.ObjectOld.(*appsv1.Deployment)
+		return !ok || newDeploymentCondition(oldObject) != newDeploymentCondition(newObject)
+	case *appsv1.StatefulSet:
+		oldObject, ok := e.ObjectOld.(*appsv1.StatefulSet)
+		return !ok || newStatefulSetCondition(oldObject) != newStatefulSetCondition(newObject)
+	case *postgres.PostgresCluster:
+		oldObject, ok := e.ObjectOld.(*postgres.PostgresCluster)
+		return !ok || newPostgresClusterCondition(oldObject) != newPostgresClusterCondition(newObject)
+	case *ray.RayCluster:
+		oldObject, ok := e.ObjectOld.(*ray.RayCluster)
+		return !ok || newRayClusterCondition(oldObject) != newRayClusterCondition(newObject)
+	case *corev1.Service:
+		// Services don't track a generation, so we need to compare the spec to detect changes.
+		oldObject, ok := e.ObjectOld.(*corev1.Service)
+		return !ok || !reflect.DeepEqual(oldObject.Spec, newObject.Spec)
+	case *rbacv1.Role:
+		// Roles and role bindings don't track a generation either, so we compare their contents.
+		oldObject, ok := e.ObjectOld.(*rbacv1.Role)
+		return !ok || !reflect.DeepEqual(oldObject.Rules, newObject.Rules)
+	case *rbacv1.RoleBinding:
+		oldObject, ok := e.ObjectOld.(*rbacv1.RoleBinding)
+		return !ok || !reflect.DeepEqual(oldObject.Subjects, newObject.Subjects)
+	case *corev1.Secret:
+		// Secrets don't track a generation either. A new password must reach the workloads that use it.
+		oldObject, ok := e.ObjectOld.(*corev1.Secret)
+		return !ok || !reflect.DeepEqual(oldObject.Data, newObject.Data)
+	case *corev1.ConfigMap:
+		// Config maps don't track a generation either, so we compare the data to detect changes.
+		oldObject, ok := e.ObjectOld.(*corev1.ConfigMap)
+		return !ok || !reflect.DeepEqual(oldObject.Data, newObject.Data) || !reflect.DeepEqual(oldObject.BinaryData, newObject.BinaryData)
+	}
+
+	return false
+}
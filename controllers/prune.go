@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/strings/slices"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// pruneOwnedPoolResources removes resources that belong to a pool that is no longer part of the workspace spec.
+// The resources are selected using the component labels and the mlops.aigency.com/pool label.
+// Only resources controlled by the workspace are removed, so we never touch resources created by someone else.
+func (r *WorkspaceReconciler) pruneOwnedPoolResources(ctx context.Context, logger logr.Logger, workspace *mlopsv1alpha1.Workspace, list client.ObjectList, componentName string, poolNames []string) error {
+	selector := client.MatchingLabels(newComponentLabels(workspace, componentName))
+
+	if err := r.List(ctx, list, client.InNamespace(workspace.GetNamespace()), selector); err != nil {
+		logger.Error(err, "Failed to list resources for pruning", "component", componentName)
+		return err
+	}
+
+	items, err := meta.ExtractList(list)
+
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		resource, ok := item.(client.Object)
+
+		if !ok || !metav1.IsControlledBy(resource, workspace) {
+			continue
+		}
+
+		poolName := resource.GetLabels()["mlops.aigency.com/pool"]
+
+		if poolName == "" || slices.Contains(poolNames, poolName) {
+			continue
+		}
+
+		if err := r.Delete(ctx, resource, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+			logger.Error(err, "Failed to prune resource for removed pool", "pool", poolName, "resource", resource.GetName())
+			return err
+		}
+
+		logger.Info("Pruned resource for removed pool", "pool", poolName, "resource", resource.GetName())
+
+		r.Recorder.Eventf(workspace, corev1.EventTypeNormal, "Pruned",
+			"Removed %s for pool %s because it is no longer part of the workspace", resource.GetName(), poolName)
+	}
+
+	return nil
+}
+
+// recordPrunedWorkerGroups records an event for each worker group that is removed from the ray cluster.
+// Worker groups are part of the ray cluster spec, so KubeRay removes the pods once we update the cluster.
+func (r *WorkspaceReconciler) recordPrunedWorkerGroups(logger logr.Logger, workspace *mlopsv1alpha1.Workspace, currentGroupNames []string, desiredGroupNames []string) {
+	for _, groupName := range currentGroupNames {
+		if slices.Contains(desiredGroupNames, groupName) {
+			continue
+		}
+
+		logger.Info("Pruned worker pool from the compute cluster", "pool", groupName)
+
+		r.Recorder.Eventf(workspace, corev1.EventTypeNormal, "Pruned",
+			"Removed worker pool %s from the compute cluster because it is no longer part of the workspace", groupName)
+	}
+}
@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	postgres "github.com/crunchydata/postgres-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
+	ray "github.com/ray-project/kuberay/ray-operator/apis/ray/v1alpha1"
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	reasonAvailable      = "Available"
+	reasonProgressing    = "Progressing"
+	reasonNotFound       = "NotFound"
+	reasonFailed         = "Failed"
+	reasonReconcileError = "ReconcileError"
+	reasonWaiting        = "Waiting"
+	reasonDisabled       = "Disabled"
+)
+
+// componentErrors contains the reconcile errors of the components in the workspace by component name.
+type componentErrors map[string]error
+
+// aggregate combines the errors of all components into a single error.
+// The errors are sorted by component name so the message in the status of the workspace is stable.
+func (e componentErrors) aggregate() error {
+	componentNames := []string{}
+
+	for componentName := range e {
+		componentNames = append(componentNames, componentName)
+	}
+
+	sort.Strings(componentNames)
+
+	errorList := []error{}
+
+	for _, componentName := range componentNames {
+		errorList = append(errorList, e[componentName])
+	}
+
+	return utilerrors.NewAggregate(errorList)
+}
+
+// waitingComponents contains the dependencies that aren't ready yet by name of the component waiting for them.
+type waitingComponents map[string][]string
+
+// componentConditions contains the observed state of the reconciled components by component name.
+type componentConditions map[string]componentCondition
+
+// componentCondition describes the observed state of a single component in the workspace.
+type componentCondition struct {
+	ready   bool
+	reason  string
+	message string
+}
+
+// updateWorkspaceStatus sets the condition contributed by each component, and derives the ready condition
+// and phase of the workspace from them. Components without a condition type only contribute to the ready condition.
+// Components that are waiting or failed to reconcile have no observed state, they report why instead.
+func (r *WorkspaceReconciler) updateWorkspaceStatus(ctx context.Context, workspace *mlopsv1alpha1.Workspace, components []Component, conditions componentConditions, reconcileErrors componentErrors, waiting waitingComponents) error {
+	logger := log.FromContext(ctx).WithValues(
+		"workspace", workspace.GetName(),
+		"namespace", workspace.GetNamespace())
+
+	originalStatus := workspace.Status.DeepCopy()
+
+	allReady := true
+	anyFailed := false
+	notReady := []string{}
+
+	for _, component := range components {
+		condition := conditions[component.Name()]
+
+		if dependencies, ok := waiting[component.Name()]; ok {
+			condition = componentCondition{
+				reason:  reasonWaiting,
+				message: fmt.Sprintf("Waiting for %s to become ready", strings.Join(dependencies, ", ")),
+			}
+		}
+
+		if reconcileErr, ok := reconcileErrors[component.Name()]; ok {
+			condition = componentCondition{reason: reasonReconcileError, message: reconcileErr.Error()}
+		}
+
+		if !condition.ready {
+			allReady = false
+			notReady = append(notReady, component.Name())
+		}
+
+		if condition.reason == reasonFailed || condition.reason == reasonReconcileError {
+			anyFailed = true
+		}
+
+		if component.ConditionType() != "" {
+			setWorkspaceCondition(workspace, component.ConditionType(), condition)
+		}
+	}
+
+	readyCondition := componentCondition{
+		ready:   allReady,
+		reason:  reasonAvailable,
+		message: "All components in the workspace are ready",
+	}
+
+	if !allReady {
+		readyCondition.reason = reasonProgressing
+		readyCondition.message = fmt.Sprintf("Waiting for components to become ready: %v", notReady)
+	}
+
+	if err := reconcileErrors.aggregate(); err != nil {
+		readyCondition.reason = reasonReconcileError
+		readyCondition.message = err.Error()
+	}
+
+	setWorkspaceCondition(workspace, mlopsv1alpha1.ConditionTypeReady, readyCondition)
+
+	switch {
+	case anyFailed:
+		workspace.Status.Phase = mlopsv1alpha1.WorkspacePhaseFailed
+	case allReady:
+		workspace.Status.Phase = mlopsv1alpha1.WorkspacePhaseReady
+	default:
+		workspace.Status.Phase = mlopsv1alpha1.WorkspacePhaseProvisioning
+	}
+
+	workspace.Status.ObservedGeneration = workspace.GetGeneration()
+	workspace.Status.URLs = newWorkspaceURLs(workspace)
+
+	if reflect.DeepEqual(originalStatus, &workspace.Status) {
+		return nil
+	}
+
+	if err := r.Status().Update(ctx, workspace); err != nil {
+		logger.Error(err, "Failed to update the status of the workspace")
+		return err
+	}
+
+	return nil
+}
+
+func setWorkspaceCondition(workspace *mlopsv1alpha1.Workspace, conditionType string, condition componentCondition) {
+	status := metav1.ConditionFalse
+
+	if condition.ready {
+		status = metav1.ConditionTrue
+	}
+
+	meta.SetStatusCondition(&workspace.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             condition.reason,
+		Message:            condition.message,
+		ObservedGeneration: workspace.GetGeneration(),
+	})
+}
+
+func newDeploymentCondition(deployment *appsv1.Deployment) componentCondition {
+	desiredReplicas := int32(1)
+
+	if deployment.Spec.Replicas != nil {
+		desiredReplicas = *deployment.Spec.Replicas
+	}
+
+	if deployment.Status.AvailableReplicas < desiredReplicas {
+		return componentCondition{
+			reason:  reasonProgressing,
+			message: fmt.Sprintf("Deployment %s has %d of %d replicas available", deployment.GetName(), deployment.Status.AvailableReplicas, desiredReplicas),
+		}
+	}
+
+	return componentCondition{
+		ready:   true,
+		reason:  reasonAvailable,
+		message: fmt.Sprintf("Deployment %s is available", deployment.GetName()),
+	}
+}
+
+func newStatefulSetCondition(statefulSet *appsv1.StatefulSet) componentCondition {
+	desiredReplicas := int32(1)
+
+	if statefulSet.Spec.Replicas != nil {
+		desiredReplicas = *statefulSet.Spec.Replicas
+	}
+
+	if statefulSet.Status.ReadyReplicas < desiredReplicas {
+		return componentCondition{
+			reason:  reasonProgressing,
+			message: fmt.Sprintf("StatefulSet %s has %d of %d replicas ready", statefulSet.GetName(), statefulSet.Status.ReadyReplicas, desiredReplicas),
+		}
+	}
+
+	return componentCondition{
+		ready:   true,
+		reason:  reasonAvailable,
+		message: fmt.Sprintf("StatefulSet %s is ready", statefulSet.GetName()),
+	}
+}
+
+func newPostgresClusterCondition(cluster *postgres.PostgresCluster) componentCondition {
+	if len(cluster.Status.InstanceSets) == 0 {
+		return componentCondition{reason: reasonProgressing, message: "The postgres cluster has no instances yet"}
+	}
+
+	for _, instanceSet := range cluster.Status.InstanceSets {
+		if instanceSet.Replicas == 0 || instanceSet.ReadyReplicas < instanceSet.Replicas {
+			return componentCondition{
+				reason: reasonProgressing,
+				message: fmt.Sprintf("Instance set %s has %d of %d replicas ready",
+					instanceSet.Name, instanceSet.ReadyReplicas, instanceSet.Replicas),
+			}
+		}
+	}
+
+	return componentCondition{ready: true, reason: reasonAvailable, message: "The postgres cluster is ready"}
+}
+
+func newRayClusterCondition(rayCluster *ray.RayCluster) componentCondition {
+	switch rayCluster.Status.State {
+	case ray.Ready:
+		return componentCondition{ready: true, reason: reasonAvailable, message: "The ray cluster is ready"}
+	case ray.Failed:
+		return componentCondition{reason: reasonFailed, message: rayCluster.Status.Reason}
+	default:
+		return componentCondition{reason: reasonProgressing, message: "The ray cluster is not ready yet"}
+	}
+}
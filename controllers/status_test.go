@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("updateWorkspaceStatus", func() {
+	It("Should report the status of each component", func() {
+		ctx := context.Background()
+		workspace := newTestWorkspace("test-status")
+
+		err := k8sClient.Create(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() error {
+			updatedWorkspace, err := getWorkspace(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			if updatedWorkspace.Status.ObservedGeneration != updatedWorkspace.GetGeneration() {
+				return fmt.Errorf("expected observed generation %d, got %d", updatedWorkspace.GetGeneration(), updatedWorkspace.Status.ObservedGeneration)
+			}
+
+			if updatedWorkspace.Status.Phase != mlopsv1alpha1.WorkspacePhaseProvisioning {
+				return fmt.Errorf("expected phase to be %s, got %s", mlopsv1alpha1.WorkspacePhaseProvisioning, updatedWorkspace.Status.Phase)
+			}
+
+			conditionTypes := []string{
+				mlopsv1alpha1.ConditionTypeReady,
+				mlopsv1alpha1.ConditionTypeDatabaseReady,
+				mlopsv1alpha1.ConditionTypeExperimentTrackingReady,
+				mlopsv1alpha1.ConditionTypeWorkflowsReady,
+				mlopsv1alpha1.ConditionTypeComputeReady,
+			}
+
+			for _, conditionType := range conditionTypes {
+				condition := meta.FindStatusCondition(updatedWorkspace.Status.Conditions, conditionType)
+
+				if condition == nil {
+					return fmt.Errorf("expected condition %s to be present", conditionType)
+				}
+
+				// There are no operators running in the test environment, so none of the components become ready.
+				if condition.Status != metav1.ConditionFalse {
+					return fmt.Errorf("expected condition %s to be false, got %s", conditionType, condition.Status)
+				}
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+	})
+})
+
+func getWorkspace(workspace *mlopsv1alpha1.Workspace) (*mlopsv1alpha1.Workspace, error) {
+	workspaceName := types.NamespacedName{
+		Name:      workspace.GetName(),
+		Namespace: workspace.GetNamespace(),
+	}
+
+	updatedWorkspace := &mlopsv1alpha1.Workspace{}
+
+	return updatedWorkspace, k8sClient.Get(context.Background(), workspaceName, updatedWorkspace)
+}
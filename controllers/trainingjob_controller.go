@@ -0,0 +1,497 @@
+/*
+Copyright 2023 Willem Meints.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	ray "github.com/ray-project/kuberay/ray-operator/apis/ray/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+)
+
+const (
+	// trainingJobWorkspaceIndexKey indexes the training jobs by the name of their workspace.
+	trainingJobWorkspaceIndexKey = ".spec.workspace"
+
+	// trainingJobWorkingDirectory is where the files of a working directory from a config map are mounted.
+	trainingJobWorkingDirectory = "/opt/training-job"
+
+	// trainingJobFinalizer keeps a removed training job around until its MLflow run is ended.
+	trainingJobFinalizer = "mlops.aigency.com/finalizer"
+)
+
+// TrainingJobReconciler reconciles a TrainingJob object
+type TrainingJobReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	tracker experimentTracker
+}
+
+//+kubebuilder:rbac:groups=mlops.aigency.com,resources=trainingjobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=mlops.aigency.com,resources=trainingjobs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=mlops.aigency.com,resources=trainingjobs/finalizers,verbs=update
+//+kubebuilder:rbac:groups=ray.io,resources=rayjobs,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile records the training job as a run in MLflow, submits it to ray as a RayJob,
+// and reports the state of the RayJob back in the status of the training job.
+func (r *TrainingJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("trainingJob", req.NamespacedName, "namespace", req.Namespace)
+
+	trainingJob := &mlopsv1alpha1.TrainingJob{}
+
+	if err := r.Get(ctx, req.NamespacedName, trainingJob); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("Training job not found. Skipping reconciliation.", "trainingJobName", req.Name, "namespace", req.Namespace)
+			return ctrl.Result{}, nil
+		}
+
+		logger.Error(err, "Failed to get the training job")
+		return ctrl.Result{}, err
+	}
+
+	trainingJob.Default()
+
+	if !trainingJob.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, r.finalizeTrainingJob(ctx, trainingJob)
+	}
+
+	if trainingJob.Status.Phase.IsFinished() {
+		return ctrl.Result{}, nil
+	}
+
+	patch := client.MergeFrom(trainingJob.DeepCopy())
+
+	if controllerutil.AddFinalizer(trainingJob, trainingJobFinalizer) {
+		if err := r.Patch(ctx, trainingJob, patch); err != nil {
+			logger.Error(err, "Failed to add the finalizer to the training job")
+			return ctrl.Result{}, err
+		}
+	}
+
+	originalStatus := trainingJob.Status.DeepCopy()
+
+	if trainingJob.Status.Phase == "" {
+		trainingJob.Status.Phase = mlopsv1alpha1.TrainingJobPhasePending
+	}
+
+	workspace := &mlopsv1alpha1.Workspace{}
+	workspaceName := types.NamespacedName{Name: trainingJob.Spec.Workspace, Namespace: trainingJob.GetNamespace()}
+
+	if err := r.Get(ctx, workspaceName, workspace); err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "Failed to get the workspace of the training job")
+			return ctrl.Result{}, err
+		}
+
+		// The workspace watch picks the job up again when the workspace is created.
+		trainingJob.Status.Message = fmt.Sprintf("Workspace %s does not exist", trainingJob.Spec.Workspace)
+		return ctrl.Result{}, r.updateTrainingJobStatus(ctx, trainingJob, originalStatus)
+	}
+
+	if trainingJob.Status.RunID == "" {
+		if !meta.IsStatusConditionTrue(workspace.Status.Conditions, mlopsv1alpha1.ConditionTypeExperimentTrackingReady) {
+			trainingJob.Status.Message = fmt.Sprintf("Waiting for the experiment tracking in workspace %s to become ready", workspace.GetName())
+			return ctrl.Result{}, r.updateTrainingJobStatus(ctx, trainingJob, originalStatus)
+		}
+
+		if err := r.createExperimentRun(ctx, trainingJob, workspace); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		// The run must be stored before the job is submitted, so we never submit the job with a run that's lost.
+		if err := r.updateTrainingJobStatus(ctx, trainingJob, originalStatus); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		originalStatus = trainingJob.Status.DeepCopy()
+	}
+
+	rayJob, err := r.getOrCreateRayJob(ctx, trainingJob, workspace)
+
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	setTrainingJobStatus(trainingJob, rayJob)
+
+	if trainingJob.Status.Phase.IsFinished() {
+		if err := r.endExperimentRun(ctx, trainingJob, workspace); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, r.updateTrainingJobStatus(ctx, trainingJob, originalStatus)
+}
+
+// createExperimentRun starts the MLflow run for the job. The job picks the run up through MLFLOW_RUN_ID,
+// so the metrics it logs end up in the run we report in the status.
+func (r *TrainingJobReconciler) createExperimentRun(ctx context.Context, trainingJob *mlopsv1alpha1.TrainingJob, workspace *mlopsv1alpha1.Workspace) error {
+	logger := log.FromContext(ctx).WithValues(
+		"trainingJob", trainingJob.GetName(),
+		"namespace", trainingJob.GetNamespace())
+
+	// The uid tells the run apart from the runs of earlier training jobs with the same name.
+	tags := map[string]string{
+		"mlops.aigency.com/training-job":     trainingJob.GetName(),
+		"mlops.aigency.com/training-job-uid": string(trainingJob.GetUID()),
+	}
+
+	// Storing the run in the status can fail after we created it, so we look for the run of an earlier attempt first.
+	run, found, err := r.tracker.FindRun(ctx, workspace, trainingJob.Spec.ExperimentName, tags)
+
+	if err != nil {
+		logger.Error(err, "Failed to look up the MLflow run for the training job")
+		return err
+	}
+
+	if found {
+		trainingJob.Status.ExperimentID = run.experimentID
+		trainingJob.Status.RunID = run.runID
+
+		return nil
+	}
+
+	run, err = r.tracker.CreateRun(ctx, workspace, trainingJob.Spec.ExperimentName, trainingJob.GetName(), tags)
+
+	if err != nil {
+		logger.Error(err, "Failed to create the MLflow run for the training job")
+		return err
+	}
+
+	trainingJob.Status.ExperimentID = run.experimentID
+	trainingJob.Status.RunID = run.runID
+
+	r.Recorder.Eventf(trainingJob, corev1.EventTypeNormal, "RunCreated",
+		"Recording the training job in MLflow run %s", run.runID)
+
+	return nil
+}
+
+// endExperimentRun marks the MLflow run as finished, in case the job didn't end the run itself.
+func (r *TrainingJobReconciler) endExperimentRun(ctx context.Context, trainingJob *mlopsv1alpha1.TrainingJob, workspace *mlopsv1alpha1.Workspace) error {
+	logger := log.FromContext(ctx).WithValues(
+		"trainingJob", trainingJob.GetName(),
+		"namespace", trainingJob.GetNamespace())
+
+	runStatuses := map[mlopsv1alpha1.TrainingJobPhase]string{
+		mlopsv1alpha1.TrainingJobPhaseSucceeded: "FINISHED",
+		mlopsv1alpha1.TrainingJobPhaseFailed:    "FAILED",
+		mlopsv1alpha1.TrainingJobPhaseStopped:   "KILLED",
+	}
+
+	endTime := time.Now()
+
+	if trainingJob.Status.CompletionTime != nil {
+		endTime = trainingJob.Status.CompletionTime.Time
+	}
+
+	if err := r.tracker.EndRun(ctx, workspace, trainingJob.Status.RunID, runStatuses[trainingJob.Status.Phase], endTime); err != nil {
+		logger.Error(err, "Failed to end the MLflow run for the training job", "runID", trainingJob.Status.RunID)
+		return err
+	}
+
+	r.Recorder.Eventf(trainingJob, corev1.EventTypeNormal, string(trainingJob.Status.Phase),
+		"The training job finished with status %s", trainingJob.Status.Phase)
+
+	return nil
+}
+
+// finalizeTrainingJob ends the MLflow run of a training job that's removed before it finished, so the run
+// doesn't stay in the running state forever. Without a workspace or experiment tracking, there's no run to end.
+func (r *TrainingJobReconciler) finalizeTrainingJob(ctx context.Context, trainingJob *mlopsv1alpha1.TrainingJob) error {
+	logger := log.FromContext(ctx).WithValues(
+		"trainingJob", trainingJob.GetName(),
+		"namespace", trainingJob.GetNamespace())
+
+	if !controllerutil.ContainsFinalizer(trainingJob, trainingJobFinalizer) {
+		return nil
+	}
+
+	if trainingJob.Status.RunID != "" && !trainingJob.Status.Phase.IsFinished() {
+		workspace := &mlopsv1alpha1.Workspace{}
+		workspaceName := types.NamespacedName{Name: trainingJob.Spec.Workspace, Namespace: trainingJob.GetNamespace()}
+
+		err := r.Get(ctx, workspaceName, workspace)
+
+		if err != nil && !errors.IsNotFound(err) {
+			logger.Error(err, "Failed to get the workspace of the training job")
+			return err
+		}
+
+		if err == nil && meta.IsStatusConditionTrue(workspace.Status.Conditions, mlopsv1alpha1.ConditionTypeExperimentTrackingReady) {
+			if err := r.tracker.EndRun(ctx, workspace, trainingJob.Status.RunID, "KILLED", time.Now()); err != nil {
+				logger.Error(err, "Failed to end the MLflow run for the removed training job", "runID", trainingJob.Status.RunID)
+				return err
+			}
+		}
+	}
+
+	patch := client.MergeFrom(trainingJob.DeepCopy())
+	controllerutil.RemoveFinalizer(trainingJob, trainingJobFinalizer)
+
+	if err := r.Patch(ctx, trainingJob, patch); err != nil {
+		logger.Error(err, "Failed to remove the finalizer from the training job")
+		return err
+	}
+
+	return nil
+}
+
+// getOrCreateRayJob submits the training job to ray. The spec of a training job can't change,
+// so the RayJob is only created once.
+func (r *TrainingJobReconciler) getOrCreateRayJob(ctx context.Context, trainingJob *mlopsv1alpha1.TrainingJob, workspace *mlopsv1alpha1.Workspace) (*ray.RayJob, error) {
+	logger := log.FromContext(ctx).WithValues(
+		"trainingJob", trainingJob.GetName(),
+		"namespace", trainingJob.GetNamespace())
+
+	rayJob := &ray.RayJob{}
+
+	err := r.Get(ctx, client.ObjectKeyFromObject(trainingJob), rayJob)
+
+	if err == nil {
+		return rayJob, nil
+	}
+
+	if !errors.IsNotFound(err) {
+		logger.Error(err, "Failed to get the RayJob for the training job")
+		return nil, err
+	}
+
+	rayJob, err = newRayJob(trainingJob, workspace)
+
+	if err != nil {
+		logger.Error(err, "Failed to render the RayJob for the training job")
+		return nil, err
+	}
+
+	if err := controllerutil.SetControllerReference(trainingJob, rayJob, r.Scheme); err != nil {
+		logger.Error(err, "Failed to set the owner of the RayJob")
+		return nil, err
+	}
+
+	if err := r.Create(ctx, rayJob); err != nil {
+		logger.Error(err, "Failed to create the RayJob for the training job")
+		return nil, err
+	}
+
+	r.Recorder.Eventf(trainingJob, corev1.EventTypeNormal, "Submitted",
+		"Submitted the training job to ray as RayJob %s", rayJob.GetName())
+
+	return rayJob, nil
+}
+
+func (r *TrainingJobReconciler) updateTrainingJobStatus(ctx context.Context, trainingJob *mlopsv1alpha1.TrainingJob, originalStatus *mlopsv1alpha1.TrainingJobStatus) error {
+	logger := log.FromContext(ctx).WithValues(
+		"trainingJob", trainingJob.GetName(),
+		"namespace", trainingJob.GetNamespace())
+
+	if reflect.DeepEqual(originalStatus, &trainingJob.Status) {
+		return nil
+	}
+
+	if err := r.Status().Update(ctx, trainingJob); err != nil {
+		logger.Error(err, "Failed to update the status of the training job")
+		return err
+	}
+
+	return nil
+}
+
+// setTrainingJobStatus copies the state of the RayJob to the status of the training job.
+func setTrainingJobStatus(trainingJob *mlopsv1alpha1.TrainingJob, rayJob *ray.RayJob) {
+	phases := map[ray.JobStatus]mlopsv1alpha1.TrainingJobPhase{
+		ray.JobStatusPending:   mlopsv1alpha1.TrainingJobPhasePending,
+		ray.JobStatusRunning:   mlopsv1alpha1.TrainingJobPhaseRunning,
+		ray.JobStatusSucceeded: mlopsv1alpha1.TrainingJobPhaseSucceeded,
+		ray.JobStatusFailed:    mlopsv1alpha1.TrainingJobPhaseFailed,
+		ray.JobStatusStopped:   mlopsv1alpha1.TrainingJobPhaseStopped,
+	}
+
+	phase, ok := phases[rayJob.Status.JobStatus]
+
+	if !ok {
+		phase = mlopsv1alpha1.TrainingJobPhasePending
+	}
+
+	trainingJob.Status.Phase = phase
+	trainingJob.Status.JobID = rayJob.Status.JobId
+	trainingJob.Status.RayClusterName = rayJob.Status.RayClusterName
+	trainingJob.Status.Message = rayJob.Status.Message
+	trainingJob.Status.StartTime = rayJob.Status.StartTime
+
+	if phase.IsFinished() {
+		trainingJob.Status.CompletionTime = rayJob.Status.EndTime
+	}
+
+	if trainingJob.Status.Message == "" && rayJob.Status.JobDeploymentStatus != "" {
+		trainingJob.Status.Message = fmt.Sprintf("RayJob %s is %s", rayJob.GetName(), rayJob.Status.JobDeploymentStatus)
+	}
+}
+
+// newRayJob creates the RayJob for a training job. Jobs on the shared cluster select it by the name KubeRay labels it with,
+// jobs on an ephemeral cluster get a copy of the compute cluster of the workspace that's removed when the job finishes.
+// The files of a working directory from a config map are mounted in every pod, so the tasks on the workers can import them too.
+func newRayJob(trainingJob *mlopsv1alpha1.TrainingJob, workspace *mlopsv1alpha1.Workspace) (*ray.RayJob, error) {
+	runtimeEnvironment, err := newRuntimeEnvironment(trainingJob)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rayJob := &ray.RayJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      trainingJob.GetName(),
+			Namespace: trainingJob.GetNamespace(),
+			Labels: map[string]string{
+				"mlops.aigency.com/workspace":    workspace.GetName(),
+				"mlops.aigency.com/training-job": trainingJob.GetName(),
+			},
+		},
+		Spec: ray.RayJobSpec{
+			Entrypoint: trainingJob.Spec.Entrypoint,
+			RuntimeEnv: runtimeEnvironment,
+			Metadata: map[string]string{
+				"trainingJob": trainingJob.GetName(),
+				"mlflowRunId": trainingJob.Status.RunID,
+			},
+		},
+	}
+
+	if trainingJob.Spec.Cluster != mlopsv1alpha1.TrainingJobClusterModeEphemeral {
+		rayJob.Spec.ClusterSelector = map[string]string{"ray.io/cluster": newRayClusterName(workspace)}
+		return rayJob, nil
+	}
+
+	rayJob.Spec.ShutdownAfterJobFinishes = true
+	rayJob.Spec.RayClusterSpec = newStandaloneRayClusterSpec(workspace)
+
+	if workingDirectory := trainingJob.Spec.RuntimeEnvironment.WorkingDirectory; workingDirectory != nil && workingDirectory.ConfigMapRef != nil {
+		mountConfigMapDirectory(rayJob.Spec.RayClusterSpec, "working-directory", workingDirectory.ConfigMapRef.Name, trainingJobWorkingDirectory)
+		rayJob.Spec.Entrypoint = fmt.Sprintf("cd %s && %s", trainingJobWorkingDirectory, trainingJob.Spec.Entrypoint)
+	}
+
+	return rayJob, nil
+}
+
+// newRuntimeEnvironment creates the runtime environment of the job in the base64 encoded JSON format KubeRay expects.
+func newRuntimeEnvironment(trainingJob *mlopsv1alpha1.TrainingJob) (string, error) {
+	runtimeEnvironmentSpec := trainingJob.Spec.RuntimeEnvironment
+
+	envVars := map[string]string{}
+
+	for name, value := range runtimeEnvironmentSpec.EnvironmentVariables {
+		envVars[name] = value
+	}
+
+	envVars["MLFLOW_EXPERIMENT_NAME"] = trainingJob.Spec.ExperimentName
+	envVars["MLFLOW_RUN_ID"] = trainingJob.Status.RunID
+
+	runtimeEnvironment := map[string]interface{}{
+		"env_vars": envVars,
+	}
+
+	if len(runtimeEnvironmentSpec.PipPackages) > 0 {
+		runtimeEnvironment["pip"] = runtimeEnvironmentSpec.PipPackages
+	}
+
+	if workingDirectory := runtimeEnvironmentSpec.WorkingDirectory; workingDirectory != nil && workingDirectory.URL != "" {
+		runtimeEnvironment["working_dir"] = workingDirectory.URL
+	}
+
+	runtimeEnvironmentJSON, err := json.Marshal(runtimeEnvironment)
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(runtimeEnvironmentJSON), nil
+}
+
+// findTrainingJobsForWorkspace maps a workspace to the training jobs that run in it,
+// so jobs waiting for the workspace continue when it becomes ready.
+func (r *TrainingJobReconciler) findTrainingJobsForWorkspace(workspace client.Object) []reconcile.Request {
+	trainingJobs := &mlopsv1alpha1.TrainingJobList{}
+
+	listOptions := []client.ListOption{
+		client.InNamespace(workspace.GetNamespace()),
+		client.MatchingFields{trainingJobWorkspaceIndexKey: workspace.GetName()},
+	}
+
+	if err := r.List(context.Background(), trainingJobs, listOptions...); err != nil {
+		return []reconcile.Request{}
+	}
+
+	requests := []reconcile.Request{}
+
+	for _, trainingJob := range trainingJobs.Items {
+		if trainingJob.Status.Phase.IsFinished() {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: trainingJob.GetName(), Namespace: trainingJob.GetNamespace()},
+		})
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *TrainingJobReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.tracker == nil {
+		r.tracker = newMLflowClient()
+	}
+
+	indexWorkspace := func(object client.Object) []string {
+		return []string{object.(*mlopsv1alpha1.TrainingJob).Spec.Workspace}
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &mlopsv1alpha1.TrainingJob{}, trainingJobWorkspaceIndexKey, indexWorkspace); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&mlopsv1alpha1.TrainingJob{}).
+		Owns(&ray.RayJob{}).
+		Watches(
+			&source.Kind{Type: &mlopsv1alpha1.Workspace{}},
+			handler.EnqueueRequestsFromMapFunc(r.findTrainingJobsForWorkspace),
+		).
+		Complete(r)
+}
@@ -0,0 +1,297 @@
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	ray "github.com/ray-project/kuberay/ray-operator/apis/ray/v1alpha1"
+	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fakeMLflowClient records the runs of the training jobs and the versions of registered models in memory.
+type fakeMLflowClient struct {
+	mutex         sync.Mutex
+	runs          int
+	runTags       map[string]map[string]string
+	endedRuns     map[string]string
+	modelVersions map[string]string
+}
+
+var testMLflowClient = &fakeMLflowClient{
+	runTags:       map[string]map[string]string{},
+	endedRuns:     map[string]string{},
+	modelVersions: map[string]string{},
+}
+
+func (t *fakeMLflowClient) FindRun(ctx context.Context, workspace *mlopsv1alpha1.Workspace, experimentName string, tags map[string]string) (experimentRun, bool, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for runID, runTags := range t.runTags {
+		if reflect.DeepEqual(runTags, tags) {
+			return experimentRun{experimentID: "1", runID: runID}, true, nil
+		}
+	}
+
+	return experimentRun{}, false, nil
+}
+
+func (t *fakeMLflowClient) CreateRun(ctx context.Context, workspace *mlopsv1alpha1.Workspace, experimentName string, runName string, tags map[string]string) (experimentRun, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.runs++
+	runID := fmt.Sprintf("run-%d", t.runs)
+	t.runTags[runID] = tags
+
+	return experimentRun{experimentID: "1", runID: runID}, nil
+}
+
+func (t *fakeMLflowClient) addRun(runID string, tags map[string]string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.runTags[runID] = tags
+}
+
+func (t *fakeMLflowClient) EndRun(ctx context.Context, workspace *mlopsv1alpha1.Workspace, runID string, status string, endTime time.Time) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.endedRuns[runID] = status
+
+	return nil
+}
+
+func (t *fakeMLflowClient) getRunStatus(runID string) string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.endedRuns[runID]
+}
+
+func (t *fakeMLflowClient) GetLatestModelVersion(ctx context.Context, workspace *mlopsv1alpha1.Workspace, modelName string, stage string) (string, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.modelVersions[modelName+"/"+stage], nil
+}
+
+func (t *fakeMLflowClient) GetServerVersion(ctx context.Context, workspace *mlopsv1alpha1.Workspace) (string, error) {
+	return "2.1.1", nil
+}
+
+func (t *fakeMLflowClient) setModelVersion(modelName string, stage string, version string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.modelVersions[modelName+"/"+stage] = version
+}
+
+var _ = Describe("TrainingJobReconciler", func() {
+	It("Should submit the training job to the compute cluster of the workspace", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-training-job")
+
+		trainingJob := newTestTrainingJob(workspace, "test-training-job-shared")
+		trainingJob.Spec.RuntimeEnvironment = mlopsv1alpha1.RuntimeEnvironmentSpec{
+			PipPackages:      []string{"scikit-learn"},
+			WorkingDirectory: &mlopsv1alpha1.WorkingDirectorySpec{URL: "s3://jobs/train.zip"},
+		}
+
+		Expect(k8sClient.Create(ctx, trainingJob)).To(Succeed())
+
+		rayJob := getRayJob(ctx, trainingJob)
+
+		Expect(rayJob.Spec.Entrypoint).To(Equal("python train.py"))
+		Expect(rayJob.Spec.ClusterSelector).To(HaveKeyWithValue("ray.io/cluster", "test-training-job-ray"))
+		Expect(rayJob.Spec.RayClusterSpec).To(BeNil())
+		Expect(rayJob.OwnerReferences).To(HaveLen(1))
+
+		trainingJob = getTrainingJob(ctx, trainingJob)
+		Expect(trainingJob.Status.RunID).NotTo(BeEmpty())
+
+		runtimeEnvironment := decodeRuntimeEnvironment(rayJob)
+
+		Expect(runtimeEnvironment).To(HaveKeyWithValue("pip", ConsistOf("scikit-learn")))
+		Expect(runtimeEnvironment).To(HaveKeyWithValue("working_dir", "s3://jobs/train.zip"))
+		Expect(runtimeEnvironment["env_vars"]).To(HaveKeyWithValue("MLFLOW_EXPERIMENT_NAME", "Default"))
+		Expect(runtimeEnvironment["env_vars"]).To(HaveKeyWithValue("MLFLOW_RUN_ID", trainingJob.Status.RunID))
+	})
+
+	It("Should run the training job on an ephemeral cluster", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-training-job-ephemeral")
+
+		trainingJob := newTestTrainingJob(workspace, "test-training-job-ephemeral")
+		trainingJob.Spec.Cluster = mlopsv1alpha1.TrainingJobClusterModeEphemeral
+		trainingJob.Spec.RuntimeEnvironment.WorkingDirectory = &mlopsv1alpha1.WorkingDirectorySpec{
+			ConfigMapRef: &corev1.LocalObjectReference{Name: "training-code"},
+		}
+
+		Expect(k8sClient.Create(ctx, trainingJob)).To(Succeed())
+
+		rayJob := getRayJob(ctx, trainingJob)
+
+		Expect(rayJob.Spec.ShutdownAfterJobFinishes).To(BeTrue())
+		Expect(rayJob.Spec.ClusterSelector).To(BeEmpty())
+		Expect(rayJob.Spec.Entrypoint).To(Equal("cd /opt/training-job && python train.py"))
+
+		headContainer := rayJob.Spec.RayClusterSpec.HeadGroupSpec.Template.Spec.Containers[0]
+
+		Expect(headContainer.Env).To(ContainElement(corev1.EnvVar{Name: "MLFLOW_TRACKING_URI", Value: "http://test-training-job-ephemeral-mlflow-server:5000"}))
+		Expect(headContainer.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+			Name:      "working-directory",
+			MountPath: "/opt/training-job",
+			ReadOnly:  true,
+		}))
+	})
+
+	It("Should report the state of the job and end the MLflow run", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-training-job-status")
+
+		trainingJob := newTestTrainingJob(workspace, "test-training-job-status")
+		Expect(k8sClient.Create(ctx, trainingJob)).To(Succeed())
+
+		rayJob := getRayJob(ctx, trainingJob)
+
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(rayJob), rayJob); err != nil {
+				return err
+			}
+
+			rayJob.Status.JobId = "test-training-job-status-abc"
+			rayJob.Status.JobStatus = ray.JobStatusSucceeded
+			rayJob.Status.JobDeploymentStatus = ray.JobDeploymentStatusComplete
+			rayJob.Status.EndTime = &metav1.Time{Time: time.Now()}
+
+			return k8sClient.Status().Update(ctx, rayJob)
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() (mlopsv1alpha1.TrainingJobPhase, error) {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(trainingJob), trainingJob); err != nil {
+				return "", err
+			}
+
+			return trainingJob.Status.Phase, nil
+		}, time.Minute, time.Second).Should(Equal(mlopsv1alpha1.TrainingJobPhaseSucceeded))
+
+		Expect(trainingJob.Status.JobID).To(Equal("test-training-job-status-abc"))
+		Expect(testMLflowClient.getRunStatus(trainingJob.Status.RunID)).To(Equal("FINISHED"))
+	})
+
+	It("Should reuse the MLflow run of an earlier attempt", func() {
+		ctx := context.Background()
+		workspace := newTestWorkspace("test-training-job-existing-run")
+
+		trainingJob := newTestTrainingJob(workspace, "test-training-job-existing-run")
+		Expect(k8sClient.Create(ctx, trainingJob)).To(Succeed())
+
+		// The run is created before the status is stored, so a failed status update leaves a run behind.
+		testMLflowClient.addRun("run-existing", map[string]string{
+			"mlops.aigency.com/training-job":     trainingJob.GetName(),
+			"mlops.aigency.com/training-job-uid": string(trainingJob.GetUID()),
+		})
+
+		createWorkspaceAndWaitForRayCluster(ctx, workspace.GetName())
+		getRayJob(ctx, trainingJob)
+
+		Expect(getTrainingJob(ctx, trainingJob).Status.RunID).To(Equal("run-existing"))
+	})
+
+	It("Should kill the MLflow run when the training job is removed", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForRayCluster(ctx, "test-training-job-removed")
+
+		trainingJob := newTestTrainingJob(workspace, "test-training-job-removed")
+		Expect(k8sClient.Create(ctx, trainingJob)).To(Succeed())
+
+		getRayJob(ctx, trainingJob)
+		runID := getTrainingJob(ctx, trainingJob).Status.RunID
+
+		Expect(k8sClient.Delete(ctx, trainingJob)).To(Succeed())
+
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(trainingJob), &mlopsv1alpha1.TrainingJob{})
+			return errors.IsNotFound(err)
+		}, time.Minute, time.Second).Should(BeTrue())
+
+		Expect(testMLflowClient.getRunStatus(runID)).To(Equal("KILLED"))
+	})
+
+	It("Should wait for the workspace", func() {
+		ctx := context.Background()
+		workspace := newTestWorkspace("test-training-job-missing")
+
+		trainingJob := newTestTrainingJob(workspace, "test-training-job-missing")
+		Expect(k8sClient.Create(ctx, trainingJob)).To(Succeed())
+
+		Eventually(func() (string, error) {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(trainingJob), trainingJob); err != nil {
+				return "", err
+			}
+
+			return trainingJob.Status.Message, nil
+		}, time.Minute, time.Second).Should(Equal("Workspace test-training-job-missing does not exist"))
+
+		Expect(trainingJob.Status.Phase).To(Equal(mlopsv1alpha1.TrainingJobPhasePending))
+	})
+})
+
+func newTestTrainingJob(workspace *mlopsv1alpha1.Workspace, name string) *mlopsv1alpha1.TrainingJob {
+	return &mlopsv1alpha1.TrainingJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: workspace.GetNamespace(),
+		},
+		Spec: mlopsv1alpha1.TrainingJobSpec{
+			Workspace:      workspace.GetName(),
+			Entrypoint:     "python train.py",
+			ExperimentName: mlopsv1alpha1.DefaultExperimentName,
+		},
+	}
+}
+
+func getRayJob(ctx context.Context, trainingJob *mlopsv1alpha1.TrainingJob) *ray.RayJob {
+	rayJob := &ray.RayJob{}
+
+	Eventually(func() error {
+		return k8sClient.Get(ctx, types.NamespacedName{Name: trainingJob.GetName(), Namespace: trainingJob.GetNamespace()}, rayJob)
+	}, time.Minute, time.Second).Should(Succeed())
+
+	return rayJob
+}
+
+func getTrainingJob(ctx context.Context, trainingJob *mlopsv1alpha1.TrainingJob) *mlopsv1alpha1.TrainingJob {
+	current := &mlopsv1alpha1.TrainingJob{}
+
+	Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(trainingJob), current)).To(Succeed())
+
+	return current
+}
+
+func decodeRuntimeEnvironment(rayJob *ray.RayJob) map[string]interface{} {
+	runtimeEnvironmentJSON, err := base64.StdEncoding.DecodeString(rayJob.Spec.RuntimeEnv)
+	Expect(err).NotTo(HaveOccurred())
+
+	runtimeEnvironment := map[string]interface{}{}
+	Expect(json.Unmarshal(runtimeEnvironmentJSON, &runtimeEnvironment)).To(Succeed())
+
+	return runtimeEnvironment
+}
@@ -1,12 +1,61 @@
 package controllers
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
 	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 )
 
+// fieldManager identifies the operator as the owner of the fields it renders when using server-side apply.
+const fieldManager = "cartographer"
+
+const (
+	// workloadUserID is the user the containers run as. The images of the workspace components use this user too.
+	workloadUserID = 1000
+
+	// temporaryDirectory is the writable directory of the containers, which have a read-only root filesystem.
+	temporaryDirectory = "/tmp"
+)
+
+// applyResource brings a resource in the cluster in line with its rendered desired state using server-side apply.
+// The operator only takes ownership of the fields present in the rendered resource.
+// Fields set by other tools are left alone, unless the operator renders them too.
+// The workspace becomes the controller of the resource so it is removed together with the workspace.
+func (r *WorkspaceReconciler) applyResource(ctx context.Context, workspace *mlopsv1alpha1.Workspace, resource client.Object) error {
+	return applyOwnedResource(ctx, r.Client, r.Scheme, workspace, resource)
+}
+
+// applyOwnedResource applies a resource with server-side apply and makes the owner its controller.
+// A resource version on the resource makes the apply fail with a conflict when the resource changed since it was read.
+func applyOwnedResource(ctx context.Context, c client.Client, scheme *runtime.Scheme, owner client.Object, resource client.Object) error {
+	if err := ctrl.SetControllerReference(owner, resource, scheme); err != nil {
+		return err
+	}
+
+	// Server-side apply requires the apiVersion and kind to be present in the request.
+	groupVersionKind, err := apiutil.GVKForObject(resource, scheme)
+
+	if err != nil {
+		return err
+	}
+
+	resource.GetObjectKind().SetGroupVersionKind(groupVersionKind)
+	resource.SetManagedFields(nil)
+
+	return c.Patch(ctx, resource, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership)
+}
+
 func newDatabaseSecretEnvVars(databaseSecretName string) []corev1.EnvVar {
 	return []corev1.EnvVar{
 		{
@@ -67,6 +116,20 @@ func newDatabaseSecretEnvVars(databaseSecretName string) []corev1.EnvVar {
 	}
 }
 
+func newSecretEnvVar(name string, secretName string, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				Key: key,
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: secretName,
+				},
+			},
+		},
+	}
+}
+
 func newComponentLabels(workspace *mlopsv1alpha1.Workspace, componentName string) map[string]string {
 	return map[string]string{
 		"mlops.aigency.com/environment": workspace.GetName(),
@@ -80,11 +143,87 @@ func newContainer(name string, image string, resources corev1.ResourceRequiremen
 		Image:           image,
 		ImagePullPolicy: corev1.PullIfNotPresent,
 		Resources:       resources,
+		SecurityContext: newSecurityContext(),
+	}
+}
+
+// newSecurityContext returns the security context that satisfies the restricted Pod Security Standard.
+func newSecurityContext() *corev1.SecurityContext {
+	return &corev1.SecurityContext{
+		AllowPrivilegeEscalation: pointer.Bool(false),
+		ReadOnlyRootFilesystem:   pointer.Bool(true),
+		RunAsNonRoot:             pointer.Bool(true),
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+}
+
+// newPodSecurityContext runs the pods as an unprivileged user. The file system group gives that user
+// write access to the mounted volumes.
+func newPodSecurityContext() *corev1.PodSecurityContext {
+	return &corev1.PodSecurityContext{
+		RunAsNonRoot: pointer.Bool(true),
+		RunAsUser:    pointer.Int64(workloadUserID),
+		RunAsGroup:   pointer.Int64(workloadUserID),
+		FSGroup:      pointer.Int64(workloadUserID),
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+}
+
+// hardenPodSpec prepares a pod for the restricted Pod Security Standard. The root filesystem of the containers
+// is read-only, so every container gets a writable temporary directory that is also its home directory.
+func hardenPodSpec(podSpec *corev1.PodSpec) {
+	podSpec.SecurityContext = newPodSecurityContext()
+
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "tmp",
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for index := range containers {
+			hardenContainer(&containers[index])
+		}
+	}
+}
+
+// hardenContainer mounts the writable temporary directory of the pod in a container and uses it as the home directory.
+// Containers added after hardenPodSpec was called need this too.
+func hardenContainer(container *corev1.Container) {
+	if container.SecurityContext == nil {
+		container.SecurityContext = newSecurityContext()
 	}
+
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      "tmp",
+		MountPath: temporaryDirectory,
+	})
+
+	container.Env = append(container.Env, corev1.EnvVar{
+		Name:  "HOME",
+		Value: temporaryDirectory,
+	})
+}
+
+// newPodTemplateHash returns a short hash of a pod template, for workloads like jobs that can't change their pod template.
+func newPodTemplateHash(template *corev1.PodTemplateSpec) string {
+	// A pod template only contains types that marshal to JSON, so there's no error to handle.
+	templateJSON, _ := json.Marshal(template)
+	templateHash := sha256.Sum256(templateJSON)
+
+	return hex.EncodeToString(templateHash[:])[:8]
 }
 
 func newDeployment(namespaceName string, deploymentName string, deploymentLabels map[string]string, replicas *int32, container corev1.Container) *appsv1.Deployment {
-	return &appsv1.Deployment{
+	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      deploymentName,
 			Namespace: namespaceName,
@@ -107,10 +246,13 @@ func newDeployment(namespaceName string, deploymentName string, deploymentLabels
 			},
 		},
 	}
+	hardenPodSpec(&deployment.Spec.Template.Spec)
+
+	return deployment
 }
 
 func newStatefulSet(namespaceName string, statefulSetName string, statefulSetLabels map[string]string, replicas *int32, container corev1.Container) *appsv1.StatefulSet {
-	return &appsv1.StatefulSet{
+	statefulSet := &appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      statefulSetName,
 			Namespace: namespaceName,
@@ -133,6 +275,9 @@ func newStatefulSet(namespaceName string, statefulSetName string, statefulSetLab
 			},
 		},
 	}
+	hardenPodSpec(&statefulSet.Spec.Template.Spec)
+
+	return statefulSet
 }
 
 func newService(name string, namespace string, serviceLabels map[string]string) *corev1.Service {
@@ -140,6 +285,7 @@ func newService(name string, namespace string, serviceLabels map[string]string)
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: namespace,
+			Labels:    serviceLabels,
 		},
 		Spec: corev1.ServiceSpec{
 			Type:     corev1.ServiceTypeClusterIP,
@@ -147,3 +293,35 @@ func newService(name string, namespace string, serviceLabels map[string]string)
 		},
 	}
 }
+
+// applyScheduling places the pods of a component on the nodes configured in the scheduling spec.
+// Topology spread constraints without a label selector spread the pods with the given labels.
+func applyScheduling(podSpec *corev1.PodSpec, podLabels map[string]string, schedulingSpec mlopsv1alpha1.SchedulingSpec) {
+	podSpec.NodeSelector = schedulingSpec.NodeSelector
+	podSpec.Tolerations = schedulingSpec.Tolerations
+	podSpec.Affinity = schedulingSpec.Affinity
+	podSpec.PriorityClassName = schedulingSpec.PriorityClassName
+	podSpec.TopologySpreadConstraints = nil
+
+	for _, constraint := range schedulingSpec.TopologySpreadConstraints {
+		if constraint.LabelSelector == nil {
+			constraint.LabelSelector = &metav1.LabelSelector{MatchLabels: podLabels}
+		}
+
+		podSpec.TopologySpreadConstraints = append(podSpec.TopologySpreadConstraints, constraint)
+	}
+}
+
+// newServiceAccount creates the service account for the pods of a component.
+// Every component runs as its own service account, so cloud workload identity can grant access per component.
+func newServiceAccount(namespaceName string, serviceAccountName string, serviceAccountLabels map[string]string, serviceAccountSpec mlopsv1alpha1.ServiceAccountSpec) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        serviceAccountName,
+			Namespace:   namespaceName,
+			Labels:      serviceAccountLabels,
+			Annotations: serviceAccountSpec.Annotations,
+		},
+		ImagePullSecrets: serviceAccountSpec.ImagePullSecrets,
+	}
+}
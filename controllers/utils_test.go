@@ -1,13 +1,131 @@
 package controllers
 
 import (
+	"context"
+	"fmt"
+	"time"
+
+	postgres "github.com/crunchydata/postgres-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
+	. "github.com/onsi/gomega"
 	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// updateWorkspace applies changes to the latest version of the workspace.
+// The operator updates the status of the workspace, so we need to retry when the workspace changed in the meantime.
+func updateWorkspace(ctx context.Context, workspace *mlopsv1alpha1.Workspace, mutate func(*mlopsv1alpha1.Workspace)) {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(workspace), workspace); err != nil {
+			return err
+		}
+
+		mutate(workspace)
+
+		return k8sClient.Update(ctx, workspace)
+	})
+
+	Expect(err).NotTo(HaveOccurred())
+}
+
+// simulateDatabaseReady marks the postgres cluster of the workspace as ready and creates the secrets for the
+// database users. There's no postgres operator in the test environment, so we have to do this ourselves.
+func simulateDatabaseReady(ctx context.Context, workspace *mlopsv1alpha1.Workspace) {
+	cluster := &postgres.PostgresCluster{}
+
+	Eventually(func() error {
+		return k8sClient.Get(ctx, client.ObjectKeyFromObject(workspace), cluster)
+	}, time.Minute, time.Second).Should(Succeed())
+
+	for _, user := range cluster.Spec.Users {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-pguser-%s", workspace.GetName(), user.Name),
+				Namespace: workspace.GetNamespace(),
+			},
+			StringData: map[string]string{
+				"host":     fmt.Sprintf("%s-primary", workspace.GetName()),
+				"port":     "5432",
+				"user":     string(user.Name),
+				"password": "test",
+				"dbname":   string(user.Databases[0]),
+				"uri":      fmt.Sprintf("postgresql://%s:test@%s-primary:5432/%s", user.Name, workspace.GetName(), user.Databases[0]),
+			},
+		}
+
+		Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(workspace), cluster); err != nil {
+			return err
+		}
+
+		cluster.Status.InstanceSets = []postgres.PostgresInstanceSetStatus{
+			{Name: "db01", Replicas: 1, ReadyReplicas: 1},
+		}
+
+		return k8sClient.Status().Update(ctx, cluster)
+	})
+
+	Expect(err).NotTo(HaveOccurred())
+}
+
+// simulateDeploymentAvailable marks a deployment as available.
+// There's no deployment controller in the test environment, so we have to do this ourselves.
+func simulateDeploymentAvailable(ctx context.Context, namespace string, name string) {
+	deployment := &appsv1.Deployment{}
+	deploymentName := types.NamespacedName{Name: name, Namespace: namespace}
+
+	Eventually(func() error {
+		return k8sClient.Get(ctx, deploymentName, deployment)
+	}, time.Minute, time.Second).Should(Succeed())
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := k8sClient.Get(ctx, deploymentName, deployment); err != nil {
+			return err
+		}
+
+		deployment.Status.Replicas = *deployment.Spec.Replicas
+		deployment.Status.ReadyReplicas = *deployment.Spec.Replicas
+		deployment.Status.AvailableReplicas = *deployment.Spec.Replicas
+
+		return k8sClient.Status().Update(ctx, deployment)
+	})
+
+	Expect(err).NotTo(HaveOccurred())
+}
+
+// simulateStatefulSetReady marks all replicas of a stateful set as ready.
+// There's no stateful set controller in the test environment, so we have to do this ourselves.
+func simulateStatefulSetReady(ctx context.Context, namespace string, name string) {
+	statefulSet := &appsv1.StatefulSet{}
+	statefulSetName := types.NamespacedName{Name: name, Namespace: namespace}
+
+	Eventually(func() error {
+		return k8sClient.Get(ctx, statefulSetName, statefulSet)
+	}, time.Minute, time.Second).Should(Succeed())
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := k8sClient.Get(ctx, statefulSetName, statefulSet); err != nil {
+			return err
+		}
+
+		statefulSet.Status.Replicas = *statefulSet.Spec.Replicas
+		statefulSet.Status.ReadyReplicas = *statefulSet.Spec.Replicas
+
+		return k8sClient.Status().Update(ctx, statefulSet)
+	})
+
+	Expect(err).NotTo(HaveOccurred())
+}
+
 func newTestWorkspace(workspaceName string) *mlopsv1alpha1.Workspace {
 	return &mlopsv1alpha1.Workspace{
 		ObjectMeta: metav1.ObjectMeta{
@@ -18,6 +136,7 @@ func newTestWorkspace(workspaceName string) *mlopsv1alpha1.Workspace {
 			Storage: mlopsv1alpha1.WorkspaceStorageSpec{
 				DatabaseStorage:       resource.MustParse("1Gi"),
 				DatabaseBackupStorage: resource.MustParse("1Gi"),
+				ObjectStorage:         resource.MustParse("1Gi"),
 			},
 			Workflows: mlopsv1alpha1.WorkflowComponentSpec{
 				Controller: mlopsv1alpha1.WorkflowControllerSpec{
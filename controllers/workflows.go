@@ -3,189 +3,136 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"reflect"
 
-	"github.com/go-logr/logr"
 	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-func (r *WorkspaceReconciler) reconcileWorkflowServer(ctx context.Context, workspace *mlopsv1alpha1.Workspace) error {
-	logger := log.FromContext(ctx).WithValues(
-		"workspace", workspace.GetName(),
-		"namespace", workspace.GetNamespace())
-
-	if err := r.reconcileWorkflowServerDeployment(ctx, logger, workspace); err != nil {
-		return err
-	}
-
-	if err := r.reconcileWorkflowServerService(ctx, logger, workspace); err != nil {
-		return err
-	}
-
-	if err := r.reconcileWorkflowAgents(ctx, logger, workspace); err != nil {
-		return err
-	}
-
-	return nil
+const workflowsComponentName = "workflows"
+
+// workflowDatabaseMigrationScript copies the workflow state from the experiment tracking database to the workflow database.
+// It runs before the workflow server starts, after the schema of the workflow database was created. Only the tables of the
+// workflow database are copied, so the MLflow tables stay behind. The rows the workflow server seeds on startup, like the
+// block types, are replaced by the rows from the experiment tracking database in the same transaction, so the copied
+// rows keep referring to the right ids. Nothing is copied when the workflow database already contains flows,
+// so the migration doesn't duplicate data when the pod restarts. The dump lists the columns of every table, so columns
+// that the current Prefect version added to the schema keep their defaults.
+const workflowDatabaseMigrationScript = `set -e
+if [ "$(psql "$SOURCE_URI" -tAc "SELECT to_regclass('public.flow') IS NOT NULL")" != "t" ]; then
+  echo "There's no workflow state in the experiment tracking database"
+  exit 0
+fi
+if [ "$(psql "$TARGET_URI" -tAc 'SELECT count(*) FROM flow')" != "0" ]; then
+  echo "The workflow database already contains workflow state"
+  exit 0
+fi
+WORKFLOW_TABLES="FROM pg_tables WHERE schemaname = 'public' AND tablename <> 'alembic_version'"
+TRUNCATE_TABLES="$(psql "$TARGET_URI" -tAc "SELECT string_agg(format('public.%I', tablename), ', ') $WORKFLOW_TABLES")"
+DUMP_TABLES="$(psql "$TARGET_URI" -tAc "SELECT string_agg(format('--table=public.%I', tablename), ' ') $WORKFLOW_TABLES")"
+{
+  echo "TRUNCATE $TRUNCATE_TABLES CASCADE;"
+  pg_dump "$SOURCE_URI" --data-only --no-owner --no-privileges $DUMP_TABLES
+} | psql "$TARGET_URI" -v ON_ERROR_STOP=1 --single-transaction
+`
+
+// workflowsComponent manages the workflow server and the agent pools that run the workflows in the workspace.
+type workflowsComponent struct {
+	componentBase
 }
 
-func (r *WorkspaceReconciler) reconcileWorkflowServerDeployment(ctx context.Context, logger logr.Logger, workspace *mlopsv1alpha1.Workspace) error {
-	deploymentName := fmt.Sprintf("%s-orion-server", workspace.GetName())
-	deployment := &appsv1.Deployment{}
-
-	if err := r.Get(ctx, types.NamespacedName{Name: deploymentName, Namespace: workspace.GetNamespace()}, deployment); err != nil {
-		if errors.IsNotFound(err) {
-			return r.createWorkflowServerDeployment(ctx, workspace, logger)
-		}
-
-		logger.Error(err, "Failed to get deployment for workflow server")
-		return err
-	}
-
-	return r.updateWorkflowServerDeployment(ctx, deployment, workspace, logger)
+func (c *workflowsComponent) Name() string {
+	return workflowsComponentName
 }
 
-func (r *WorkspaceReconciler) updateWorkflowServerDeployment(ctx context.Context, deployment *appsv1.Deployment, workspace *mlopsv1alpha1.Workspace, logger logr.Logger) error {
-	deploymentChanged := false
-
-	if *deployment.Spec.Replicas != *workspace.Spec.Workflows.Controller.Replicas {
-		deployment.Spec.Replicas = workspace.Spec.Workflows.Controller.Replicas
-		deploymentChanged = true
-	}
-
-	if deployment.Spec.Template.Spec.Containers[0].Image != workspace.Spec.Workflows.Controller.Image {
-		deployment.Spec.Template.Spec.Containers[0].Image = workspace.Spec.Workflows.Controller.Image
-		deploymentChanged = true
-	}
-
-	if !reflect.DeepEqual(deployment.Spec.Template.Spec.Containers[0].Resources, workspace.Spec.Workflows.Controller.Resources) {
-		deployment.Spec.Template.Spec.Containers[0].Resources = workspace.Spec.Workflows.Controller.Resources
-		deploymentChanged = true
-	}
-
-	if deploymentChanged {
-		if err := r.Update(ctx, deployment); err != nil {
-			logger.Error(err, "Failed to update workflow controller deployment")
-			return err
-		}
-	}
-
-	return nil
+func (c *workflowsComponent) ConditionType() string {
+	return mlopsv1alpha1.ConditionTypeWorkflowsReady
 }
 
-func (r *WorkspaceReconciler) createWorkflowServerDeployment(ctx context.Context, workspace *mlopsv1alpha1.Workspace, logger logr.Logger) error {
-	deployment := newWorkflowServerDeployment(workspace)
+func (c *workflowsComponent) OwnedTypes() []client.Object {
+	return []client.Object{&appsv1.Deployment{}, &appsv1.StatefulSet{}, &corev1.Service{}, &corev1.ServiceAccount{}}
+}
 
-	if err := ctrl.SetControllerReference(workspace, deployment, r.Scheme); err != nil {
-		logger.Error(err, "Failed to set controller reference for workflow server deployment")
-		return err
+func (c *workflowsComponent) Render(workspace *mlopsv1alpha1.Workspace) ([]client.Object, error) {
+	resources := []client.Object{
+		newWorkflowServerServiceAccount(workspace),
+		newWorkflowServerDeployment(workspace),
+		newWorkflowServerService(workspace),
 	}
 
-	if err := r.Create(ctx, deployment); err != nil {
-		logger.Error(err, "Failed to create deployment for workflow server")
-		return err
+	for index := range workspace.Spec.Workflows.Agents {
+		resources = append(resources,
+			newWorkflowAgentPoolServiceAccount(workspace, &workspace.Spec.Workflows.Agents[index]),
+			newWorkflowAgentPoolStatefulSet(workspace, &workspace.Spec.Workflows.Agents[index]))
 	}
 
-	return nil
+	return resources, nil
 }
 
-func (r *WorkspaceReconciler) reconcileWorkflowServerService(ctx context.Context, logger logr.Logger, workspace *mlopsv1alpha1.Workspace) error {
-	serviceName := fmt.Sprintf("%s-orion-server", workspace.GetName())
-	serviceLabels := newComponentLabels(workspace, "workflow-server")
-
-	service := &corev1.Service{}
+func (c *workflowsComponent) Ready(ctx context.Context, workspace *mlopsv1alpha1.Workspace) (componentCondition, error) {
+	deploymentName := fmt.Sprintf("%s-orion-server", workspace.GetName())
+	deployment := &appsv1.Deployment{}
 
-	if err := r.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: workspace.GetNamespace()}, service); err != nil {
+	if err := c.reconciler.Get(ctx, types.NamespacedName{Name: deploymentName, Namespace: workspace.GetNamespace()}, deployment); err != nil {
 		if errors.IsNotFound(err) {
-			service = newService(serviceName, workspace.GetNamespace(), serviceLabels)
-
-			service.Spec.Ports = []corev1.ServicePort{
-				{
-					Name:       "http-orion",
-					Protocol:   corev1.ProtocolTCP,
-					Port:       4200,
-					TargetPort: intstr.FromInt(4200),
-				},
-			}
-
-			if err := ctrl.SetControllerReference(workspace, service, r.Scheme); err != nil {
-				logger.Error(err, "Failed to set controller reference workflow server service")
-				return err
-			}
-
-			if err := r.Create(ctx, service); err != nil {
-				logger.Error(err, "Failed to create service for workflow server")
-				return err
-			}
-
-			return nil
+			return componentCondition{reason: reasonNotFound, message: "The workflow server does not exist yet"}, nil
 		}
 
-		logger.Error(err, "Failed to get service for workflow server")
-		return err
+		return componentCondition{}, err
 	}
 
-	return nil
-}
+	if condition := newDeploymentCondition(deployment); !condition.ready {
+		return condition, nil
+	}
 
-func (r *WorkspaceReconciler) reconcileWorkflowAgents(ctx context.Context, logger logr.Logger, workspace *mlopsv1alpha1.Workspace) error {
 	for _, agentPoolSpec := range workspace.Spec.Workflows.Agents {
 		statefulSetName := fmt.Sprintf("%s-agent-%s", workspace.GetName(), agentPoolSpec.Name)
 		statefulSet := &appsv1.StatefulSet{}
 
-		if err := r.Get(ctx, types.NamespacedName{Name: statefulSetName, Namespace: workspace.GetNamespace()}, statefulSet); err != nil {
+		if err := c.reconciler.Get(ctx, types.NamespacedName{Name: statefulSetName, Namespace: workspace.GetNamespace()}, statefulSet); err != nil {
 			if errors.IsNotFound(err) {
-				return r.createWorkflowAgentPool(ctx, &agentPoolSpec, workspace, logger)
+				return componentCondition{
+					reason:  reasonNotFound,
+					message: fmt.Sprintf("The agent pool %s does not exist yet", agentPoolSpec.Name),
+				}, nil
 			}
 
-			logger.Error(err, "Failed to get statefulset for workflow agent pool")
-			return err
+			return componentCondition{}, err
 		}
 
-		if err := r.updateWorkflowAgentPool(ctx, statefulSet, agentPoolSpec, logger); err != nil {
-			return err
+		if condition := newStatefulSetCondition(statefulSet); !condition.ready {
+			return condition, nil
 		}
 	}
 
-	return nil
+	return componentCondition{ready: true, reason: reasonAvailable, message: "The workflow server and agent pools are ready"}, nil
 }
 
-func (r *WorkspaceReconciler) updateWorkflowAgentPool(ctx context.Context, statefulSet *appsv1.StatefulSet, agentPoolSpec mlopsv1alpha1.WorkflowAgentPoolSpec, logger logr.Logger) error {
-	statefulSetChanged := false
-
-	if *statefulSet.Spec.Replicas != *agentPoolSpec.Replicas {
-		statefulSet.Spec.Replicas = agentPoolSpec.Replicas
-		statefulSetChanged = true
-	}
+// Cleanup removes the agent pools that are no longer part of the workspace spec.
+func (c *workflowsComponent) Cleanup(ctx context.Context, workspace *mlopsv1alpha1.Workspace) error {
+	logger := log.FromContext(ctx).WithValues(
+		"workspace", workspace.GetName(),
+		"namespace", workspace.GetNamespace())
 
-	if statefulSet.Spec.Template.Spec.Containers[0].Image != agentPoolSpec.Image {
-		statefulSet.Spec.Template.Spec.Containers[0].Image = agentPoolSpec.Image
-		statefulSetChanged = true
-	}
+	poolNames := []string{}
 
-	if !reflect.DeepEqual(statefulSet.Spec.Template.Spec.Containers[0].Resources, agentPoolSpec.Resources) {
-		statefulSet.Spec.Template.Spec.Containers[0].Resources = agentPoolSpec.Resources
-		statefulSetChanged = true
+	for _, agentPoolSpec := range workspace.Spec.Workflows.Agents {
+		poolNames = append(poolNames, agentPoolSpec.Name)
 	}
 
-	if statefulSetChanged {
-		if err := r.Update(ctx, statefulSet); err != nil {
-			logger.Error(err, "Failed to update stateful set for workflow agent pool %s", agentPoolSpec.Name)
-			return err
-		}
+	if err := c.reconciler.pruneOwnedPoolResources(ctx, logger, workspace, &appsv1.StatefulSetList{}, "workflow-agent", poolNames); err != nil {
+		return err
 	}
 
-	return nil
+	return c.reconciler.pruneOwnedPoolResources(ctx, logger, workspace, &corev1.ServiceAccountList{}, "workflow-agent", poolNames)
 }
 
-func (r *WorkspaceReconciler) createWorkflowAgentPool(ctx context.Context, agentPoolSpec *mlopsv1alpha1.WorkflowAgentPoolSpec, workspace *mlopsv1alpha1.Workspace, logger logr.Logger) error {
+func newWorkflowAgentPoolStatefulSet(workspace *mlopsv1alpha1.Workspace, agentPoolSpec *mlopsv1alpha1.WorkflowAgentPoolSpec) *appsv1.StatefulSet {
 	statefulSetName := fmt.Sprintf("%s-agent-%s", workspace.GetName(), agentPoolSpec.Name)
 	statefulSetLabels := newComponentLabels(workspace, "workflow-agent")
 	statefulSetLabels["mlops.aigency.com/pool"] = agentPoolSpec.Name
@@ -200,43 +147,48 @@ func (r *WorkspaceReconciler) createWorkflowAgentPool(ctx context.Context, agent
 		agentPoolSpec.Name,
 	}
 
-	container.Env = []corev1.EnvVar{
-		{
-			Name:  "PREFECT_API_URL",
-			Value: fmt.Sprintf("http://%s-orion-server:4200/api", workspace.GetName()),
-		},
-		{
-			Name:  "MLFLOW_TRACKING_URI",
-			Value: fmt.Sprintf("http://%s-mlflow-server:5000", workspace.GetName()),
-		},
-		{
-			Name:  "QUEUE_NAME",
-			Value: agentPoolSpec.Name,
-		},
-	}
+	container.Env = append(newWorkspaceEndpoints(workspace).newEnvVars(), newObjectStorageCredentialEnvVars(workspace)...)
+	container.Env = append(container.Env, corev1.EnvVar{
+		Name:  "QUEUE_NAME",
+		Value: agentPoolSpec.Name,
+	})
 
 	statefulSet := newStatefulSet(workspace.GetNamespace(), statefulSetName, statefulSetLabels, agentPoolSpec.Replicas, container)
+	statefulSet.Spec.Template.Spec.ServiceAccountName = statefulSetName
+	applyScheduling(&statefulSet.Spec.Template.Spec, statefulSetLabels, agentPoolSpec.Scheduling)
 
-	if err := ctrl.SetControllerReference(workspace, statefulSet, r.Scheme); err != nil {
-		logger.Error(err, "Failed to set controller reference for stateful set %s", agentPoolSpec.Name)
-		return err
-	}
+	return statefulSet
+}
 
-	if err := r.Create(ctx, statefulSet); err != nil {
-		logger.Error(err, "Failed to create stateful set for agent pool %s", agentPoolSpec.Name)
-		return err
-	}
+func newWorkflowAgentPoolServiceAccount(workspace *mlopsv1alpha1.Workspace, agentPoolSpec *mlopsv1alpha1.WorkflowAgentPoolSpec) *corev1.ServiceAccount {
+	serviceAccountLabels := newComponentLabels(workspace, "workflow-agent")
+	serviceAccountLabels["mlops.aigency.com/pool"] = agentPoolSpec.Name
 
-	return nil
+	return newServiceAccount(
+		workspace.GetNamespace(),
+		fmt.Sprintf("%s-agent-%s", workspace.GetName(), agentPoolSpec.Name),
+		serviceAccountLabels,
+		agentPoolSpec.ServiceAccount,
+	)
+}
+
+func newWorkflowServerServiceAccount(workspace *mlopsv1alpha1.Workspace) *corev1.ServiceAccount {
+	return newServiceAccount(
+		workspace.GetNamespace(),
+		fmt.Sprintf("%s-orion-server", workspace.GetName()),
+		newComponentLabels(workspace, "workflow-server"),
+		workspace.Spec.Workflows.Controller.ServiceAccount,
+	)
 }
 
 func newWorkflowServerDeployment(workspace *mlopsv1alpha1.Workspace) *appsv1.Deployment {
 	deploymentName := fmt.Sprintf("%s-orion-server", workspace.GetName())
 	deploymentLabels := newComponentLabels(workspace, "workflow-server")
-	databaseSecretName := fmt.Sprintf("%s-pguser-mlflow", workspace.GetName())
+	databaseSecretName := newDatabaseSecretName(workspace, workflowsComponentName)
 
 	container := newContainer("orion", workspace.Spec.Workflows.Controller.Image, workspace.Spec.Workflows.Controller.Resources)
-	container.Env = newDatabaseSecretEnvVars(databaseSecretName)
+	container.Env = append(newDatabaseSecretEnvVars(databaseSecretName), newSecretEnvVar("DB_URI", databaseSecretName, "uri"))
+	container.Env = append(container.Env, newWorkflowsUIEnvVars(workspace)...)
 
 	container.Ports = []corev1.ContainerPort{
 		{
@@ -245,12 +197,6 @@ func newWorkflowServerDeployment(workspace *mlopsv1alpha1.Workspace) *appsv1.Dep
 		},
 	}
 
-	container.Command = []string{
-		"prefect",
-		"orion",
-		"start",
-	}
-
 	deployment := newDeployment(
 		workspace.GetNamespace(),
 		deploymentName,
@@ -259,5 +205,54 @@ func newWorkflowServerDeployment(workspace *mlopsv1alpha1.Workspace) *appsv1.Dep
 		container,
 	)
 
+	deployment.Spec.Template.Spec.ServiceAccountName = deploymentName
+	applyScheduling(&deployment.Spec.Template.Spec, deploymentLabels, workspace.Spec.Workflows.Controller.Scheduling)
+
+	if workspace.Spec.Workflows.MigrateLegacyDatabase {
+		deployment.Spec.Template.Spec.InitContainers = newWorkflowDatabaseMigrationContainers(workspace, container)
+	}
+
 	return deployment
 }
+
+func newWorkflowServerService(workspace *mlopsv1alpha1.Workspace) *corev1.Service {
+	serviceName := fmt.Sprintf("%s-orion-server", workspace.GetName())
+	serviceLabels := newComponentLabels(workspace, "workflow-server")
+
+	service := newService(serviceName, workspace.GetNamespace(), serviceLabels)
+
+	service.Spec.Ports = []corev1.ServicePort{
+		{
+			Name:       "http-orion",
+			Protocol:   corev1.ProtocolTCP,
+			Port:       4200,
+			TargetPort: intstr.FromInt(4200),
+		},
+	}
+
+	return service
+}
+
+// newWorkflowDatabaseMigrationContainers creates the init containers that move the workflow state from the experiment
+// tracking database to the workflow database, for workspaces created before each component had its own database.
+// The first container creates the schema with the workflow server image, the second one copies the data.
+func newWorkflowDatabaseMigrationContainers(workspace *mlopsv1alpha1.Workspace, serverContainer corev1.Container) []corev1.Container {
+	schemaContainer := newContainer("create-schema", serverContainer.Image, serverContainer.Resources)
+	schemaContainer.Args = []string{"sh", "/app/entrypoint.sh", "server", "database", "upgrade", "-y"}
+	schemaContainer.Env = append([]corev1.EnvVar{}, serverContainer.Env...)
+
+	migrationContainer := newContainer("migrate-database", postgresImage, corev1.ResourceRequirements{})
+	migrationContainer.Command = []string{"/bin/sh", "-c", workflowDatabaseMigrationScript}
+	migrationContainer.Env = []corev1.EnvVar{
+		newSecretEnvVar("SOURCE_URI", newDatabaseSecretName(workspace, experimentTrackingComponentName), "uri"),
+		newSecretEnvVar("TARGET_URI", newDatabaseSecretName(workspace, workflowsComponentName), "uri"),
+	}
+
+	initContainers := []corev1.Container{schemaContainer, migrationContainer}
+
+	for index := range initContainers {
+		hardenContainer(&initContainers[index])
+	}
+
+	return initContainers
+}
@@ -3,6 +3,10 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -10,6 +14,7 @@ import (
 	mlopsv1alpha1 "github.com/wmeints/cartographer/api/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/pointer"
@@ -24,6 +29,8 @@ var _ = Describe("reconcileWorkflowServer", Ordered, func() {
 		err := k8sClient.Create(ctx, workspace)
 		Expect(err).NotTo(HaveOccurred())
 
+		simulateDatabaseReady(ctx, workspace)
+
 		Eventually(func() error {
 			deployment := &appsv1.Deployment{}
 			deploymentName := types.NamespacedName{
@@ -86,16 +93,74 @@ var _ = Describe("reconcileWorkflowServer", Ordered, func() {
 		}, time.Minute, time.Second).Should(Succeed())
 	})
 
-	It("Should scale the workflow agents", func() {
+	It("Should connect the workflow server to the workflow database", func() {
 		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForAgentPoolDeployment(ctx, "test-workflows-database")
 
-		workspace := createWorkspaceAndWaitForAgentPoolDeployment(ctx, "test-workflow-agents-scale")
+		Eventually(func() error {
+			deployment, err := getWorkflowServerDeployment(workspace)
 
-		workspace.Spec.Workflows.Agents[0].Replicas = pointer.Int32(2)
+			if err != nil {
+				return err
+			}
+
+			expectedSecretName := fmt.Sprintf("%s-pguser-prefect", workspace.GetName())
+
+			for _, envVar := range deployment.Spec.Template.Spec.Containers[0].Env {
+				if envVar.ValueFrom == nil || envVar.ValueFrom.SecretKeyRef == nil {
+					continue
+				}
+
+				if envVar.ValueFrom.SecretKeyRef.Name != expectedSecretName {
+					return fmt.Errorf("expected %s to come from %s, got %s", envVar.Name, expectedSecretName, envVar.ValueFrom.SecretKeyRef.Name)
+				}
+			}
 
-		err := k8sClient.Update(ctx, workspace)
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+	})
+
+	It("Should migrate the workflow state from the experiment tracking database", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForAgentPoolDeployment(ctx, "test-workflows-migration")
+
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.Workflows.MigrateLegacyDatabase = true
+		})
+
+		Eventually(func() ([]string, error) {
+			deployment, err := getWorkflowServerDeployment(workspace)
+
+			if err != nil {
+				return nil, err
+			}
+
+			initContainerNames := []string{}
+
+			for _, initContainer := range deployment.Spec.Template.Spec.InitContainers {
+				initContainerNames = append(initContainerNames, initContainer.Name)
+			}
+
+			return initContainerNames, nil
+		}, time.Minute, time.Second).Should(Equal([]string{"create-schema", "migrate-database"}))
+
+		deployment, err := getWorkflowServerDeployment(workspace)
 		Expect(err).NotTo(HaveOccurred())
 
+		// The image builds the connection url of the workflow server, so the operator doesn't override its command.
+		Expect(deployment.Spec.Template.Spec.Containers[0].Command).To(BeEmpty())
+		Expect(deployment.Spec.Template.Spec.InitContainers[0].Args).To(Equal([]string{"sh", "/app/entrypoint.sh", "server", "database", "upgrade", "-y"}))
+	})
+
+	It("Should scale the workflow agents", func() {
+		ctx := context.Background()
+
+		workspace := createWorkspaceAndWaitForAgentPoolDeployment(ctx, "test-workflow-agents-scale")
+
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.Workflows.Agents[0].Replicas = pointer.Int32(2)
+		})
+
 		Eventually(func() error {
 			statefulSet, err := getWorkflowAgentPool(workspace, "test")
 
@@ -116,10 +181,9 @@ var _ = Describe("reconcileWorkflowServer", Ordered, func() {
 
 		workspace := createWorkspaceAndWaitForAgentPoolDeployment(ctx, "test-workflow-agents-image")
 
-		workspace.Spec.Workflows.Agents[0].Image = "willemmeints/workflow-agent:unknown"
-
-		err := k8sClient.Update(ctx, workspace)
-		Expect(err).NotTo(HaveOccurred())
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.Workflows.Agents[0].Image = "willemmeints/workflow-agent:unknown"
+		})
 
 		Eventually(func() error {
 			statefulSet, err := getWorkflowAgentPool(workspace, "test")
@@ -140,19 +204,18 @@ var _ = Describe("reconcileWorkflowServer", Ordered, func() {
 		ctx := context.Background()
 		workspace := createWorkspaceAndWaitForAgentPoolDeployment(ctx, "test-workflow-agents-vertical-scaling")
 
-		workspace.Spec.Workflows.Agents[0].Resources = corev1.ResourceRequirements{
-			Limits: corev1.ResourceList{
-				corev1.ResourceCPU:    resource.MustParse("2"),
-				corev1.ResourceMemory: resource.MustParse("2Gi"),
-			},
-			Requests: corev1.ResourceList{
-				corev1.ResourceCPU:    resource.MustParse("1"),
-				corev1.ResourceMemory: resource.MustParse("1Gi"),
-			},
-		}
-
-		err := k8sClient.Update(ctx, workspace)
-		Expect(err).NotTo(HaveOccurred())
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.Workflows.Agents[0].Resources = corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("2"),
+					corev1.ResourceMemory: resource.MustParse("2Gi"),
+				},
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				},
+			}
+		})
 
 		Eventually(func() error {
 			statefulSet, err := getWorkflowAgentPool(workspace, "test")
@@ -185,10 +248,9 @@ var _ = Describe("reconcileWorkflowServer", Ordered, func() {
 		ctx := context.Background()
 		workspace := createWorkspaceAndWaitForAgentPoolDeployment(ctx, "test-workflow-controller-replicas")
 
-		workspace.Spec.Workflows.Controller.Replicas = pointer.Int32(2)
-
-		err := k8sClient.Update(ctx, workspace)
-		Expect(err).NotTo(HaveOccurred())
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.Workflows.Controller.Replicas = pointer.Int32(2)
+		})
 
 		Eventually(func() error {
 			typedDeploymentName := types.NamespacedName{
@@ -215,10 +277,9 @@ var _ = Describe("reconcileWorkflowServer", Ordered, func() {
 		ctx := context.Background()
 		workspace := createWorkspaceAndWaitForAgentPoolDeployment(ctx, "test-workflow-controller-image")
 
-		workspace.Spec.Workflows.Controller.Image = "willemmeints/workflow-controller:unknown"
-
-		err := k8sClient.Update(ctx, workspace)
-		Expect(err).NotTo(HaveOccurred())
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.Workflows.Controller.Image = "willemmeints/workflow-controller:unknown"
+		})
 
 		Eventually(func() error {
 			typedDeploymentName := types.NamespacedName{
@@ -245,19 +306,18 @@ var _ = Describe("reconcileWorkflowServer", Ordered, func() {
 		ctx := context.Background()
 		workspace := createWorkspaceAndWaitForAgentPoolDeployment(ctx, "test-workflow-controller-resources")
 
-		workspace.Spec.Workflows.Controller.Resources = corev1.ResourceRequirements{
-			Limits: corev1.ResourceList{
-				corev1.ResourceCPU:    resource.MustParse("2"),
-				corev1.ResourceMemory: resource.MustParse("2Gi"),
-			},
-			Requests: corev1.ResourceList{
-				corev1.ResourceCPU:    resource.MustParse("1"),
-				corev1.ResourceMemory: resource.MustParse("1Gi"),
-			},
-		}
-
-		err := k8sClient.Update(ctx, workspace)
-		Expect(err).NotTo(HaveOccurred())
+		updateWorkspace(ctx, workspace, func(workspace *mlopsv1alpha1.Workspace) {
+			workspace.Spec.Workflows.Controller.Resources = corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("2"),
+					corev1.ResourceMemory: resource.MustParse("2Gi"),
+				},
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				},
+			}
+		})
 
 		Eventually(func() error {
 			typedDeploymentName := types.NamespacedName{
@@ -291,8 +351,155 @@ var _ = Describe("reconcileWorkflowServer", Ordered, func() {
 			return nil
 		}, time.Minute, time.Second).Should(Succeed())
 	})
+
+	It("Should restore the image of the workflow agents when it is changed outside the operator", func() {
+		ctx := context.Background()
+		workspace := createWorkspaceAndWaitForAgentPoolDeployment(ctx, "test-workflow-agents-drift")
+
+		Eventually(func() error {
+			statefulSet, err := getWorkflowAgentPool(workspace, "test")
+
+			if err != nil {
+				return err
+			}
+
+			statefulSet.Spec.Template.Spec.Containers[0].Image = "willemmeints/workflow-agent:modified"
+
+			return k8sClient.Update(ctx, statefulSet)
+		}, time.Minute, time.Second).Should(Succeed())
+
+		Eventually(func() error {
+			statefulSet, err := getWorkflowAgentPool(workspace, "test")
+
+			if err != nil {
+				return err
+			}
+
+			if statefulSet.Spec.Template.Spec.Containers[0].Image != workspace.Spec.Workflows.Agents[0].Image {
+				return fmt.Errorf("expected image to be '%s', got %s", workspace.Spec.Workflows.Agents[0].Image, statefulSet.Spec.Template.Spec.Containers[0].Image)
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+	})
+
+	It("Should deploy all agent pools in the workspace", func() {
+		ctx := context.Background()
+		workspace := newTestWorkspace("test-workflow-agents-multiple")
+
+		poolNames := []string{"test", "training", "inference", "reporting", "batch"}
+
+		for _, poolName := range poolNames[1:] {
+			workspace.Spec.Workflows.Agents = append(workspace.Spec.Workflows.Agents, mlopsv1alpha1.WorkflowAgentPoolSpec{
+				Name:     poolName,
+				Image:    "willemmeints/workflow-agent:latest",
+				Replicas: pointer.Int32(1),
+			})
+		}
+
+		err := k8sClient.Create(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+
+		simulateDatabaseReady(ctx, workspace)
+
+		Eventually(func() error {
+			for _, poolName := range poolNames {
+				if _, err := getWorkflowAgentPool(workspace, poolName); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+
+		Eventually(func() error {
+			for _, poolName := range poolNames {
+				statefulSet, err := getWorkflowAgentPool(workspace, poolName)
+
+				if err != nil {
+					return err
+				}
+
+				statefulSet.Spec.Template.Spec.Containers[0].Image = "willemmeints/workflow-agent:unknown"
+
+				if err := k8sClient.Update(ctx, statefulSet); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+
+		Eventually(func() error {
+			for _, poolName := range poolNames {
+				statefulSet, err := getWorkflowAgentPool(workspace, poolName)
+
+				if err != nil {
+					return err
+				}
+
+				if statefulSet.Spec.Template.Spec.Containers[0].Image != "willemmeints/workflow-agent:latest" {
+					return fmt.Errorf("expected image for pool %s to be 'willemmeints/workflow-agent:latest', got %s", poolName, statefulSet.Spec.Template.Spec.Containers[0].Image)
+				}
+			}
+
+			return nil
+		}, time.Minute, time.Second).Should(Succeed())
+	})
+
+	It("Should remove agent pools that are no longer in the workspace", func() {
+		ctx := context.Background()
+		workspace := newTestWorkspace("test-workflow-agents-prune")
+
+		workspace.Spec.Workflows.Agents = append(workspace.Spec.Workflows.Agents, mlopsv1alpha1.WorkflowAgentPoolSpec{
+			Name:     "removed",
+			Image:    "willemmeints/workflow-agent:latest",
+			Replicas: pointer.Int32(1),
+		})
+
+		err := k8sClient.Create(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+
+		simulateDatabaseReady(ctx, workspace)
+
+		Eventually(func() error {
+			_, err := getWorkflowAgentPool(workspace, "removed")
+			return err
+		}, time.Minute, time.Second).Should(Succeed())
+
+		Eventually(func() error {
+			updatedWorkspace, err := getWorkspace(workspace)
+
+			if err != nil {
+				return err
+			}
+
+			updatedWorkspace.Spec.Workflows.Agents = updatedWorkspace.Spec.Workflows.Agents[:1]
+
+			return k8sClient.Update(ctx, updatedWorkspace)
+		}, time.Minute, time.Second).Should(Succeed())
+
+		Eventually(func() bool {
+			_, err := getWorkflowAgentPool(workspace, "removed")
+			return errors.IsNotFound(err)
+		}, time.Minute, time.Second).Should(BeTrue())
+
+		_, err = getWorkflowAgentPool(workspace, "test")
+		Expect(err).NotTo(HaveOccurred())
+	})
 })
 
+func getWorkflowServerDeployment(workspace *mlopsv1alpha1.Workspace) (*appsv1.Deployment, error) {
+	deploymentName := types.NamespacedName{
+		Name:      fmt.Sprintf("%s-orion-server", workspace.GetName()),
+		Namespace: workspace.GetNamespace(),
+	}
+
+	deployment := &appsv1.Deployment{}
+
+	return deployment, k8sClient.Get(context.Background(), deploymentName, deployment)
+}
+
 func getWorkflowAgentPool(workspace *mlopsv1alpha1.Workspace, poolName string) (*appsv1.StatefulSet, error) {
 	statefulSetName := fmt.Sprintf("%s-agent-%s", workspace.GetName(), poolName)
 
@@ -318,6 +525,8 @@ func createWorkspaceAndWaitForAgentPoolDeployment(ctx context.Context, name stri
 	err := k8sClient.Create(ctx, workspace)
 	Expect(err).NotTo(HaveOccurred())
 
+	simulateDatabaseReady(ctx, workspace)
+
 	Eventually(func() error {
 		_, err := getWorkflowAgentPool(workspace, "test")
 		return err
@@ -325,3 +534,94 @@ func createWorkspaceAndWaitForAgentPoolDeployment(ctx context.Context, name stri
 
 	return workspace
 }
+
+// The migration script runs against a real postgres server, set TEST_POSTGRES_URI to the uri of a user that can create databases.
+var _ = Describe("workflowDatabaseMigrationScript", Ordered, func() {
+	var sourceURI, targetURI string
+
+	BeforeAll(func() {
+		serverURI := os.Getenv("TEST_POSTGRES_URI")
+
+		if serverURI == "" {
+			Skip("TEST_POSTGRES_URI is not set")
+		}
+
+		for _, tool := range []string{"psql", "pg_dump"} {
+			if _, err := exec.LookPath(tool); err != nil {
+				Skip(fmt.Sprintf("%s is not installed", tool))
+			}
+		}
+
+		for _, databaseName := range []string{"migration_source", "migration_target"} {
+			runSQL(serverURI, fmt.Sprintf("DROP DATABASE IF EXISTS %s", databaseName))
+			runSQL(serverURI, fmt.Sprintf("CREATE DATABASE %s", databaseName))
+		}
+
+		parsedURI, err := url.Parse(serverURI)
+		Expect(err).NotTo(HaveOccurred())
+
+		parsedURI.Path = "/migration_source"
+		sourceURI = parsedURI.String()
+
+		parsedURI.Path = "/migration_target"
+		targetURI = parsedURI.String()
+
+		workflowSchema := `
+			CREATE TABLE alembic_version (version_num varchar(32) PRIMARY KEY);
+			CREATE TABLE block_type (id uuid PRIMARY KEY, slug text UNIQUE NOT NULL);
+			CREATE TABLE block_document (id uuid PRIMARY KEY, name text, block_type_id uuid NOT NULL REFERENCES block_type (id));
+			CREATE TABLE flow (id uuid PRIMARY KEY, name text UNIQUE NOT NULL);`
+
+		// The experiment tracking database contains the MLflow tables next to the workflow state.
+		runSQL(sourceURI, workflowSchema+`
+			CREATE TABLE experiments (experiment_id serial PRIMARY KEY, name text);
+			INSERT INTO alembic_version VALUES ('legacy');
+			INSERT INTO experiments (name) VALUES ('Default');
+			INSERT INTO block_type VALUES ('00000000-0000-0000-0000-000000000001', 'secret');
+			INSERT INTO block_document VALUES ('00000000-0000-0000-0000-000000000002', 'api-key', '00000000-0000-0000-0000-000000000001');
+			INSERT INTO flow VALUES ('00000000-0000-0000-0000-000000000003', 'train');`)
+
+		// The workflow server seeds the block types with its own ids when it starts.
+		runSQL(targetURI, workflowSchema+`
+			INSERT INTO alembic_version VALUES ('current');
+			INSERT INTO block_type VALUES ('00000000-0000-0000-0000-000000000009', 'secret');`)
+	})
+
+	It("Should copy the workflow state over the seeded rows", func() {
+		Expect(runMigrationScript(sourceURI, targetURI)).To(Succeed())
+
+		Expect(querySQL(targetURI, "SELECT name FROM flow")).To(Equal("train"))
+		Expect(querySQL(targetURI, "SELECT id FROM block_type")).To(Equal("00000000-0000-0000-0000-000000000001"))
+		Expect(querySQL(targetURI, "SELECT name FROM block_document")).To(Equal("api-key"))
+		Expect(querySQL(targetURI, "SELECT version_num FROM alembic_version")).To(Equal("current"))
+		Expect(querySQL(targetURI, "SELECT to_regclass('public.experiments') IS NULL")).To(Equal("t"))
+	})
+
+	It("Should not copy the workflow state again", func() {
+		Expect(runMigrationScript(sourceURI, targetURI)).To(Succeed())
+		Expect(querySQL(targetURI, "SELECT count(*) FROM flow")).To(Equal("1"))
+	})
+})
+
+func runMigrationScript(sourceURI string, targetURI string) error {
+	command := exec.Command("/bin/sh", "-c", workflowDatabaseMigrationScript)
+	command.Env = append(os.Environ(), "SOURCE_URI="+sourceURI, "TARGET_URI="+targetURI)
+	command.Stdout = GinkgoWriter
+	command.Stderr = GinkgoWriter
+
+	return command.Run()
+}
+
+func runSQL(uri string, sql string) {
+	command := exec.Command("psql", uri, "-v", "ON_ERROR_STOP=1", "-c", sql)
+	command.Stderr = GinkgoWriter
+
+	Expect(command.Run()).To(Succeed())
+}
+
+func querySQL(uri string, sql string) string {
+	output, err := exec.Command("psql", uri, "-tAc", sql).Output()
+	Expect(err).NotTo(HaveOccurred())
+
+	return strings.TrimSpace(string(output))
+}
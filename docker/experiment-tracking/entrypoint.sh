@@ -4,5 +4,11 @@ ENCODED_PASSWORD=$(python -c "import urllib.parse; print(urllib.parse.quote_plus
 
 # Make sure we have sensible defaults for the registry and backend storage.
 MLFLOW_BACKEND_STORE="postgresql://${DB_USER}:${ENCODED_PASSWORD}@${DB_HOST}:${DB_PORT}/${DB_NAME}"
- 
-mlflow server --backend-store-uri "${MLFLOW_BACKEND_STORE}" --host 0.0.0.0
+
+# The server proxies all artifact requests, so clients don't need credentials for the artifact storage.
+# The root filesystem is read-only, so without a backend the artifacts go to the volume the operator mounts.
+ARTIFACTS_DESTINATION="${MLFLOW_ARTIFACTS_DESTINATION:-/mlflow/artifacts}"
+
+# With path routing the operator sets the prefix the user interface is served under.
+mlflow server --backend-store-uri "${MLFLOW_BACKEND_STORE}" --host 0.0.0.0 --serve-artifacts --artifacts-destination "${ARTIFACTS_DESTINATION}" \
+  ${MLFLOW_STATIC_PREFIX:+--static-prefix "${MLFLOW_STATIC_PREFIX}"}
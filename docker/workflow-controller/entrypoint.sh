@@ -1,2 +1,9 @@
-PREFECT_ORION_DATABASE_CONNECTION_URL="postgresql+asyncpg://${DB_USER}:${DB_PASS}@${DB_HOST}:${DB_PORT}/${DB_NAME}"
-prefect orion start --host 0.0.0.0 --port 4200 --log-level WARNING
\ No newline at end of file
+# The password in the uri from the database secret is url-encoded, so we only have to swap the scheme for the async driver.
+export PREFECT_API_DATABASE_CONNECTION_URL="postgresql+asyncpg://${DB_URI#postgresql://}"
+
+# Arguments run a prefect command against the database instead of the server, like the schema upgrade before a migration.
+if [ "$#" -gt 0 ]; then
+  exec prefect "$@"
+fi
+
+exec prefect server start --host 0.0.0.0 --port 4200 --log-level WARNING